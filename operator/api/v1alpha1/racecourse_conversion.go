@@ -0,0 +1,171 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// Holds the v1beta1 spec and status of an object served as v1alpha1 whenever v1alpha1
+// can't express all of it, so that converting back to v1beta1 loses nothing
+const ConversionDataAnnotation = "racecourse.kaleido.io/conversion-data"
+
+// The payload of the conversion data annotation
+type conversionData struct {
+	Spec   v1beta1.RacecourseSpec   `json:"spec"`
+	Status v1beta1.RacecourseStatus `json:"status"`
+}
+
+// ConvertTo converts this Racecourse (v1alpha1) to the Hub version (v1beta1).
+func (src *Racecourse) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.Racecourse)
+	if !ok {
+		return fmt.Errorf("expected a v1beta1 Racecourse but got %T", dstRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	// Start from the stashed v1beta1 object, if any, so fields v1alpha1 doesn't know
+	// about survive, then lay everything v1alpha1 does know about on top of it
+	restored, err := popConversionData(dst)
+	if err != nil {
+		return err
+	}
+	if restored != nil {
+		dst.Spec = restored.Spec
+		dst.Status = restored.Status
+	}
+
+	dst.Spec.Image = v1beta1.ImageSpec(src.Spec.Image)
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.Resources = src.Spec.Resources
+	dst.Spec.ContractAddress = src.Spec.ContractAddress
+
+	// A URL endpoint shows up as an empty wallet Service in v1alpha1, so keep it
+	// unless a v1alpha1 client has since filled a Service in
+	if src.Spec.WalletService.Name != "" || dst.Spec.Wallet.Type != v1beta1.WalletEndpointTypeURL {
+		service := v1beta1.WalletServiceRef(src.Spec.WalletService)
+		dst.Spec.Wallet = v1beta1.WalletEndpoint{
+			Type:    v1beta1.WalletEndpointTypeService,
+			Service: &service,
+		}
+	}
+
+	if restored == nil || dst.Spec.Ingress.IsEnabled() != src.Spec.Ingress.Enabled {
+		dst.Spec.Ingress.Enabled = ptr.To(src.Spec.Ingress.Enabled)
+	}
+	dst.Spec.Ingress.ClassName = src.Spec.Ingress.ClassName
+	dst.Spec.Ingress.Host = src.Spec.Ingress.Host
+	dst.Spec.Ingress.Path = src.Spec.Ingress.Path
+	dst.Spec.Ingress.Annotations = src.Spec.Ingress.Annotations
+
+	dst.Status.Phase = v1beta1.RacecoursePhase(src.Status.Phase)
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Conditions = src.Status.Conditions
+	dst.Status.DeploymentReady = src.Status.DeploymentReady
+	dst.Status.AvailableReplicas = src.Status.AvailableReplicas
+	dst.Status.URL = src.Status.URL
+	dst.Status.WalletServiceEndpoint = src.Status.WalletServiceEndpoint
+
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1beta1) to this version (v1alpha1).
+func (dst *Racecourse) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.Racecourse)
+	if !ok {
+		return fmt.Errorf("expected a v1beta1 Racecourse but got %T", srcRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec.Image = ImageSpec(src.Spec.Image)
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.Resources = src.Spec.Resources
+	dst.Spec.ContractAddress = src.Spec.ContractAddress
+
+	dst.Spec.WalletService = WalletServiceSpec{}
+	if src.Spec.Wallet.Service != nil {
+		dst.Spec.WalletService = WalletServiceSpec(*src.Spec.Wallet.Service)
+	}
+
+	dst.Spec.Ingress = IngressSpec{
+		Enabled:     src.Spec.Ingress.IsEnabled(),
+		ClassName:   src.Spec.Ingress.ClassName,
+		Host:        src.Spec.Ingress.Host,
+		Path:        src.Spec.Ingress.Path,
+		Annotations: src.Spec.Ingress.Annotations,
+	}
+
+	dst.Status = RacecourseStatus{
+		Phase:                 RacecoursePhase(src.Status.Phase),
+		ObservedGeneration:    src.Status.ObservedGeneration,
+		Conditions:            src.Status.Conditions,
+		DeploymentReady:       src.Status.DeploymentReady,
+		AvailableReplicas:     src.Status.AvailableReplicas,
+		URL:                   src.Status.URL,
+		WalletServiceEndpoint: src.Status.WalletServiceEndpoint,
+	}
+
+	// Only stash the v1beta1 object when converting back would not reproduce it
+	roundTrip := &v1beta1.Racecourse{}
+	if err := dst.DeepCopy().ConvertTo(roundTrip); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(roundTrip.Spec, src.Spec) && equality.Semantic.DeepEqual(roundTrip.Status, src.Status) {
+		return nil
+	}
+
+	data, err := json.Marshal(conversionData{Spec: src.Spec, Status: src.Status})
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[ConversionDataAnnotation] = string(data)
+
+	return nil
+}
+
+// Removes the conversion data annotation from the object and returns its payload,
+// or nil if there wasn't one
+func popConversionData(racecourse *v1beta1.Racecourse) (*conversionData, error) {
+	raw, ok := racecourse.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	delete(racecourse.Annotations, ConversionDataAnnotation)
+	if len(racecourse.Annotations) == 0 {
+		racecourse.Annotations = nil
+	}
+
+	data := &conversionData{}
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		return nil, fmt.Errorf("unable to read %s annotation: %w", ConversionDataAnnotation, err)
+	}
+
+	return data, nil
+}
@@ -27,6 +27,7 @@ import (
 // The desired state of Racecourse instance
 type RacecourseSpec struct {
 	// Sets the container image
+	// +kubebuilder:default={}
 	// +optional
 	Image ImageSpec `json:"image,omitempty"`
 
@@ -51,6 +52,7 @@ type RacecourseSpec struct {
 	ContractAddress string `json:"contractAddress,omitempty"`
 
 	// The ingress configuration
+	// +kubebuilder:default={}
 	// +optional
 	Ingress IngressSpec `json:"ingress,omitempty"`
 }
@@ -102,11 +104,12 @@ type IngressSpec struct {
 	// +optional
 	ClassName string `json:"className,omitempty"`
 
-	// The hostname for the ingress
+	// The hostname for the ingress. Defaults to racecourse.local while the ingress is enabled.
 	// +optional
 	Host string `json:"host,omitempty"`
 
 	// The path for the ingress
+	// +kubebuilder:default="/"
 	// +optional
 	Path string `json:"path,omitempty"`
 
@@ -121,7 +124,13 @@ type RacecourseStatus struct {
 	// +optional
 	Phase RacecoursePhase `json:"phase,omitempty"`
 
+	// The generation of the spec that the status was last computed from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// The latest available observations of the Racecourse instance's state
+	// +listType=map
+	// +listMapKey=type
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
@@ -159,6 +168,7 @@ const (
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:deprecatedversion:warning="racecourse.kaleido.io/v1alpha1 Racecourse is deprecated; use racecourse.kaleido.io/v1beta1"
 // +kubebuilder:resource:shortName=rcs
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.availableReplicas`
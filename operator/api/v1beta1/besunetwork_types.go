@@ -0,0 +1,355 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The network a BesuNetwork runs. Everything written into the genesis block is fixed
+// once the network is created.
+type BesuNetworkSpec struct {
+	// The number of validator nodes to run. The nodes that exist when the network is
+	// created make up the validator set written into the genesis block. Changing it later
+	// has the validators vote nodes into or out of the validator set on the live chain.
+	// +kubebuilder:default=4
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Validators int32 `json:"validators,omitempty"`
+
+	// The chain ID of the network
+	// +kubebuilder:default=1337
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="is fixed by the genesis block"
+	// +optional
+	ChainID int64 `json:"chainId,omitempty"`
+
+	// The number of seconds between blocks
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="is fixed by the genesis block"
+	// +optional
+	BlockPeriodSeconds int64 `json:"blockPeriodSeconds,omitempty"`
+
+	// The number of blocks after which pending validator votes are discarded
+	// +kubebuilder:default=30000
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="is fixed by the genesis block"
+	// +optional
+	EpochLength int64 `json:"epochLength,omitempty"`
+
+	// The block gas limit, as a hex quantity
+	// +kubebuilder:default="0x1fffffffffffff"
+	// +kubebuilder:validation:Pattern=`^0x[0-9a-fA-F]+$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="is fixed by the genesis block"
+	// +optional
+	GasLimit string `json:"gasLimit,omitempty"`
+
+	// The accounts funded in the genesis block, by address
+	// +kubebuilder:validation:MaxProperties=256
+	// +kubebuilder:validation:XValidation:rule="self.all(address, address.matches('^0x[0-9a-fA-F]{40}$'))",message="keys must be 0x-prefixed 20 byte addresses"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="is fixed by the genesis block"
+	// +optional
+	Alloc map[string]GenesisAccount `json:"alloc,omitempty"`
+
+	// The Besu container image
+	// +kubebuilder:default="hyperledger/besu:latest"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The image pull policy
+	// +kubebuilder:default="IfNotPresent"
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Defines resource requests/limits on the nodes
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// The volume each node keeps the chain in
+	// +optional
+	Storage BesuStorageSpec `json:"storage,omitempty"`
+
+	// How the nodes are upgraded when their pod spec, like the image, changes
+	// +optional
+	Upgrade BesuUpgradeSpec `json:"upgrade,omitempty"`
+
+	// How many blocks a node's head may be behind the highest head on the network before
+	// the network is reported degraded
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBlockLag *int64 `json:"maxBlockLag,omitempty"`
+}
+
+// An account funded in the genesis block
+type GenesisAccount struct {
+	// The balance in wei, as a hex quantity
+	// +kubebuilder:validation:Pattern=`^0x[0-9a-fA-F]+$`
+	Balance string `json:"balance"`
+}
+
+// Defines the volume each node keeps the chain in
+type BesuStorageSpec struct {
+	// The size of the volume
+	// +kubebuilder:default="2Gi"
+	// +optional
+	Size resource.Quantity `json:"size,omitempty"`
+
+	// The storage class of the volume. If unset, the cluster's default class is used.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// Defines how the nodes are upgraded. They are restarted one at a time, and the next
+// only once the last has caught up with the chain, regained its peers and seen a new block,
+// so the validators never drop below the quorum QBFT needs to produce blocks.
+type BesuUpgradeSpec struct {
+	// How long the network may go without a new block during an upgrade before the
+	// upgrade halts. It carries on once blocks are produced again.
+	// +kubebuilder:default="2m"
+	// +optional
+	StallTimeout *metav1.Duration `json:"stallTimeout,omitempty"`
+}
+
+// The observed state of BesuNetwork
+type BesuNetworkStatus struct {
+	// The generation of the spec that the status was last computed from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The latest available observations of the network's state
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// The addresses of the validators written into the genesis block
+	// +optional
+	GenesisValidators []string `json:"genesisValidators,omitempty"`
+
+	// The validators of the chain as of its latest block
+	// +optional
+	Validators []string `json:"validators,omitempty"`
+
+	// The changes to the validator set that the spec asks for and the chain hasn't made yet
+	// +optional
+	PendingValidatorChanges []BesuValidatorChange `json:"pendingValidatorChanges,omitempty"`
+
+	// The latest changes made to the validator set, oldest first
+	// +optional
+	AppliedValidatorChanges []BesuValidatorChange `json:"appliedValidatorChanges,omitempty"`
+
+	// The nodes of the network, in ordinal order
+	// +optional
+	Nodes []BesuNodeStatus `json:"nodes,omitempty"`
+
+	// The public key of every node's key by node name, derived from the keys Secret once
+	// so the private keys aren't parsed again on every reconcile
+	// +optional
+	NodePublicKeys map[string]string `json:"nodePublicKeys,omitempty"`
+
+	// The number of nodes whose pods are ready
+	// +optional
+	ReadyNodes int32 `json:"readyNodes,omitempty"`
+
+	// The highest block any node has reported
+	// +optional
+	HeadBlock int64 `json:"headBlock,omitempty"`
+
+	// When the head block was first seen
+	// +optional
+	HeadBlockTime *metav1.Time `json:"headBlockTime,omitempty"`
+
+	// The progress of the upgrade of the nodes, while one is under way
+	// +optional
+	Upgrade *BesuUpgradeStatus `json:"upgrade,omitempty"`
+
+	// The in-cluster JSON-RPC endpoint of the network, for a Racecourse's wallet or signer
+	// +optional
+	RPCURL string `json:"rpcURL,omitempty"`
+
+	// The in-cluster WebSocket JSON-RPC endpoint of the network
+	// +optional
+	WebSocketURL string `json:"webSocketURL,omitempty"`
+}
+
+// Identifies a node of the network
+type BesuNodeStatus struct {
+	// The name of the node's pod
+	Name string `json:"name"`
+
+	// The account address of the node's key, which names it in the validator set
+	Address string `json:"address"`
+
+	// The enode URL peers reach the node at
+	Enode string `json:"enode"`
+
+	// Whether the node's pod is ready
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+
+	// Whether the node answered its health checks over JSON-RPC. The fields below are
+	// only reported for a node that did.
+	// +optional
+	Responding bool `json:"responding,omitempty"`
+
+	// The number of peers the node is connected to
+	// +optional
+	Peers int32 `json:"peers,omitempty"`
+
+	// Whether the node is still catching up with the chain
+	// +optional
+	Syncing bool `json:"syncing,omitempty"`
+
+	// The number of the node's latest block
+	// +optional
+	HeadBlock int64 `json:"headBlock,omitempty"`
+
+	// How many blocks the node's head is behind the highest head on the network
+	// +optional
+	BlocksBehind int64 `json:"blocksBehind,omitempty"`
+
+	// Whether the node is in the current validator set
+	// +optional
+	Validator bool `json:"validator,omitempty"`
+
+	// Why the node didn't answer its health checks
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Whether a node is added to or removed from the validator set
+// +kubebuilder:validation:Enum=Add;Remove
+type BesuValidatorAction string
+
+const (
+	BesuValidatorActionAdd    BesuValidatorAction = "Add"
+	BesuValidatorActionRemove BesuValidatorAction = "Remove"
+)
+
+// A change to the validator set
+type BesuValidatorChange struct {
+	// The node added or removed
+	Node string `json:"node"`
+
+	// The account address of the node's key
+	Address string `json:"address"`
+
+	// Whether the node is added or removed
+	Action BesuValidatorAction `json:"action"`
+
+	// The number of validators voting for a pending change
+	// +optional
+	Votes int32 `json:"votes,omitempty"`
+
+	// What a pending change is waiting for
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The head block when an applied change was first seen
+	// +optional
+	Block int64 `json:"block,omitempty"`
+}
+
+// The progress of an upgrade of the nodes
+type BesuUpgradeStatus struct {
+	// The StatefulSet revision the nodes are being upgraded to
+	Revision string `json:"revision"`
+
+	// The number of nodes running the revision
+	UpdatedNodes int32 `json:"updatedNodes"`
+
+	// The node restarted on the revision that the upgrade is waiting on
+	// +optional
+	Node string `json:"node,omitempty"`
+
+	// The number of peers the node had before it was restarted, which it has to regain
+	// +optional
+	PeersBefore int32 `json:"peersBefore,omitempty"`
+
+	// The head block of the node once it had caught up. The upgrade moves on once the
+	// node sees a later block.
+	// +optional
+	SyncedBlock *int64 `json:"syncedBlock,omitempty"`
+
+	// The highest block seen on the network during the upgrade
+	HeadBlock int64 `json:"headBlock"`
+
+	// When the head block was first seen
+	HeadBlockTime metav1.Time `json:"headBlockTime"`
+}
+
+// The reasons of the Available, Progressing and Degraded conditions of a BesuNetwork, besides
+// those shared with Racecourse
+const (
+	// Enough validators are ready for the network to produce blocks
+	ReasonQuorumAvailable = "QuorumAvailable"
+	// Too few validators are ready for the network to produce blocks
+	ReasonQuorumUnavailable = "QuorumUnavailable"
+	// The nodes' StatefulSet doesn't exist yet
+	ReasonStatefulSetNotFound = "StatefulSetNotFound"
+	// A node has been restarted on the new revision and the upgrade is waiting on it
+	ReasonUpgradingNode = "UpgradingNode"
+	// The upgrade is waiting for every node to be ready before restarting the next
+	ReasonWaitingForNodes = "WaitingForNodes"
+	// No block has been produced for longer than the stall timeout, so the upgrade has halted
+	ReasonBlockProductionStalled = "BlockProductionStalled"
+	// The chain has stopped producing blocks, or too few validators are up to date with it
+	// to keep doing so
+	ReasonValidatorsNotProducing = "ValidatorsNotProducing"
+	// A node's head is further behind the network than spec.maxBlockLag
+	ReasonNodesLagging = "NodesLagging"
+	// The genesis ConfigMap is gone after the chain was started from it
+	ReasonGenesisLost = "GenesisLost"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=besu
+// +kubebuilder:printcolumn:name="Validators",type=integer,JSONPath=`.spec.validators`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyNodes`
+// +kubebuilder:printcolumn:name="Chain ID",type=integer,JSONPath=`.spec.chainId`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// A private Besu network of QBFT validators. The operator generates the node keys and
+// the genesis block, and runs the nodes as a StatefulSet that peer with each other.
+type BesuNetwork struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BesuNetworkSpec   `json:"spec,omitempty"`
+	Status BesuNetworkStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A list of BesuNetwork instances
+type BesuNetworkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BesuNetwork `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BesuNetwork{}, &BesuNetworkList{})
+}
@@ -0,0 +1,486 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Defaults written into the stored spec by the defaulting webhook
+const (
+	DefaultReplicas          int32 = 2
+	DefaultImageRepository         = "racecourse"
+	DefaultImageTag                = "0.0.1"
+	DefaultImagePullPolicy         = corev1.PullIfNotPresent
+	DefaultWalletServicePort int32 = 8545
+	DefaultIngressClassName        = "nginx"
+	DefaultIngressHost             = "racecourse.local"
+	DefaultIngressPath             = "/"
+)
+
+// The desired state of Racecourse instance
+type RacecourseSpec struct {
+	// Sets the container image
+	// +kubebuilder:default={}
+	// +optional
+	Image ImageSpec `json:"image,omitempty"`
+
+	// The number of racecourse pods to run
+	// +kubebuilder:default=2
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Defines resource requests/limits on the pods
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Wallet defines the JSON-RPC endpoint of the wallet (signer) the app connects to
+	Wallet WalletEndpoint `json:"wallet"`
+
+	// The Ethereum address of the deployed Race contract
+	// If empty, the operator deploys a Race contract through the wallet and records
+	// its address in status.contractAddress
+	// Must be either empty or valid 42-character hex string
+	// +kubebuilder:validation:Pattern=`^(0x[a-fA-F0-9]{40})?$`
+	// +optional
+	ContractAddress string `json:"contractAddress,omitempty"`
+
+	// The ingress configuration
+	// +kubebuilder:default={}
+	// +optional
+	Ingress IngressSpec `json:"ingress,omitempty"`
+
+	// Pins the chain the wallet must be on. If unset, any chain is accepted.
+	// +optional
+	Chain *ChainSpec `json:"chain,omitempty"`
+
+	// Where to send CloudEvents about the race and the Racecourse. If unset, none are sent.
+	// +optional
+	EventSink *EventSinkSpec `json:"eventSink,omitempty"`
+
+	// How often to check the wallet, chain and contract again when nothing has changed.
+	// If unset, the operator's --resync-interval applies.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+}
+
+// An HTTP endpoint that accepts CloudEvents in binary content mode
+type EventSinkSpec struct {
+	// The URL events are POSTed to
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// A key of a Secret in the Racecourse's namespace holding a bearer token to send
+	// with every event
+	// +optional
+	AuthSecretRef *corev1.SecretKeySelector `json:"authSecretRef,omitempty"`
+}
+
+// Identifies the chain a Racecourse expects its wallet to be on
+type ChainSpec struct {
+	// The chain ID the wallet must report from eth_chainId
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ChainID int64 `json:"chainId,omitempty"`
+
+	// The hash of block 0, which tells apart networks that share a chain ID
+	// +kubebuilder:validation:Pattern=`^0x[a-fA-F0-9]{64}$`
+	// +optional
+	GenesisHash string `json:"genesisHash,omitempty"`
+}
+
+// Defines the configuration for the container image
+type ImageSpec struct {
+	// The repository from which to pull the image
+	// +kubebuilder:default="racecourse"
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// Tags associated with the image
+	// +kubebuilder:default="0.0.1"
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// The image pull policy
+	// +kubebuilder:default="IfNotPresent"
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +optional
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+}
+
+// The kind of wallet endpoint
+// +kubebuilder:validation:Enum=Service;URL
+type WalletEndpointType string
+
+const (
+	// The wallet is a Kubernetes Service in the cluster
+	WalletEndpointTypeService WalletEndpointType = "Service"
+	// The wallet is reached through an explicit URL
+	WalletEndpointTypeURL WalletEndpointType = "URL"
+)
+
+// Defines where the wallet JSON-RPC endpoint lives. Exactly one of the members
+// must be set, matching the type.
+// +kubebuilder:validation:XValidation:rule="self.type == 'Service' ? has(self.service) && !has(self.url) : has(self.url) && !has(self.service)",message="service must be set when type is Service, and url must be set when type is URL"
+// +union
+type WalletEndpoint struct {
+	// The kind of endpoint that is set
+	// +unionDiscriminator
+	Type WalletEndpointType `json:"type"`
+
+	// The Kubernetes Service associated with the wallet
+	// +optional
+	Service *WalletServiceRef `json:"service,omitempty"`
+
+	// The URL of the wallet JSON-RPC endpoint, for wallets running outside the cluster
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// The WebSocket JSON-RPC endpoint of the wallet or the node behind it. When set,
+	// the operator subscribes to new blocks there and reads the race state when a
+	// block may hold the contract's events, instead of polling for it.
+	// +kubebuilder:validation:Pattern=`^wss?://`
+	// +optional
+	WebSocketURL string `json:"webSocketURL,omitempty"`
+}
+
+// Defines how to connect to a wallet running as a Kubernetes Service
+type WalletServiceRef struct {
+	// The name of the Kubernetes Service associated with the wallet service
+	Name string `json:"name"`
+
+	// The namespace where the wallet Service is located
+	// If empty, it defaults to the same namespace as the Racecourse instance
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// The JSON-RPC port associated with the wallet service
+	// +kubebuilder:default=8545
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// Defines the configuration for ingress
+type IngressSpec struct {
+	// Determines if an ingress resource should be created
+	// If unset, an ingress is created
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// The name of the ingress class
+	// +kubebuilder:default="nginx"
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// The hostname for the ingress. Defaults to racecourse.local while the ingress is enabled.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// The path for the ingress
+	// +kubebuilder:default="/"
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Additional pod-level annotations for the ingress resource
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Terminates TLS at the ingress for the host
+	// +optional
+	TLS *IngressTLS `json:"tls,omitempty"`
+}
+
+// Defines how TLS is terminated at the ingress
+type IngressTLS struct {
+	// The name of the Secret holding the certificate and key
+	// If empty, it defaults to <racecourse name>-tls
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// The cert-manager ClusterIssuer used to issue the certificate into the Secret
+	// If empty, the Secret must be provided some other way
+	// +optional
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+}
+
+// Reports whether an Ingress should be created, treating unset as enabled
+func (i IngressSpec) IsEnabled() bool {
+	return i.Enabled == nil || *i.Enabled
+}
+
+// HostOrDefault returns the host the ingress is created with
+func (i IngressSpec) HostOrDefault() string {
+	if i.Host != "" {
+		return i.Host
+	}
+	return DefaultIngressHost
+}
+
+// The observed state of Racecourse
+type RacecourseStatus struct {
+	// The current phase of the Racecourse instance
+	// +optional
+	Phase RacecoursePhase `json:"phase,omitempty"`
+
+	// The generation of the spec that the status was last computed from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The latest available observations of the Racecourse instance's state
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Indicates whether the Deployment is ready
+	// +optional
+	DeploymentReady bool `json:"deploymentReady,omitempty"`
+
+	// The number of ready pods
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// The URL where the racecourse app can be accessed
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// The resolved wallet service endpoint URL
+	// +optional
+	WalletServiceEndpoint string `json:"walletServiceEndpoint,omitempty"`
+
+	// The address of the Race contract the operator deployed because the spec doesn't
+	// name one
+	// +optional
+	ContractAddress string `json:"contractAddress,omitempty"`
+
+	// The hash of the transaction deploying the Race contract, while it waits to be mined
+	// +optional
+	ContractDeploymentTransaction string `json:"contractDeploymentTransaction,omitempty"`
+
+	// The account the Race contract is being deployed from
+	// +optional
+	ContractDeploymentFrom string `json:"contractDeploymentFrom,omitempty"`
+
+	// The nonce the deployment transaction is sent with. It is saved before the
+	// transaction is sent, so that a deployment whose hash was never saved is found by
+	// its sender and nonce rather than sent again.
+	// +optional
+	ContractDeploymentNonce *int64 `json:"contractDeploymentNonce,omitempty"`
+
+	// The state of the race, read periodically from the Race contract's getters
+	// +optional
+	Race *RaceStatus `json:"race,omitempty"`
+
+	// How far the contract's events have been indexed into RaceRounds
+	// +optional
+	RoundIndex *RaceRoundIndex `json:"roundIndex,omitempty"`
+
+	// How far events have been delivered to the event sink
+	// +optional
+	EventSink *EventSinkStatus `json:"eventSink,omitempty"`
+
+	// The child resources most recently deleted because the spec no longer asks for them,
+	// newest first
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	PrunedResources []PrunedResource `json:"prunedResources,omitempty"`
+}
+
+// The state of a race, as reported by the Race contract
+type RaceStatus struct {
+	// The number of horses in the race
+	HorseCount int64 `json:"horseCount"`
+
+	// The number of bets placed
+	BetCount int64 `json:"betCount"`
+
+	// The number of players who have placed a bet
+	PlayersCount int64 `json:"playersCount"`
+
+	// The number of players who are ready to race
+	PlayersReadyToRace int64 `json:"playersReadyToRace"`
+
+	// Whether the race has been run
+	RaceFinished bool `json:"raceFinished"`
+
+	// The index of the winning horse, which only means anything once the race has been run
+	WinnerHorse int64 `json:"winnerHorse"`
+
+	// The sum of the bets, as a decimal string since it can exceed 64 bits
+	Jackpot string `json:"jackpot"`
+}
+
+// The position of the RaceRound indexer on a contract
+type RaceRoundIndex struct {
+	// The contract being indexed. The index starts over when the address changes.
+	ContractAddress string `json:"contractAddress"`
+
+	// The last block whose events have been indexed
+	Block int64 `json:"block"`
+
+	// The number of rounds seen so far
+	Rounds int64 `json:"rounds"`
+}
+
+// The position of event delivery to the event sink. Events are delivered at least
+// once: anything after the position is sent again until the sink accepts it.
+type EventSinkStatus struct {
+	// The contract whose events are being delivered. Delivery starts over from block 0
+	// when it changes.
+	// +optional
+	ContractAddress string `json:"contractAddress,omitempty"`
+
+	// The last block whose events have all been delivered
+	Block int64 `json:"block"`
+
+	// The last phase delivered, and how many phase changes have been delivered
+	// +optional
+	Phase RacecoursePhase `json:"phase,omitempty"`
+	// +optional
+	PhaseChanges int64 `json:"phaseChanges,omitempty"`
+
+	// The contract the operator deployed whose deployment has been delivered
+	// +optional
+	DeployedContract string `json:"deployedContract,omitempty"`
+
+	// Why the last delivery failed, cleared once the sink accepts events again
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Records a child resource the operator deleted
+type PrunedResource struct {
+	// The kind of the child resource
+	Kind string `json:"kind"`
+
+	// The name of the child resource
+	Name string `json:"name"`
+
+	// When the child resource was deleted
+	PrunedAt metav1.Time `json:"prunedAt"`
+}
+
+// The statusphase of a Racecourse instance
+// +kubebuilder:validation:Enum=Pending;Running;Failed;Unknown
+type RacecoursePhase string
+
+const (
+	// The Racecourse instance is being created
+	RacecoursePhasePending RacecoursePhase = "Pending"
+	// The Racecourse instance is running successfully
+	RacecoursePhaseRunning RacecoursePhase = "Running"
+	// The Racecourse instance has failed
+	RacecoursePhaseFailed RacecoursePhase = "Failed"
+	// The state of the Racecourse instance is unknown
+	RacecoursePhaseUnknown RacecoursePhase = "Unknown"
+)
+
+// The types of conditions reported in the status of a Racecourse
+const (
+	// At least one racecourse pod is available to serve requests
+	ConditionTypeAvailable = "Available"
+	// The Deployment is rolling out a new revision
+	ConditionTypeProgressing = "Progressing"
+	// Fewer racecourse pods are available than requested, or the rollout is stuck
+	ConditionTypeDegraded = "Degraded"
+	// The wallet JSON-RPC endpoint answers requests and has accounts to sign with
+	ConditionTypeWalletReachable = "WalletReachable"
+	// A Race contract is available for the app to use
+	ConditionTypeContractReady = "ContractReady"
+	// The code at the contract address is a supported release of the Race contract
+	ConditionTypeContractVerified = "ContractVerified"
+	// The wallet is on the chain pinned in the spec
+	ConditionTypeChainVerified = "ChainVerified"
+	// The Ingress has been admitted and given an address
+	ConditionTypeIngressReady = "IngressReady"
+)
+
+// The reasons set on the conditions of a Racecourse
+const (
+	ReasonMinimumReplicasAvailable = "MinimumReplicasAvailable"
+	ReasonDeploymentNotFound       = "DeploymentNotFound"
+	ReasonReplicasUnavailable      = "ReplicasUnavailable"
+	ReasonRollingOut               = "RollingOut"
+	ReasonRolloutComplete          = "RolloutComplete"
+	ReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	ReasonAsExpected               = "AsExpected"
+	ReasonWalletResponding         = "WalletResponding"
+	ReasonWalletServiceNotFound    = "WalletServiceNotFound"
+	ReasonWalletEndpointsNotReady  = "WalletEndpointsNotReady"
+	ReasonWalletUnreachable        = "WalletUnreachable"
+	ReasonWalletRPCError           = "WalletRPCError"
+	ReasonWalletNoAccounts         = "WalletNoAccounts"
+	ReasonWaitingForWallet         = "WaitingForWallet"
+	ReasonContractAddressSet       = "ContractAddressSet"
+	ReasonContractNotConfigured    = "ContractNotConfigured"
+	ReasonContractDeploying        = "ContractDeploying"
+	ReasonContractDeployed         = "ContractDeployed"
+	ReasonWaitingForContract       = "WaitingForContract"
+	ReasonContractCodeMatches      = "ContractCodeMatches"
+	ReasonContractCodeMissing      = "ContractCodeMissing"
+	ReasonContractCodeMismatch     = "ContractCodeMismatch"
+	ReasonContractNotVerified      = "ContractNotVerified"
+	ReasonVerificationPending      = "VerificationPending"
+	ReasonChainNotPinned           = "ChainNotPinned"
+	ReasonChainMatches             = "ChainMatches"
+	ReasonChainIDMismatch          = "ChainIDMismatch"
+	ReasonGenesisHashMismatch      = "GenesisHashMismatch"
+	ReasonIngressDisabled          = "IngressDisabled"
+	ReasonIngressAddressAssigned   = "IngressAddressAssigned"
+	ReasonIngressPending           = "IngressPending"
+	ReasonImagePullFailed          = "ImagePullFailed"
+	ReasonCrashLooping             = "CrashLooping"
+	ReasonUnschedulable            = "Unschedulable"
+	ReasonProbeFailing             = "ProbeFailing"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=rcs
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.availableReplicas`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Jackpot",type=string,JSONPath=`.status.race.jackpot`
+// +kubebuilder:printcolumn:name="Players Ready",type=integer,JSONPath=`.status.race.playersReadyToRace`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// The actual custom resource that users will create
+type Racecourse struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RacecourseSpec   `json:"spec,omitempty"`
+	Status RacecourseStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A list of Racecourse instances
+type RacecourseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Racecourse `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Racecourse{}, &RacecourseList{})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Identifies a round of a Racecourse's Race contract
+type RaceRoundSpec struct {
+	// The Racecourse whose contract ran the round
+	Racecourse string `json:"racecourse"`
+
+	// The address of the Race contract
+	ContractAddress string `json:"contractAddress"`
+
+	// The number of the round on this contract, counting from 1
+	// +kubebuilder:validation:Minimum=1
+	Round int64 `json:"round"`
+}
+
+// What happened in a round, as indexed from the contract's events
+type RaceRoundStatus struct {
+	// The block of the first bet of the round
+	StartBlock int64 `json:"startBlock"`
+
+	// The block the race was run in
+	// +optional
+	FinishedBlock int64 `json:"finishedBlock,omitempty"`
+
+	// The last block whose events have been indexed into the round
+	LastIndexedBlock int64 `json:"lastIndexedBlock"`
+
+	// The bets, in the order they were placed
+	// +optional
+	Bets []RaceBet `json:"bets,omitempty"`
+
+	// The addresses of the players, in the order they placed their bets
+	// +optional
+	Players []string `json:"players,omitempty"`
+
+	// The number of players who have said they're ready to race
+	// +optional
+	PlayersReadyToRace int64 `json:"playersReadyToRace,omitempty"`
+
+	// Whether the race has been run
+	Finished bool `json:"finished"`
+
+	// The index of the winning horse, once the race has been run
+	// +optional
+	WinnerHorse *int64 `json:"winnerHorse,omitempty"`
+
+	// The jackpot as of the last indexed block, or when the race was run, in the
+	// contract's units as a decimal string
+	// +optional
+	Jackpot string `json:"jackpot,omitempty"`
+
+	// Why some of the round couldn't be read from the contract, if it couldn't
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// A bet placed on a horse
+type RaceBet struct {
+	// The index of the horse bet on
+	Horse int64 `json:"horse"`
+
+	// The address of the player
+	Player string `json:"player"`
+
+	// The amount bet, as a decimal string
+	Amount string `json:"amount"`
+
+	// The block the bet was placed in
+	Block int64 `json:"block"`
+
+	// The transaction that placed the bet
+	TransactionHash string `json:"transactionHash"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=rr
+// +kubebuilder:printcolumn:name="Racecourse",type=string,JSONPath=`.spec.racecourse`
+// +kubebuilder:printcolumn:name="Round",type=integer,JSONPath=`.spec.round`
+// +kubebuilder:printcolumn:name="Finished",type=boolean,JSONPath=`.status.finished`
+// +kubebuilder:printcolumn:name="Winner",type=integer,JSONPath=`.status.winnerHorse`
+// +kubebuilder:printcolumn:name="Jackpot",type=string,JSONPath=`.status.jackpot`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// A round of bets on a Racecourse's Race contract, from the first bet to the race being
+// run. The operator creates one per round from the contract's events, so the history
+// survives the contract resetting itself for the next round.
+type RaceRound struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RaceRoundSpec   `json:"spec,omitempty"`
+	Status RaceRoundStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A list of RaceRound instances
+type RaceRoundList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RaceRound `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RaceRound{}, &RaceRoundList{})
+}
@@ -0,0 +1,745 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuNetwork) DeepCopyInto(out *BesuNetwork) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuNetwork.
+func (in *BesuNetwork) DeepCopy() *BesuNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BesuNetwork) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuNetworkList) DeepCopyInto(out *BesuNetworkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BesuNetwork, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuNetworkList.
+func (in *BesuNetworkList) DeepCopy() *BesuNetworkList {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuNetworkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BesuNetworkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuNetworkSpec) DeepCopyInto(out *BesuNetworkSpec) {
+	*out = *in
+	if in.Alloc != nil {
+		in, out := &in.Alloc, &out.Alloc
+		*out = make(map[string]GenesisAccount, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Storage.DeepCopyInto(&out.Storage)
+	in.Upgrade.DeepCopyInto(&out.Upgrade)
+	if in.MaxBlockLag != nil {
+		in, out := &in.MaxBlockLag, &out.MaxBlockLag
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuNetworkSpec.
+func (in *BesuNetworkSpec) DeepCopy() *BesuNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuNetworkStatus) DeepCopyInto(out *BesuNetworkStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GenesisValidators != nil {
+		in, out := &in.GenesisValidators, &out.GenesisValidators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Validators != nil {
+		in, out := &in.Validators, &out.Validators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingValidatorChanges != nil {
+		in, out := &in.PendingValidatorChanges, &out.PendingValidatorChanges
+		*out = make([]BesuValidatorChange, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedValidatorChanges != nil {
+		in, out := &in.AppliedValidatorChanges, &out.AppliedValidatorChanges
+		*out = make([]BesuValidatorChange, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]BesuNodeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodePublicKeys != nil {
+		in, out := &in.NodePublicKeys, &out.NodePublicKeys
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HeadBlockTime != nil {
+		in, out := &in.HeadBlockTime, &out.HeadBlockTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(BesuUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuNetworkStatus.
+func (in *BesuNetworkStatus) DeepCopy() *BesuNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuNodeStatus) DeepCopyInto(out *BesuNodeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuNodeStatus.
+func (in *BesuNodeStatus) DeepCopy() *BesuNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuStorageSpec) DeepCopyInto(out *BesuStorageSpec) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuStorageSpec.
+func (in *BesuStorageSpec) DeepCopy() *BesuStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuUpgradeSpec) DeepCopyInto(out *BesuUpgradeSpec) {
+	*out = *in
+	if in.StallTimeout != nil {
+		in, out := &in.StallTimeout, &out.StallTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuUpgradeSpec.
+func (in *BesuUpgradeSpec) DeepCopy() *BesuUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuUpgradeStatus) DeepCopyInto(out *BesuUpgradeStatus) {
+	*out = *in
+	if in.SyncedBlock != nil {
+		in, out := &in.SyncedBlock, &out.SyncedBlock
+		*out = new(int64)
+		**out = **in
+	}
+	in.HeadBlockTime.DeepCopyInto(&out.HeadBlockTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuUpgradeStatus.
+func (in *BesuUpgradeStatus) DeepCopy() *BesuUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BesuValidatorChange) DeepCopyInto(out *BesuValidatorChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BesuValidatorChange.
+func (in *BesuValidatorChange) DeepCopy() *BesuValidatorChange {
+	if in == nil {
+		return nil
+	}
+	out := new(BesuValidatorChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChainSpec) DeepCopyInto(out *ChainSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChainSpec.
+func (in *ChainSpec) DeepCopy() *ChainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventSinkSpec) DeepCopyInto(out *EventSinkSpec) {
+	*out = *in
+	if in.AuthSecretRef != nil {
+		in, out := &in.AuthSecretRef, &out.AuthSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventSinkSpec.
+func (in *EventSinkSpec) DeepCopy() *EventSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EventSinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventSinkStatus) DeepCopyInto(out *EventSinkStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventSinkStatus.
+func (in *EventSinkStatus) DeepCopy() *EventSinkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EventSinkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GenesisAccount) DeepCopyInto(out *GenesisAccount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenesisAccount.
+func (in *GenesisAccount) DeepCopy() *GenesisAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(GenesisAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSpec) DeepCopyInto(out *ImageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSpec.
+func (in *ImageSpec) DeepCopy() *ImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressSpec) DeepCopyInto(out *IngressSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(IngressTLS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressSpec.
+func (in *IngressSpec) DeepCopy() *IngressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressTLS) DeepCopyInto(out *IngressTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressTLS.
+func (in *IngressTLS) DeepCopy() *IngressTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrunedResource) DeepCopyInto(out *PrunedResource) {
+	*out = *in
+	in.PrunedAt.DeepCopyInto(&out.PrunedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrunedResource.
+func (in *PrunedResource) DeepCopy() *PrunedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(PrunedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceBet) DeepCopyInto(out *RaceBet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceBet.
+func (in *RaceBet) DeepCopy() *RaceBet {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceBet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceRound) DeepCopyInto(out *RaceRound) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceRound.
+func (in *RaceRound) DeepCopy() *RaceRound {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceRound)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RaceRound) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceRoundIndex) DeepCopyInto(out *RaceRoundIndex) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceRoundIndex.
+func (in *RaceRoundIndex) DeepCopy() *RaceRoundIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceRoundIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceRoundList) DeepCopyInto(out *RaceRoundList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RaceRound, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceRoundList.
+func (in *RaceRoundList) DeepCopy() *RaceRoundList {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceRoundList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RaceRoundList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceRoundSpec) DeepCopyInto(out *RaceRoundSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceRoundSpec.
+func (in *RaceRoundSpec) DeepCopy() *RaceRoundSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceRoundSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceRoundStatus) DeepCopyInto(out *RaceRoundStatus) {
+	*out = *in
+	if in.Bets != nil {
+		in, out := &in.Bets, &out.Bets
+		*out = make([]RaceBet, len(*in))
+		copy(*out, *in)
+	}
+	if in.Players != nil {
+		in, out := &in.Players, &out.Players
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WinnerHorse != nil {
+		in, out := &in.WinnerHorse, &out.WinnerHorse
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceRoundStatus.
+func (in *RaceRoundStatus) DeepCopy() *RaceRoundStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceRoundStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaceStatus) DeepCopyInto(out *RaceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaceStatus.
+func (in *RaceStatus) DeepCopy() *RaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Racecourse) DeepCopyInto(out *Racecourse) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Racecourse.
+func (in *Racecourse) DeepCopy() *Racecourse {
+	if in == nil {
+		return nil
+	}
+	out := new(Racecourse)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Racecourse) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RacecourseList) DeepCopyInto(out *RacecourseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Racecourse, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RacecourseList.
+func (in *RacecourseList) DeepCopy() *RacecourseList {
+	if in == nil {
+		return nil
+	}
+	out := new(RacecourseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RacecourseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RacecourseSpec) DeepCopyInto(out *RacecourseSpec) {
+	*out = *in
+	out.Image = in.Image
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Wallet.DeepCopyInto(&out.Wallet)
+	in.Ingress.DeepCopyInto(&out.Ingress)
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = new(ChainSpec)
+		**out = **in
+	}
+	if in.EventSink != nil {
+		in, out := &in.EventSink, &out.EventSink
+		*out = new(EventSinkSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RacecourseSpec.
+func (in *RacecourseSpec) DeepCopy() *RacecourseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RacecourseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RacecourseStatus) DeepCopyInto(out *RacecourseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ContractDeploymentNonce != nil {
+		in, out := &in.ContractDeploymentNonce, &out.ContractDeploymentNonce
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Race != nil {
+		in, out := &in.Race, &out.Race
+		*out = new(RaceStatus)
+		**out = **in
+	}
+	if in.RoundIndex != nil {
+		in, out := &in.RoundIndex, &out.RoundIndex
+		*out = new(RaceRoundIndex)
+		**out = **in
+	}
+	if in.EventSink != nil {
+		in, out := &in.EventSink, &out.EventSink
+		*out = new(EventSinkStatus)
+		**out = **in
+	}
+	if in.PrunedResources != nil {
+		in, out := &in.PrunedResources, &out.PrunedResources
+		*out = make([]PrunedResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RacecourseStatus.
+func (in *RacecourseStatus) DeepCopy() *RacecourseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RacecourseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WalletEndpoint) DeepCopyInto(out *WalletEndpoint) {
+	*out = *in
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(WalletServiceRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WalletEndpoint.
+func (in *WalletEndpoint) DeepCopy() *WalletEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(WalletEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WalletServiceRef) DeepCopyInto(out *WalletServiceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WalletServiceRef.
+func (in *WalletServiceRef) DeepCopy() *WalletServiceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(WalletServiceRef)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -20,15 +20,21 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -36,7 +42,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
 	"github.com/mgoode/racecourse-operator/internal/controller"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+	webhookv1alpha1 "github.com/mgoode/racecourse-operator/internal/webhook/v1alpha1"
+	webhookv1beta1 "github.com/mgoode/racecourse-operator/internal/webhook/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,8 +57,10 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(racecoursev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(racecoursev1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -61,6 +73,7 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var resyncInterval time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -79,6 +92,9 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&resyncInterval, "resync-interval", 10*time.Minute,
+		"How often each Racecourse's wallet, chain and contract are checked again when nothing has changed. "+
+			"A Racecourse's spec.resyncInterval overrides it. Use 0 to only check when something changes.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -161,6 +177,13 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "a1d90f2f.kaleido.io",
+		// The controllers watch pods and list ReplicaSets, but only their own
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Pod{}:        {Label: controller.ManagedPodSelector()},
+				&appsv1.ReplicaSet{}: {Label: controller.ManagedPodSelector()},
+			},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -178,15 +201,77 @@ func main() {
 		os.Exit(1)
 	}
 
+	heads := &controller.HeadWatcher{}
+	if err := mgr.Add(heads); err != nil {
+		setupLog.Error(err, "unable to set up the new heads watcher")
+		os.Exit(1)
+	}
 	if err := (&controller.RacecourseReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("racecourse-controller"),
+		APIReader:      mgr.GetAPIReader(),
+		ResyncInterval: resyncInterval,
+		Heads:          heads,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Racecourse")
 		os.Exit(1)
 	}
+	if err := (&controller.RaceRoundReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("raceround-controller"),
+		APIReader: mgr.GetAPIReader(),
+		Heads:     heads,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RaceRound")
+		os.Exit(1)
+	}
+	if err := (&controller.EventSinkReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EventSink")
+		os.Exit(1)
+	}
+	if err := (&controller.BesuNetworkReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("besunetwork-controller"),
+		APIReader: mgr.GetAPIReader(),
+		// The nodes are in the cluster and asked one at a time, so one that is slow to
+		// answer is treated as down rather than waited for
+		RPCOptions: []ethrpc.Option{ethrpc.WithTimeout(2 * time.Second), ethrpc.WithRetries(0, 0)},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BesuNetwork")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupRacecourseWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Racecourse")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1beta1.SetupRacecourseWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Racecourse")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if err := mgr.Add(&controller.StorageVersionMigrator{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  mgr.GetEventRecorderFor("storage-version-migrator"),
+	}); err != nil {
+		setupLog.Error(err, "unable to set up storage version migration")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,167 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package besu
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node keys", func() {
+	It("should derive the public key and address of a private key", func() {
+		key, err := ParseNodeKey("0x0000000000000000000000000000000000000000000000000000000000000001")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key.EnodeID()).To(Equal("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+			"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"))
+		Expect(key.Address()).To(Equal("0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"))
+
+		key, err = ParseNodeKey("0x0000000000000000000000000000000000000000000000000000000000000002\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key.Address()).To(Equal("0x2b5ad5c4795c026514f8317c7a215e218dccd6cf"))
+	})
+
+	It("should derive the public keys and addresses other Ethereum tooling does", func() {
+		// The Hardhat and Anvil development accounts, which go-ethereum and Besu derive the
+		// same way, and n-1, whose public key is -G
+		for _, vector := range []struct{ private, public, address string }{
+			{
+				private: "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+				public: "8318535b54105d4a7aae60c08fc45f9687181b4fdfc625bd1a753fa7397fed75" +
+					"3547f11ca8696646f2f3acb08e31016afac23e630c5d11f59f61fef57b0d2aa5",
+				address: "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266",
+			},
+			{
+				private: "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+				address: "0x70997970c51812dc3a010c7d01b50e0d17dc79c8",
+			},
+			{
+				private: "0x5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a",
+				address: "0x3c44cdddb6a900fa2b585dd299e03d12fa4293bc",
+			},
+			{
+				private: "0x0000000000000000000000000000000000000000000000000000000000000003",
+				address: "0x6813eb9362372eef6200f3b1dbc3f819671cba69",
+			},
+			{
+				private: "0xfffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+				public: "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+					"b7c52588d95c3b9aa25b0403f1eef75702e84bb7597aabe663b82f6f04ef2777",
+			},
+		} {
+			key, err := ParseNodeKey(vector.private)
+			Expect(err).NotTo(HaveOccurred(), vector.private)
+			if vector.public != "" {
+				Expect(key.EnodeID()).To(Equal(vector.public), vector.private)
+			}
+			if vector.address != "" {
+				Expect(key.Address()).To(Equal(vector.address), vector.private)
+			}
+		}
+	})
+
+	It("should generate keys that read back the same", func() {
+		key, err := GenerateNodeKey()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key.Hex()).To(HaveLen(66))
+
+		parsed, err := ParseNodeKey(key.Hex())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Address()).To(Equal(key.Address()))
+		Expect(parsed.PublicKey()).To(Equal(key.PublicKey()))
+	})
+
+	It("should read back a node's identity from its enode ID", func() {
+		key, err := GenerateNodeKey()
+		Expect(err).NotTo(HaveOccurred())
+
+		node, err := ParseNodeIdentity(key.EnodeID())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(node.Address()).To(Equal(key.Address()))
+
+		for _, value := range []string{"", key.EnodeID()[2:], strings.Repeat("00", 64)} {
+			_, err := ParseNodeIdentity(value)
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+
+	It("should reject keys that aren't valid secp256k1 private keys", func() {
+		for _, value := range []string{
+			"",
+			"0x01",
+			"0x0000000000000000000000000000000000000000000000000000000000000000",
+			"0xfffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+		} {
+			_, err := ParseNodeKey(value)
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+})
+
+var _ = Describe("Genesis", func() {
+	const validator = "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"
+
+	It("should encode the validators into the QBFT extraData", func() {
+		extraData, err := ExtraData([]string{validator})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(extraData).To(Equal("0xf83aa0" + strings.Repeat("00", 32) + "d594" + validator[2:] + "c080c0"))
+
+		_, err = ExtraData(nil)
+		Expect(err).To(HaveOccurred())
+		_, err = ExtraData([]string{"0x1234"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should render a genesis document Besu can start a QBFT network from", func() {
+		rendered, err := RenderGenesis(GenesisConfig{
+			ChainID:               1337,
+			BlockPeriodSeconds:    5,
+			EpochLength:           30000,
+			RequestTimeoutSeconds: 10,
+			GasLimit:              "0x1fffffffffffff",
+			Timestamp:             0x690A17E4,
+			Alloc:                 map[string]string{"0xFB6920EF5A2EEE9185E4A04524BEF6647C5DA0BE": "0x100"},
+			Validators:            []string{validator},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		document := map[string]any{}
+		Expect(json.Unmarshal(rendered, &document)).To(Succeed())
+		Expect(document).To(HaveKeyWithValue("config", map[string]any{
+			"chainId":     1337.0,
+			"berlinBlock": 0.0,
+			"qbft": map[string]any{
+				"blockperiodseconds":    5.0,
+				"epochlength":           30000.0,
+				"requesttimeoutseconds": 10.0,
+			},
+		}))
+		Expect(document).To(HaveKeyWithValue("timestamp", "0x690A17E4"))
+		Expect(document).To(HaveKeyWithValue("mixHash", bftMixHash))
+		Expect(document).To(HaveKeyWithValue("alloc", map[string]any{
+			"0xfb6920ef5a2eee9185e4a04524bef6647c5da0be": map[string]any{"balance": "0x100"},
+		}))
+		Expect(document["extraData"]).To(HaveSuffix(validator[2:] + "c080c0"))
+
+		By("reading the validators back")
+		Expect(GenesisValidators(rendered)).To(Equal([]string{validator}))
+		_, err = GenesisValidators([]byte(`{"extraData": "0xc0"}`))
+		Expect(err).To(HaveOccurred())
+	})
+})
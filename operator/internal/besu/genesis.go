@@ -0,0 +1,168 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package besu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// The mix hash that marks a genesis block as using BFT consensus
+const bftMixHash = "0x63746963616c2062797a616e74696e65206661756c7420746f6c6572616e6365"
+
+// GenesisConfig holds the settings of a QBFT network fixed by its genesis block
+type GenesisConfig struct {
+	ChainID               int64
+	BlockPeriodSeconds    int64
+	EpochLength           int64
+	RequestTimeoutSeconds int64
+	// The block gas limit as a hex quantity
+	GasLimit string
+	// The time of the genesis block in seconds since the epoch
+	Timestamp int64
+	// The balance of each funded account, by address
+	Alloc map[string]string
+	// The addresses of the validators that seal the first blocks
+	Validators []string
+}
+
+// The genesis document as Besu reads it
+type genesis struct {
+	Config     genesisChainConfig        `json:"config"`
+	Nonce      string                    `json:"nonce"`
+	Timestamp  string                    `json:"timestamp"`
+	GasLimit   string                    `json:"gasLimit"`
+	Difficulty string                    `json:"difficulty"`
+	MixHash    string                    `json:"mixHash"`
+	Coinbase   string                    `json:"coinbase"`
+	Alloc      map[string]genesisAccount `json:"alloc"`
+	ExtraData  string                    `json:"extraData"`
+}
+
+type genesisChainConfig struct {
+	ChainID     int64      `json:"chainId"`
+	BerlinBlock int64      `json:"berlinBlock"`
+	QBFT        qbftConfig `json:"qbft"`
+}
+
+type qbftConfig struct {
+	BlockPeriodSeconds    int64 `json:"blockperiodseconds"`
+	EpochLength           int64 `json:"epochlength"`
+	RequestTimeoutSeconds int64 `json:"requesttimeoutseconds"`
+}
+
+type genesisAccount struct {
+	Balance string `json:"balance"`
+}
+
+// RenderGenesis renders the genesis.json of a QBFT network
+func RenderGenesis(config GenesisConfig) ([]byte, error) {
+	extraData, err := ExtraData(config.Validators)
+	if err != nil {
+		return nil, err
+	}
+
+	alloc := map[string]genesisAccount{}
+	for address, balance := range config.Alloc {
+		alloc[strings.ToLower(address)] = genesisAccount{Balance: balance}
+	}
+
+	return json.MarshalIndent(genesis{
+		Config: genesisChainConfig{
+			ChainID: config.ChainID,
+			QBFT: qbftConfig{
+				BlockPeriodSeconds:    config.BlockPeriodSeconds,
+				EpochLength:           config.EpochLength,
+				RequestTimeoutSeconds: config.RequestTimeoutSeconds,
+			},
+		},
+		Nonce:      "0x0",
+		Timestamp:  fmt.Sprintf("0x%X", config.Timestamp),
+		GasLimit:   config.GasLimit,
+		Difficulty: "0x1",
+		MixHash:    bftMixHash,
+		Coinbase:   "0x0000000000000000000000000000000000000000",
+		Alloc:      alloc,
+		ExtraData:  extraData,
+	}, "", "  ")
+}
+
+// ExtraData encodes the extraData of a QBFT genesis block, which names the validators:
+// RLP([32 bytes of vanity, [validators...], no vote, round 0, no seals])
+func ExtraData(validators []string) (string, error) {
+	if len(validators) == 0 {
+		return "", fmt.Errorf("a QBFT network needs at least one validator")
+	}
+
+	encoded := make([][]byte, 0, len(validators))
+	for _, validator := range validators {
+		address, err := ethrpc.DecodeHex(validator)
+		if err != nil || len(address) != 20 {
+			return "", fmt.Errorf("invalid validator address %q", validator)
+		}
+		encoded = append(encoded, rlpString(address))
+	}
+
+	return ethrpc.EncodeHex(rlpList(
+		rlpString(make([]byte, 32)),
+		rlpList(encoded...),
+		rlpList(),
+		rlpString(nil),
+		rlpList(),
+	)), nil
+}
+
+// GenesisValidators reads the validators out of the extraData of a QBFT genesis.json
+func GenesisValidators(genesisJSON []byte) ([]string, error) {
+	document := &genesis{}
+	if err := json.Unmarshal(genesisJSON, document); err != nil {
+		return nil, err
+	}
+	extraData, err := ethrpc.DecodeHex(document.ExtraData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extraData: %w", err)
+	}
+
+	isList, fields, _, err := rlpSplit(extraData)
+	if err != nil || !isList {
+		return nil, fmt.Errorf("the extraData isn't an RLP list")
+	}
+	// Skip the vanity to get to the list of validators
+	if _, _, fields, err = rlpSplit(fields); err != nil {
+		return nil, err
+	}
+	isList, list, _, err := rlpSplit(fields)
+	if err != nil || !isList {
+		return nil, fmt.Errorf("the extraData has no list of validators")
+	}
+
+	var validators []string
+	for len(list) > 0 {
+		var address []byte
+		if _, address, list, err = rlpSplit(list); err != nil {
+			return nil, err
+		}
+		if len(address) != 20 {
+			return nil, fmt.Errorf("invalid validator address in the extraData")
+		}
+		validators = append(validators, ethrpc.EncodeHex(address))
+	}
+	return validators, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package besu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The network the Helm chart was generated for with scripts/generate-keys.sh, which
+// used the Besu CLI to derive the addresses and encode the extraData
+var chartDir = filepath.Join("..", "..", "..", "helm", "besu")
+
+func readChartFile(name string) []byte {
+	data, err := os.ReadFile(filepath.Join(chartDir, name))
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+var _ = Describe("The Helm chart's network", func() {
+	var addresses []string
+
+	BeforeEach(func() {
+		addresses = nil
+		for _, line := range strings.Fields(string(readChartFile("enodes.txt"))) {
+			id, _, _ := strings.Cut(line, ":")
+			node, err := ParseNodeIdentity(id)
+			Expect(err).NotTo(HaveOccurred())
+			addresses = append(addresses, node.Address())
+		}
+	})
+
+	It("should derive the validator addresses from the public keys", func() {
+		Expect(addresses).To(Equal([]string{
+			"0xf299016883247748ba5314bdfd4d437c64ee5079",
+			"0x845d55023d400460cea9c0bd491b5e5728cd2ca1",
+			"0xf63887f19b7ecc7202d22e6935dfda7917f0f561",
+			"0xf7317cece2547c0f4e4056415c10ea448b4c92e2",
+		}))
+		Expect(GenesisValidators(readChartFile("genesis.json"))).To(Equal(addresses))
+	})
+
+	It("should encode the same extraData as the Besu CLI", func() {
+		committed := map[string]any{}
+		Expect(json.Unmarshal(readChartFile("genesis.json"), &committed)).To(Succeed())
+
+		extraData, err := ExtraData(addresses)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(extraData).To(Equal(committed["extraData"]))
+	})
+
+	It("should render genesis.json from the fields of genesis-base.json", func() {
+		base := &genesis{}
+		Expect(json.Unmarshal(readChartFile("genesis-base.json"), base)).To(Succeed())
+		alloc := map[string]string{}
+		for address, account := range base.Alloc {
+			alloc[address] = account.Balance
+		}
+		var timestamp int64
+		_, err := fmt.Sscanf(base.Timestamp, "0x%X", &timestamp)
+		Expect(err).NotTo(HaveOccurred())
+
+		rendered, err := RenderGenesis(GenesisConfig{
+			ChainID:               base.Config.ChainID,
+			BlockPeriodSeconds:    base.Config.QBFT.BlockPeriodSeconds,
+			EpochLength:           base.Config.QBFT.EpochLength,
+			RequestTimeoutSeconds: base.Config.QBFT.RequestTimeoutSeconds,
+			GasLimit:              base.GasLimit,
+			Timestamp:             timestamp,
+			Alloc:                 alloc,
+			Validators:            addresses,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		document, committed := map[string]any{}, map[string]any{}
+		Expect(json.Unmarshal(rendered, &document)).To(Succeed())
+		Expect(json.Unmarshal(readChartFile("genesis.json"), &committed)).To(Succeed())
+		Expect(document).To(Equal(committed))
+	})
+})
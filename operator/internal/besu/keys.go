@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package besu generates the keys and genesis of a private Besu network running QBFT.
+package besu
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// NodeKey is the secp256k1 key pair of a Besu node. It identifies the node to its peers
+// and, for a validator, signs the blocks it proposes.
+type NodeKey struct {
+	NodeIdentity
+	private *secp256k1.PrivateKey
+}
+
+// NodeIdentity is the public half of a node key, which is all the operator needs once a
+// key exists: peers know the node by its public key and the validator set by its address.
+type NodeIdentity struct {
+	public []byte
+}
+
+// GenerateNodeKey creates a new random node key
+func GenerateNodeKey() (*NodeKey, error) {
+	return generateNodeKey(rand.Reader)
+}
+
+func generateNodeKey(random io.Reader) (*NodeKey, error) {
+	buf := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+		// Draw again in the rare case the bytes aren't a valid private key
+		if key, err := newNodeKey(buf); err == nil {
+			return key, nil
+		}
+	}
+}
+
+// ParseNodeKey reads a private key in the hex form Besu keeps in its key file
+func ParseNodeKey(value string) (*NodeKey, error) {
+	raw, err := ethrpc.DecodeHex(strings.TrimSpace(value))
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("a node key must be 32 bytes of hex")
+	}
+	return newNodeKey(raw)
+}
+
+func newNodeKey(raw []byte) (*NodeKey, error) {
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(raw); overflow || scalar.IsZero() {
+		return nil, fmt.Errorf("the node key is out of range for secp256k1")
+	}
+	private := secp256k1.NewPrivateKey(&scalar)
+	return &NodeKey{
+		NodeIdentity: NodeIdentity{public: private.PubKey().SerializeUncompressed()[1:]},
+		private:      private,
+	}, nil
+}
+
+// Hex returns the private key the way Besu writes its key file
+func (k *NodeKey) Hex() string {
+	return ethrpc.EncodeHex(k.private.Serialize())
+}
+
+// ParseNodeIdentity reads a public key in the hex form of an enode ID
+func ParseNodeIdentity(enodeID string) (NodeIdentity, error) {
+	raw, err := ethrpc.DecodeHex(enodeID)
+	if err != nil || len(raw) != 64 {
+		return NodeIdentity{}, fmt.Errorf("a node ID must be 64 bytes of hex")
+	}
+	if _, err := secp256k1.ParsePubKey(append([]byte{0x04}, raw...)); err != nil {
+		return NodeIdentity{}, fmt.Errorf("the node ID isn't a secp256k1 public key: %w", err)
+	}
+	return NodeIdentity{public: raw}, nil
+}
+
+// PublicKey returns the uncompressed public key without its 0x04 prefix, as used in enode URLs
+func (id NodeIdentity) PublicKey() []byte {
+	return id.public
+}
+
+// EnodeID returns the public key in hex, which identifies the node in enode URLs
+func (id NodeIdentity) EnodeID() string {
+	return strings.TrimPrefix(ethrpc.EncodeHex(id.public), "0x")
+}
+
+// Address returns the account address of the key, which is how QBFT names validators: the
+// last 20 bytes of the Keccak-256 hash of the public key
+func (id NodeIdentity) Address() string {
+	return ethrpc.EncodeHex(ethrpc.Keccak256(id.public)[12:])
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package besu
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// Validators returns the validator set of a QBFT chain as of the block, which is a
+// block number from ethrpc.BlockTag or "latest"
+func Validators(ctx context.Context, node *ethrpc.Client, block string) ([]string, error) {
+	var validators []string
+	if err := node.Call(ctx, &validators, "qbft_getValidatorsByBlockNumber", block); err != nil {
+		return nil, err
+	}
+	for i, validator := range validators {
+		validators[i] = strings.ToLower(validator)
+	}
+	return validators, nil
+}
+
+// PendingVotes returns the votes the node casts in the blocks it proposes, by address:
+// true to add the address to the validator set, false to remove it
+func PendingVotes(ctx context.Context, node *ethrpc.Client) (map[string]bool, error) {
+	votes := map[string]bool{}
+	if err := node.Call(ctx, &votes, "qbft_getPendingVotes"); err != nil {
+		return nil, err
+	}
+	lower := make(map[string]bool, len(votes))
+	for address, add := range votes {
+		lower[strings.ToLower(address)] = add
+	}
+	return lower, nil
+}
+
+// ProposeValidatorVote has the node vote to add the address to the validator set, or
+// to remove it, in every block it proposes until the vote is discarded. A change takes
+// effect once more than half the validators have voted for it.
+func ProposeValidatorVote(ctx context.Context, node *ethrpc.Client, address string, add bool) error {
+	var accepted bool
+	return node.Call(ctx, &accepted, "qbft_proposeValidatorVote", address, add)
+}
+
+// DiscardValidatorVote withdraws the node's vote on the address
+func DiscardValidatorVote(ctx context.Context, node *ethrpc.Client, address string) error {
+	var discarded bool
+	return node.Call(ctx, &discarded, "qbft_discardValidatorVote", address)
+}
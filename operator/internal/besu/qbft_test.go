@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package besu
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+var _ = Describe("QBFT JSON-RPC", func() {
+	var (
+		ctx   context.Context
+		node  *ethrpc.Client
+		calls []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		calls = nil
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			request := &struct {
+				ID     int64             `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}{}
+			Expect(json.NewDecoder(r.Body).Decode(request)).To(Succeed())
+			call := request.Method
+			for _, param := range request.Params {
+				call += " " + string(param)
+			}
+			calls = append(calls, call)
+
+			var result any = true
+			switch request.Method {
+			case "qbft_getValidatorsByBlockNumber":
+				result = []string{"0x7E5F4552091A69125D5DFCB7B8C2659029395BDF"}
+			case "qbft_getPendingVotes":
+				result = map[string]bool{"0x2B5AD5C4795C026514F8317C7A215E218DCCD6CF": true}
+			}
+			Expect(json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})).To(Succeed())
+		}))
+		DeferCleanup(server.Close)
+		node = ethrpc.New(server.URL)
+	})
+
+	It("should read the validators and pending votes in lower case", func() {
+		Expect(Validators(ctx, node, "latest")).To(Equal([]string{"0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"}))
+		Expect(PendingVotes(ctx, node)).To(Equal(map[string]bool{"0x2b5ad5c4795c026514f8317c7a215e218dccd6cf": true}))
+	})
+
+	It("should propose and discard votes", func() {
+		const address = "0x2b5ad5c4795c026514f8317c7a215e218dccd6cf"
+		Expect(ProposeValidatorVote(ctx, node, address, false)).To(Succeed())
+		Expect(DiscardValidatorVote(ctx, node, address)).To(Succeed())
+		Expect(calls).To(Equal([]string{
+			`qbft_proposeValidatorVote "` + address + `" false`,
+			`qbft_discardValidatorVote "` + address + `"`,
+		}))
+	})
+})
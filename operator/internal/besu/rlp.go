@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package besu
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RLP encodes a byte string
+func rlpString(value []byte) []byte {
+	if len(value) == 1 && value[0] < 0x80 {
+		return value
+	}
+	return append(rlpHeader(0x80, len(value)), value...)
+}
+
+// RLP encodes a list of already encoded items
+func rlpList(items ...[]byte) []byte {
+	var payload []byte
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(rlpHeader(0xc0, len(payload)), payload...)
+}
+
+// The prefix of a string (offset 0x80) or list (offset 0xc0) with a payload of the length
+func rlpHeader(offset byte, length int) []byte {
+	if length < 56 {
+		return []byte{offset + byte(length)}
+	}
+	size := new(big.Int).SetInt64(int64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(size))}, size...)
+}
+
+// Splits the first RLP item off the data, returning whether it's a list, its payload
+// and what follows it
+func rlpSplit(data []byte) (bool, []byte, []byte, error) {
+	if len(data) == 0 {
+		return false, nil, nil, fmt.Errorf("unexpected end of RLP data")
+	}
+
+	prefix := data[0]
+	var isList bool
+	var offset, length int
+	switch {
+	case prefix < 0x80:
+		return false, data[:1], data[1:], nil
+	case prefix < 0xb8:
+		offset, length = 1, int(prefix-0x80)
+	case prefix < 0xc0:
+		offset, length = rlpLongLength(data, prefix-0xb7)
+	case prefix < 0xf8:
+		isList, offset, length = true, 1, int(prefix-0xc0)
+	default:
+		isList = true
+		offset, length = rlpLongLength(data, prefix-0xf7)
+	}
+	if offset < 0 || length < 0 || offset+length > len(data) {
+		return false, nil, nil, fmt.Errorf("RLP item overruns its data")
+	}
+	return isList, data[offset : offset+length], data[offset+length:], nil
+}
+
+// Reads the length of a long string or list, whose prefix is followed by its length
+// in size bytes. Returns -1s if the data is too short.
+func rlpLongLength(data []byte, size byte) (int, int) {
+	if int(size) >= len(data) || size > 4 {
+		return -1, -1
+	}
+	return 1 + int(size), int(new(big.Int).SetBytes(data[1 : 1+size]).Int64())
+}
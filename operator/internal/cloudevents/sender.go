@@ -0,0 +1,202 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudevents delivers CloudEvents to an HTTP sink in binary content mode.
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// The version of the CloudEvents spec events are sent as
+const SpecVersion = "1.0"
+
+// Defaults used unless overridden by an Option
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultRetries      = 2
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// Event is a CloudEvent whose data is sent as JSON
+type Event struct {
+	// Identifies the event within its source, so a sink can drop events it is sent twice
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// Sender posts events to a single sink
+type Sender struct {
+	url          string
+	token        string
+	httpClient   *http.Client
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+}
+
+// Option configures a Sender
+type Option func(*Sender)
+
+// WithToken sends the token as a bearer token with every event
+func WithToken(token string) Option {
+	return func(s *Sender) {
+		s.token = token
+	}
+}
+
+// WithTimeout limits how long each attempt to send an event may take
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Sender) {
+		s.timeout = timeout
+	}
+}
+
+// WithRetries sets how many times sending an event is retried when the sink can't be
+// reached or fails with a server error, and the delay before the first retry, which
+// doubles with every attempt
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(s *Sender) {
+		s.retries = retries
+		s.retryBackoff = backoff
+	}
+}
+
+// WithHTTPClient sets the HTTP client events are sent with
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(s *Sender) {
+		s.httpClient = httpClient
+	}
+}
+
+// New creates a sender for the sink at the URL
+func New(url string, opts ...Option) *Sender {
+	s := &Sender{
+		url:          url,
+		httpClient:   http.DefaultClient,
+		timeout:      DefaultTimeout,
+		retries:      DefaultRetries,
+		retryBackoff: DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// DeliveryError is returned when the sink doesn't accept an event
+type DeliveryError struct {
+	ID string
+	// The HTTP status the sink answered with, or 0 if it couldn't be reached
+	StatusCode int
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("event %s: sink answered with HTTP %d", e.ID, e.StatusCode)
+	}
+	return fmt.Sprintf("event %s: %v", e.ID, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Send delivers the event, retrying when the sink can't be reached or fails with a
+// server error. The sink has accepted the event once this returns nil.
+func (s *Sender) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.send(ctx, event, body)
+		if err == nil || attempt >= s.retries || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Sender) send(ctx context.Context, event Event, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// In binary content mode the attributes travel as headers and the body is the data
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", SpecVersion)
+	req.Header.Set("ce-id", event.ID)
+	req.Header.Set("ce-source", event.Source)
+	req.Header.Set("ce-type", event.Type)
+	if event.Subject != "" {
+		req.Header.Set("ce-subject", event.Subject)
+	}
+	if !event.Time.IsZero() {
+		req.Header.Set("ce-time", event.Time.UTC().Format(time.RFC3339Nano))
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return &DeliveryError{ID: event.ID, Err: err}
+	}
+	defer res.Body.Close() //nolint:errcheck
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.CopyN(io.Discard, res.Body, 4096)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &DeliveryError{ID: event.ID, StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+// Reports whether a failed delivery is worth retrying
+func retryable(err error) bool {
+	deliveryErr := &DeliveryError{}
+	if !errors.As(err, &deliveryErr) {
+		return false
+	}
+	if deliveryErr.StatusCode == 0 {
+		return !errors.Is(err, context.Canceled)
+	}
+	return deliveryErr.StatusCode >= 500 || deliveryErr.StatusCode == http.StatusTooManyRequests
+}
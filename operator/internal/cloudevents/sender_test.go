@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sender", func() {
+	var ctx context.Context
+
+	event := Event{
+		ID:      "race-1",
+		Source:  "/racecourses/default/racecourse",
+		Type:    "io.kaleido.racecourse.race.finished",
+		Subject: "racecourse-5aaeb605-1",
+		Time:    time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Data:    map[string]any{"winnerHorse": 2},
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("should send the event in binary content mode", func() {
+		var received *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		DeferCleanup(server.Close)
+
+		Expect(New(server.URL, WithToken("s3cret")).Send(ctx, event)).To(Succeed())
+		Expect(received.Method).To(Equal(http.MethodPost))
+		Expect(received.Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(received.Header.Get("ce-specversion")).To(Equal("1.0"))
+		Expect(received.Header.Get("ce-id")).To(Equal("race-1"))
+		Expect(received.Header.Get("ce-source")).To(Equal("/racecourses/default/racecourse"))
+		Expect(received.Header.Get("ce-type")).To(Equal("io.kaleido.racecourse.race.finished"))
+		Expect(received.Header.Get("ce-subject")).To(Equal("racecourse-5aaeb605-1"))
+		Expect(received.Header.Get("ce-time")).To(Equal("2025-06-01T12:00:00Z"))
+		Expect(received.Header.Get("Authorization")).To(Equal("Bearer s3cret"))
+		Expect(body).To(MatchJSON(`{"winnerHorse": 2}`))
+	})
+
+	It("should retry when the sink fails with a server error", func() {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		DeferCleanup(server.Close)
+
+		Expect(New(server.URL, WithRetries(2, time.Millisecond)).Send(ctx, event)).To(Succeed())
+		Expect(attempts.Load()).To(Equal(int32(3)))
+	})
+
+	It("should not retry an event the sink rejects", func() {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		DeferCleanup(server.Close)
+
+		err := New(server.URL, WithRetries(2, time.Millisecond)).Send(ctx, event)
+		deliveryErr := &DeliveryError{}
+		Expect(errors.As(err, &deliveryErr)).To(BeTrue())
+		Expect(deliveryErr.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(attempts.Load()).To(Equal(int32(1)))
+	})
+
+	It("should report a sink that can't be reached", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		err := New(server.URL, WithRetries(1, time.Millisecond)).Send(ctx, event)
+		Expect(err).To(MatchError(ContainSubstring("event race-1")))
+	})
+})
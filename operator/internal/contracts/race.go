@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package contracts holds the compiled contracts the operator deploys. The files are
+// copied from racecourse/contracts by "make contracts".
+package contracts
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+//go:embed Race.bin
+var raceBin string
+
+// RaceCreationCode returns the creation bytecode of the Race contract
+func RaceCreationCode() ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(raceBin), "0x"))
+}
+
+// RaceRuntimeCode returns the runtime bytecode the Race contract's constructor leaves on
+// chain, which is what eth_getCode returns for a deployed Race contract
+func RaceRuntimeCode() ([]byte, error) {
+	code, err := RaceCreationCode()
+	if err != nil {
+		return nil, err
+	}
+	return runtimeCode(code)
+}
+
+// Finds the runtime bytecode in creation bytecode. solc places it after the constructor,
+// which copies it out and returns it with PUSH2 size, DUP1, PUSH3 offset, PUSH1 0,
+// CODECOPY, PUSH1 0, RETURN.
+func runtimeCode(creationCode []byte) ([]byte, error) {
+	end := bytes.Index(creationCode, []byte{0x60, 0x00, 0x39, 0x60, 0x00, 0xf3})
+	if end < 8 || creationCode[end-8] != 0x61 || creationCode[end-5] != 0x80 || creationCode[end-4] != 0x62 {
+		return nil, fmt.Errorf("no copy of the runtime code found in the creation code")
+	}
+	size := int(creationCode[end-7])<<8 | int(creationCode[end-6])
+	offset := int(creationCode[end-3])<<16 | int(creationCode[end-2])<<8 | int(creationCode[end-1])
+	if offset+size > len(creationCode) {
+		return nil, fmt.Errorf("runtime code at %d+%d is past the end of the creation code", offset, size)
+	}
+	return creationCode[offset : offset+size], nil
+}
+
+// The Keccak-256 hashes of the runtime bytecode of every Race contract release the
+// operator works with, mapped to the app release it shipped in. Add the new hash here
+// whenever Race.bin changes, keeping the old ones for contracts already deployed.
+var raceCodeHashes = map[string]string{
+	"0xcb54391a335af746c33de4465b92ba01a45d7c9701a5b1878e2bff385d458f2c": "1.0.0",
+}
+
+// RaceVersion returns the app release whose Race contract has the runtime bytecode, as
+// returned by eth_getCode, and whether it is a supported Race contract at all
+func RaceVersion(runtimeCode []byte) (string, bool) {
+	version, ok := raceCodeHashes[ethrpc.EncodeHex(ethrpc.Keccak256(runtimeCode))]
+	return version, ok
+}
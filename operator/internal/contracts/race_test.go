@@ -0,0 +1,46 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package contracts
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Race contract", func() {
+	It("should support the runtime code of the embedded Race contract", func() {
+		code, err := RaceRuntimeCode()
+		Expect(err).NotTo(HaveOccurred())
+
+		version, ok := RaceVersion(code)
+		Expect(ok).To(BeTrue(), "add the runtime code hash of the new Race.bin to raceCodeHashes")
+		Expect(version).NotTo(BeEmpty())
+	})
+
+	It("should not find runtime code that isn't copied out", func() {
+		_, err := runtimeCode([]byte{0x60, 0x80, 0x60, 0x40, 0x52})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not support other code", func() {
+		_, ok := RaceVersion(nil)
+		Expect(ok).To(BeFalse())
+
+		_, ok = RaceVersion([]byte{0x60, 0x80, 0x60, 0x40, 0x52})
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,121 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// The field manager the operator server-side applies child resources with
+const fieldManager = "racecourse-operator"
+
+// The field managers that wrote child resources before they were server-side applied
+var legacyFieldManagers = sets.New("manager")
+
+// Server-side applies a child resource, unless the fields the operator owns on the
+// existing object already match the desired ones, so steady-state reconciles don't
+// write anything. found is filled in with the existing object, if there is one.
+func applyChild[T client.Object, A namedApplyConfiguration](
+	ctx context.Context,
+	c client.Client,
+	found T,
+	desired A,
+	extract func(T, string) (A, error),
+) (controllerutil.OperationResult, error) {
+	key := types.NamespacedName{Name: *desired.GetName(), Namespace: *desired.GetNamespace()}
+	err := c.Get(ctx, key, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return controllerutil.OperationResultNone, err
+	}
+
+	result := controllerutil.OperationResultCreated
+	if err == nil {
+		result = controllerutil.OperationResultUpdated
+
+		// Hand over fields written by earlier versions of the operator, which used
+		// Update, so that fields it stops setting get removed
+		patch, err := csaupgrade.UpgradeManagedFieldsPatch(found, legacyFieldManagers, fieldManager)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		if patch != nil {
+			if err := c.Patch(ctx, found, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+				return controllerutil.OperationResultNone, err
+			}
+		}
+
+		owned, err := extract(found, fieldManager)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		if equality.Semantic.DeepEqual(owned, desired) {
+			return controllerutil.OperationResultNone, nil
+		}
+	}
+
+	if err := c.Apply(ctx, desired, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	return result, nil
+}
+
+// Reports whether a field manager other than the operator owns a field of the object,
+// like a HorizontalPodAutoscaler owning a Deployment's spec.replicas
+func managedElsewhere(obj metav1.Object, path ...string) bool {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == fieldManager || legacyFieldManagers.Has(entry.Manager) || entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields map[string]any
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		found := true
+		for _, name := range path {
+			next, ok := fields["f:"+name].(map[string]any)
+			if !ok {
+				found = false
+				break
+			}
+			fields = next
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// The generated apply configurations of namespaced objects, which all carry their
+// name and namespace
+type namedApplyConfiguration interface {
+	runtime.ApplyConfiguration
+	GetName() *string
+	GetNamespace() *string
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse server-side apply", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		key        types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().Build()
+		reconciler = &RacecourseReconciler{Client: c, Scheme: testScheme, Recorder: record.NewFakeRecorder(100)}
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas: ptr.To(int32(2)),
+				Image:    racecoursev1beta1.ImageSpec{Repository: "racecourse", Tag: "0.0.1", PullPolicy: corev1.PullIfNotPresent},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+				},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+	})
+
+	It("should not write a Deployment that is already up to date", func() {
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		resourceVersion := deployment.ResourceVersion
+
+		result, err := applyChild(ctx, c, &appsv1.Deployment{}, reconciler.buildDeployment(racecourse, true), appsv1ac.ExtractDeployment)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(controllerutil.OperationResultNone))
+
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.ResourceVersion).To(Equal(resourceVersion))
+	})
+
+	It("should apply changes to the Deployment", func() {
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+
+		racecourse.Spec.Image.Tag = "1.0.0"
+		result, err := applyChild(ctx, c, &appsv1.Deployment{}, reconciler.buildDeployment(racecourse, true), appsv1ac.ExtractDeployment)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(controllerutil.OperationResultUpdated))
+
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("racecourse:1.0.0"))
+	})
+
+	It("should roll the pods when the generated config changes", func() {
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		hash := deployment.Spec.Template.Annotations[configHashAnnotation]
+		Expect(hash).NotTo(BeEmpty())
+
+		By("changing something that isn't in the config")
+		racecourse.Spec.Replicas = ptr.To(int32(3))
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(configHashAnnotation, hash))
+
+		By("changing the contract address")
+		racecourse.Spec.ContractAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Annotations[configHashAnnotation]).NotTo(Equal(hash))
+		Expect(deployment.Spec.Template.Annotations[configHashAnnotation]).To(Equal(configHash(configData(racecourse))))
+	})
+
+	It("should leave replicas to another field manager that scales the Deployment", func() {
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+
+		By("scaling the Deployment as an autoscaler would")
+		scale := appsv1ac.Deployment("racecourse", "default").WithSpec(appsv1ac.DeploymentSpec().WithReplicas(5))
+		Expect(c.Apply(ctx, scale, client.FieldOwner("autoscaler"), client.ForceOwnership)).To(Succeed())
+
+		racecourse.Spec.Image.Tag = "1.0.0"
+		Expect(reconciler.reconcileDeployment(ctx, racecourse)).To(Succeed())
+
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(5)))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("racecourse:1.0.0"))
+	})
+
+	It("should give an Ingress with no host set the default one", func() {
+		ingress := reconciler.buildIngress(racecourse)
+		Expect(ingress.Spec.Rules[0].Host).To(HaveValue(Equal(racecoursev1beta1.DefaultIngressHost)))
+
+		racecourse.Spec.Ingress.Host = "racecourse.example.com"
+		ingress = reconciler.buildIngress(racecourse)
+		Expect(ingress.Spec.Rules[0].Host).To(HaveValue(Equal("racecourse.example.com")))
+	})
+
+	It("should leave fields set by other controllers alone", func() {
+		Expect(reconciler.reconcileService(ctx, racecourse)).To(Succeed())
+
+		service := &corev1.Service{}
+		Expect(c.Get(ctx, key, service)).To(Succeed())
+		service.Annotations = map[string]string{"example.com/injected": "true"}
+		Expect(c.Update(ctx, service, client.FieldOwner("other-controller"))).To(Succeed())
+
+		Expect(reconciler.reconcileService(ctx, racecourse)).To(Succeed())
+		Expect(c.Get(ctx, key, service)).To(Succeed())
+		Expect(service.Annotations).To(HaveKeyWithValue("example.com/injected", "true"))
+	})
+})
@@ -0,0 +1,479 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/besu"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How long validators wait for a proposal before moving to the next round
+const besuRequestTimeoutSeconds = 10
+
+// The reason of the Warning event recorded when the genesis the chain started from is gone
+const eventReasonGenesisLost = "GenesisLost"
+
+// How long each phase of a reconcile may spend on JSON-RPC calls to the nodes. The
+// nodes are asked one at a time, so a few that don't answer mustn't hold it up.
+const besuRPCPhaseTimeout = 10 * time.Second
+
+// BesuNetworkReconciler reconciles a BesuNetwork object
+type BesuNetworkReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Reads the node keys Secret straight from the API server, so the manager doesn't
+	// have to cache every Secret in the cluster, and the genesis, so a stale cache can't
+	// get it rendered again
+	APIReader client.Reader
+
+	// Options for the JSON-RPC clients used to talk to the nodes
+	RPCOptions []ethrpc.Option
+
+	// Gives the JSON-RPC URL of a node. If nil, nodes are reached through the headless Service.
+	NodeURL func(network *racecoursev1beta1.BesuNetwork, node string) string
+}
+
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=besunetworks,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=besunetworks/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=besunetworks/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;create;update
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *BesuNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	network := &racecoursev1beta1.BesuNetwork{}
+	if err := r.Get(ctx, req.NamespacedName, network); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("BesuNetwork resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get BesuNetwork")
+		return ctrl.Result{}, err
+	}
+
+	if !network.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("Reconciling BesuNetwork", "name", network.Name, "namespace", network.Namespace)
+
+	original := network.Status.DeepCopy()
+	keys, err := r.reconcileKeys(ctx, network)
+	if err != nil {
+		log.Error(err, "Failed to reconcile the node keys")
+		return ctrl.Result{}, err
+	}
+
+	// Nodes voted out of the validator set keep running until the chain has removed them
+	chain := r.observeValidators(ctx, network, keys)
+	nodes := besuNodeCount(network, keys, chain.validators)
+	keys = keys[:nodes]
+
+	genesis, err := r.reconcileGenesis(ctx, network, keys)
+	if err != nil {
+		log.Error(err, "Failed to reconcile the genesis")
+		return ctrl.Result{}, err
+	}
+	if genesis == "" {
+		if !equality.Semantic.DeepEqual(original, &network.Status) {
+			if err := r.Status().Update(ctx, network); err != nil {
+				log.Error(err, "Failed to update BesuNetwork status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: besuHealthCheckInterval}, nil
+	}
+
+	for _, service := range r.buildServices(network) {
+		result, err := applyChild(ctx, r.Client, &corev1.Service{}, service, corev1ac.ExtractService)
+		recordApply(r.Recorder, network, "Service", *service.Name, result, err)
+		if err != nil {
+			log.Error(err, "Failed to apply Service", "name", *service.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	statefulSet := r.buildStatefulSet(network, int32(nodes))
+	result, err := applyChild(ctx, r.Client, &appsv1.StatefulSet{}, statefulSet, appsv1ac.ExtractStatefulSet)
+	recordApply(r.Recorder, network, "StatefulSet", *statefulSet.Name, result, err)
+	if err != nil {
+		log.Error(err, "Failed to apply StatefulSet")
+		return ctrl.Result{}, err
+	}
+
+	upgrade, err := r.reconcileUpgrade(ctx, network, nodes)
+	if err != nil {
+		log.Error(err, "Failed to upgrade the nodes")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileValidatorSet(ctx, network, keys, chain, upgrade != nil); err != nil {
+		log.Error(err, "Failed to reconcile the validator set")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.updateStatus(ctx, network, original, keys, genesis, chain.validators, upgrade); err != nil {
+		log.Error(err, "Failed to update BesuNetwork status")
+		return ctrl.Result{}, err
+	}
+
+	if upgrade != nil || len(network.Status.PendingValidatorChanges) > 0 {
+		return ctrl.Result{RequeueAfter: besuProgressCheckInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: besuHealthCheckInterval}, nil
+}
+
+// Loads the public key of every node, generating the keys of nodes that don't have one
+// yet. Keys are never removed, so a node scaled away and back keeps its identity, and the
+// keys of nodes beyond spec.validators are returned too. The public keys are recorded in
+// the status, so a private key is only parsed the first time it's seen.
+func (r *BesuNetworkReconciler) reconcileKeys(ctx context.Context, network *racecoursev1beta1.BesuNetwork) ([]besu.NodeIdentity, error) {
+	secret := &corev1.Secret{}
+	err := r.APIReader.Get(ctx, types.NamespacedName{Name: besuKeysSecretName(network), Namespace: network.Namespace}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+	if !exists {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      besuKeysSecretName(network),
+				Namespace: network.Namespace,
+				Labels:    besuLabels(network),
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		if err := controllerutil.SetControllerReference(network, secret, r.Scheme); err != nil {
+			return nil, err
+		}
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	if network.Status.NodePublicKeys == nil {
+		network.Status.NodePublicKeys = map[string]string{}
+	}
+	var keys []besu.NodeIdentity
+	generated := false
+	for i := 0; ; i++ {
+		name := besuNodeName(network, i)
+		value, ok := secret.Data[name]
+		if ok {
+			// Keys are never replaced, so the public key recorded for the node still
+			// matches its private key
+			if node, err := besu.ParseNodeIdentity(network.Status.NodePublicKeys[name]); err == nil {
+				keys = append(keys, node)
+				continue
+			}
+			key, err := besu.ParseNodeKey(string(value))
+			if err != nil {
+				return nil, fmt.Errorf("key %s in Secret %s: %w", name, secret.Name, err)
+			}
+			keys = append(keys, key.NodeIdentity)
+			network.Status.NodePublicKeys[name] = key.EnodeID()
+			continue
+		}
+		if i >= int(network.Spec.Validators) {
+			break
+		}
+
+		key, err := besu.GenerateNodeKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.NodeIdentity)
+		secret.Data[name] = []byte(key.Hex())
+		network.Status.NodePublicKeys[name] = key.EnodeID()
+		generated = true
+	}
+
+	switch {
+	case !exists:
+		err = r.Create(ctx, secret)
+		recordApply(r.Recorder, network, "Secret", secret.Name, controllerutil.OperationResultCreated, err)
+	case generated:
+		err = r.Update(ctx, secret)
+		recordApply(r.Recorder, network, "Secret", secret.Name, controllerutil.OperationResultUpdated, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Applies the ConfigMap holding the genesis and the static nodes, which list the nodes
+// with the keys, and returns the genesis. The genesis is rendered once, from the nodes
+// that exist when the network is created, and kept from then on, since the chain is
+// bound to it. If it's gone after that, the network is marked Degraded and the genesis
+// returned is empty: another would start a different chain.
+func (r *BesuNetworkReconciler) reconcileGenesis(ctx context.Context, network *racecoursev1beta1.BesuNetwork, keys []besu.NodeIdentity) (string, error) {
+	log := log.FromContext(ctx)
+
+	found := &corev1.ConfigMap{}
+	err := r.APIReader.Get(ctx, types.NamespacedName{Name: besuGenesisConfigMapName(network), Namespace: network.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	genesis := found.Data["genesis.json"]
+	if genesis == "" && len(network.Status.GenesisValidators) > 0 {
+		message := fmt.Sprintf("the genesis in ConfigMap %s is gone; restore it from a backup or a node's data, since a new one would start a different chain",
+			besuGenesisConfigMapName(network))
+		if degraded := meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeDegraded); degraded == nil || degraded.Reason != racecoursev1beta1.ReasonGenesisLost {
+			r.Recorder.Event(network, corev1.EventTypeWarning, eventReasonGenesisLost, message)
+		}
+		log.Info("Not rendering the genesis again", "configMap", besuGenesisConfigMapName(network))
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, racecoursev1beta1.ReasonGenesisLost, message)
+		return "", nil
+	}
+	if genesis == "" {
+		validators := make([]string, network.Spec.Validators)
+		for i := range validators {
+			validators[i] = keys[i].Address()
+		}
+		alloc := make(map[string]string, len(network.Spec.Alloc))
+		for address, account := range network.Spec.Alloc {
+			alloc[address] = account.Balance
+		}
+
+		rendered, err := besu.RenderGenesis(besu.GenesisConfig{
+			ChainID:               network.Spec.ChainID,
+			BlockPeriodSeconds:    network.Spec.BlockPeriodSeconds,
+			EpochLength:           network.Spec.EpochLength,
+			RequestTimeoutSeconds: besuRequestTimeoutSeconds,
+			GasLimit:              network.Spec.GasLimit,
+			Timestamp:             network.CreationTimestamp.Unix(),
+			Alloc:                 alloc,
+			Validators:            validators,
+		})
+		if err != nil {
+			return "", err
+		}
+		genesis = string(rendered)
+	}
+
+	// Nodes only read the static nodes when they start, but the nodes that join on a
+	// scale up dial every node listed, which is enough to connect them all
+	staticNodes, err := besuStaticNodes(network, keys)
+	if err != nil {
+		return "", err
+	}
+
+	configMap := r.buildGenesisConfigMap(network, genesis, staticNodes)
+	result, err := applyChild(ctx, r.Client, found, configMap, corev1ac.ExtractConfigMap)
+	recordApply(r.Recorder, network, "ConfigMap", *configMap.Name, result, err)
+	if err != nil {
+		return "", err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Applied ConfigMap", "name", *configMap.Name, "result", result)
+	}
+	return genesis, nil
+}
+
+func (r *BesuNetworkReconciler) updateStatus(ctx context.Context, network *racecoursev1beta1.BesuNetwork, original *racecoursev1beta1.BesuNetworkStatus, keys []besu.NodeIdentity, genesis string, validators []string, upgrade *upgradeProgress) error {
+	log := log.FromContext(ctx)
+
+	genesisValidators, err := besu.GenesisValidators([]byte(genesis))
+	if err != nil {
+		return fmt.Errorf("unable to read the validators from the genesis: %w", err)
+	}
+	network.Status.GenesisValidators = genesisValidators
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(network.Namespace), client.MatchingLabels(besuLabels(network))); err != nil {
+		return err
+	}
+	ready := map[string]bool{}
+	for i := range pods.Items {
+		ready[pods.Items[i].Name] = podReady(&pods.Items[i])
+	}
+
+	network.Status.Nodes = make([]racecoursev1beta1.BesuNodeStatus, len(keys))
+	network.Status.ReadyNodes = 0
+	readyValidators := 0
+	for i, key := range keys {
+		name := besuNodeName(network, i)
+		network.Status.Nodes[i] = racecoursev1beta1.BesuNodeStatus{
+			Name:    name,
+			Address: key.Address(),
+			Enode:   besuEnode(network, i, key),
+			Ready:   ready[name],
+		}
+		if ready[name] {
+			network.Status.ReadyNodes++
+			if slices.Contains(validators, key.Address()) {
+				readyValidators++
+			}
+		}
+	}
+	setQuorumCondition(network, readyValidators, len(validators))
+	r.checkNodeHealth(ctx, network, validators)
+	setHealthCondition(network, len(validators))
+
+	statefulSet := &appsv1.StatefulSet{}
+	err = r.Get(ctx, types.NamespacedName{Name: network.Name, Namespace: network.Namespace}, statefulSet)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err != nil {
+		statefulSet = nil
+	}
+	setStatefulSetCondition(network, statefulSet)
+	if upgrade != nil {
+		setUpgradeCondition(network, upgrade)
+	}
+
+	network.Status.RPCURL = fmt.Sprintf("http://%s-rpc.%s.svc.cluster.local:%d", network.Name, network.Namespace, besuRPCPort)
+	network.Status.WebSocketURL = fmt.Sprintf("ws://%s-ws.%s.svc.cluster.local:%d", network.Name, network.Namespace, besuWSPort)
+	network.Status.ObservedGeneration = network.Generation
+
+	if equality.Semantic.DeepEqual(original, &network.Status) {
+		return nil
+	}
+	log.Info("Updating status", "readyNodes", network.Status.ReadyNodes)
+	return r.Status().Update(ctx, network)
+}
+
+// Sets a condition on the BesuNetwork, stamped with the generation it was computed from
+func setNetworkCondition(network *racecoursev1beta1.BesuNetwork, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&network.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: network.Generation,
+	})
+}
+
+// The number of the validators that have to take part for QBFT to agree on blocks
+func besuQuorum(validators int) int {
+	return (2*validators + 2) / 3
+}
+
+// Sets the Available condition from how many of the current validators are ready
+func setQuorumCondition(network *racecoursev1beta1.BesuNetwork, ready, validators int) {
+	message := fmt.Sprintf("%d/%d validators ready, %d needed to produce blocks", ready, validators, besuQuorum(validators))
+	if validators > 0 && ready >= besuQuorum(validators) {
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeAvailable, metav1.ConditionTrue, racecoursev1beta1.ReasonQuorumAvailable, message)
+	} else {
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeAvailable, metav1.ConditionFalse, racecoursev1beta1.ReasonQuorumUnavailable, message)
+	}
+}
+
+// Sets the Progressing condition from the nodes' StatefulSet, which is nil if it
+// doesn't exist yet
+func setStatefulSetCondition(network *racecoursev1beta1.BesuNetwork, statefulSet *appsv1.StatefulSet) {
+	if statefulSet == nil {
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionTrue, racecoursev1beta1.ReasonStatefulSetNotFound,
+			"the StatefulSet has not been created yet")
+		return
+	}
+
+	desired := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		desired = *statefulSet.Spec.Replicas
+	}
+	rolledOut := statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+		statefulSet.Status.UpdatedReplicas == desired &&
+		statefulSet.Status.ReadyReplicas == desired &&
+		statefulSet.Status.CurrentRevision == statefulSet.Status.UpdateRevision
+	if rolledOut {
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionFalse, racecoursev1beta1.ReasonRolloutComplete,
+			fmt.Sprintf("all %d nodes are up to date", desired))
+	} else {
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionTrue, racecoursev1beta1.ReasonRollingOut,
+			fmt.Sprintf("%d/%d nodes updated, %d ready", statefulSet.Status.UpdatedReplicas, desired, statefulSet.Status.ReadyReplicas))
+	}
+}
+
+// Sets the Progressing condition from an upgrade under way, which is no longer
+// progressing once it has halted
+func setUpgradeCondition(network *racecoursev1beta1.BesuNetwork, upgrade *upgradeProgress) {
+	status := metav1.ConditionTrue
+	if upgrade.halted {
+		status = metav1.ConditionFalse
+	}
+	setNetworkCondition(network, racecoursev1beta1.ConditionTypeProgressing, status, upgrade.reason, upgrade.message)
+}
+
+func (r *BesuNetworkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Every health check writes the status, which mustn't trigger another reconcile
+		For(&racecoursev1beta1.BesuNetwork{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(besuNetworkForPod)).
+		Named("besunetwork").
+		Complete(r)
+}
+
+// Maps a node pod to its BesuNetwork, so the ready nodes are counted as they change
+func besuNetworkForPod(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels["app"] != "besu" || labels["besunetwork"] == "" {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: labels["besunetwork"], Namespace: obj.GetNamespace()},
+	}}
+}
+
+// Reports whether the pod's Ready condition is true
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,468 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/besu"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// The chain as one Besu node sees it
+type fakeBesuNode struct {
+	mu         sync.Mutex
+	head       uint64
+	peers      uint64
+	syncing    bool
+	validators []string
+	// The node's pending votes, by address
+	votes map[string]bool
+}
+
+func (n *fakeBesuNode) set(head, peers uint64, syncing bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.head, n.peers, n.syncing = head, peers, syncing
+}
+
+func (n *fakeBesuNode) setValidators(validators []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.validators = validators
+}
+
+// The node's pending votes, by address
+func (n *fakeBesuNode) pendingVotes() map[string]bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return maps.Clone(n.votes)
+}
+
+// Serves the JSON-RPC methods of a Besu node that the operator uses to follow it
+func fakeBesuNodeServer(node *fakeBesuNode) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer GinkgoRecover()
+		request := &struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		Expect(json.NewDecoder(r.Body).Decode(request)).To(Succeed())
+
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		var result any
+		switch request.Method {
+		case "eth_blockNumber":
+			result = fmt.Sprintf("0x%x", node.head)
+		case "net_peerCount":
+			result = fmt.Sprintf("0x%x", node.peers)
+		case "eth_syncing":
+			result = false
+			if node.syncing {
+				result = map[string]string{"currentBlock": "0x1", "highestBlock": fmt.Sprintf("0x%x", node.head)}
+			}
+		case "qbft_getValidatorsByBlockNumber":
+			Expect(string(request.Params[0])).To(Equal(`"latest"`))
+			result = node.validators
+		case "qbft_getPendingVotes":
+			result = node.votes
+		case "qbft_proposeValidatorVote":
+			var address string
+			var add bool
+			Expect(json.Unmarshal(request.Params[0], &address)).To(Succeed())
+			Expect(json.Unmarshal(request.Params[1], &add)).To(Succeed())
+			if node.votes == nil {
+				node.votes = map[string]bool{}
+			}
+			node.votes[address] = add
+			result = true
+		case "qbft_discardValidatorVote":
+			var address string
+			Expect(json.Unmarshal(request.Params[0], &address)).To(Succeed())
+			delete(node.votes, address)
+			result = true
+		}
+		Expect(json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})).To(Succeed())
+	}))
+}
+
+// A BesuNetwork reconciled against a fake client, with a fake JSON-RPC server for each
+// of its first nodes
+type besuTestNetwork struct {
+	ctx        context.Context
+	c          client.Client
+	recorder   *record.FakeRecorder
+	reconciler *BesuNetworkReconciler
+	key        types.NamespacedName
+	// The fake nodes and the URLs they are reached at, by pod name. Pointing a node's URL
+	// elsewhere takes effect on the next reconcile.
+	nodes map[string]*fakeBesuNode
+	urls  map[string]string
+}
+
+// Creates a network of four validators, runs a fake node for each of the first count
+// pod names, and wires a reconciler to them. The network can be changed before it's
+// created with configure, which may be nil.
+func newBesuTestNetwork(count int, configure func(network *racecoursev1beta1.BesuNetwork)) *besuTestNetwork {
+	network := &racecoursev1beta1.BesuNetwork{
+		ObjectMeta: metav1.ObjectMeta{Name: "besu", Namespace: "default", UID: "besu-uid"},
+		Spec: racecoursev1beta1.BesuNetworkSpec{
+			Validators:         4,
+			ChainID:            1337,
+			BlockPeriodSeconds: 5,
+			EpochLength:        30000,
+			GasLimit:           "0x1fffffffffffff",
+			Image:              "hyperledger/besu:24.12.0",
+		},
+	}
+	if configure != nil {
+		configure(network)
+	}
+
+	b := &besuTestNetwork{
+		ctx:      context.Background(),
+		recorder: record.NewFakeRecorder(100),
+		key:      types.NamespacedName{Name: network.Name, Namespace: network.Namespace},
+		nodes:    map[string]*fakeBesuNode{},
+		urls:     map[string]string{},
+	}
+	for i := range count {
+		name := besuNodeName(network, i)
+		b.nodes[name] = &fakeBesuNode{}
+		server := fakeBesuNodeServer(b.nodes[name])
+		DeferCleanup(server.Close)
+		b.urls[name] = server.URL
+	}
+
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+	b.c = fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().
+		WithObjects(network).WithStatusSubresource(network).Build()
+	b.reconciler = &BesuNetworkReconciler{
+		Client:    b.c,
+		Scheme:    testScheme,
+		Recorder:  b.recorder,
+		APIReader: b.c,
+		// Nodes without a fake aren't reachable, so don't wait on them
+		RPCOptions: []ethrpc.Option{ethrpc.WithRetries(0, 0)},
+		NodeURL: func(_ *racecoursev1beta1.BesuNetwork, node string) string {
+			return b.urls[node]
+		},
+	}
+	return b
+}
+
+// Reconciles the network and returns the result and the network as it was left
+func (b *besuTestNetwork) reconcileNetwork() (reconcile.Result, *racecoursev1beta1.BesuNetwork) {
+	result, err := b.reconciler.Reconcile(b.ctx, reconcile.Request{NamespacedName: b.key})
+	Expect(err).NotTo(HaveOccurred())
+	return result, b.network()
+}
+
+// Gets the network as it is now
+func (b *besuTestNetwork) network() *racecoursev1beta1.BesuNetwork {
+	network := &racecoursev1beta1.BesuNetwork{}
+	Expect(b.c.Get(b.ctx, b.key, network)).To(Succeed())
+	return network
+}
+
+// Changes the spec of the network
+func (b *besuTestNetwork) update(change func(spec *racecoursev1beta1.BesuNetworkSpec)) {
+	network := b.network()
+	change(&network.Spec)
+	Expect(b.c.Update(b.ctx, network)).To(Succeed())
+}
+
+// Runs a ready pod of the node, on the StatefulSet revision unless it's empty
+func (b *besuTestNetwork) runNode(name, revision string) {
+	labels := map[string]string{"app": "besu", "besunetwork": b.key.Name}
+	if revision != "" {
+		labels[appsv1.ControllerRevisionHashLabelKey] = revision
+	}
+	Expect(b.c.Create(b.ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.key.Namespace,
+			UID:       types.UID(name + "-" + revision),
+			Labels:    labels,
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	})).To(Succeed())
+}
+
+// Has every node report the head block and the validators the genesis was made with
+func (b *besuTestNetwork) startChain(head uint64) {
+	validators := b.network().Status.GenesisValidators
+	for _, node := range b.nodes {
+		node.set(head, 3, false)
+		node.setValidators(validators)
+	}
+}
+
+var _ = Describe("BesuNetwork Controller", func() {
+	var env *besuTestNetwork
+
+	keys := func() map[string][]byte {
+		secret := &corev1.Secret{}
+		Expect(env.c.Get(env.ctx, types.NamespacedName{Name: "besu-node-keys", Namespace: "default"}, secret)).To(Succeed())
+		return secret.Data
+	}
+
+	genesisConfigMap := func() *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{}
+		Expect(env.c.Get(env.ctx, types.NamespacedName{Name: "besu-genesis", Namespace: "default"}, configMap)).To(Succeed())
+		return configMap
+	}
+
+	BeforeEach(func() {
+		env = newBesuTestNetwork(0, func(network *racecoursev1beta1.BesuNetwork) {
+			network.CreationTimestamp = metav1.Unix(1700000000, 0)
+			network.Spec.Alloc = map[string]racecoursev1beta1.GenesisAccount{
+				"0xfe3b557e8fb62b89f4916b721be55ceb828dbd73": {Balance: "0xad78ebc5ac6200000"},
+			}
+		})
+	})
+
+	It("should generate a key for every node and keep them", func() {
+		env.reconcileNetwork()
+		generated := keys()
+		Expect(generated).To(HaveLen(4))
+		for i := range 4 {
+			_, err := besu.ParseNodeKey(string(generated[fmt.Sprintf("besu-%d", i)]))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		env.reconcileNetwork()
+		Expect(keys()).To(Equal(generated))
+	})
+
+	It("should record the public keys and not parse the private keys again", func() {
+		_, network := env.reconcileNetwork()
+		Expect(network.Status.NodePublicKeys).To(HaveLen(4))
+		for i := range 4 {
+			name := fmt.Sprintf("besu-%d", i)
+			nodeKey, err := besu.ParseNodeKey(string(keys()[name]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(network.Status.NodePublicKeys).To(HaveKeyWithValue(name, nodeKey.EnodeID()))
+		}
+		address := network.Status.Nodes[0].Address
+
+		// A key that can't be parsed only fails the reconcile if it has to be parsed
+		secret := &corev1.Secret{}
+		Expect(env.c.Get(env.ctx, types.NamespacedName{Name: "besu-node-keys", Namespace: "default"}, secret)).To(Succeed())
+		secret.Data["besu-0"] = []byte("not a key")
+		Expect(env.c.Update(env.ctx, secret)).To(Succeed())
+
+		_, network = env.reconcileNetwork()
+		Expect(network.Status.Nodes[0].Address).To(Equal(address))
+	})
+
+	It("should write the node addresses into the genesis validators", func() {
+		_, network := env.reconcileNetwork()
+
+		addresses := []string{}
+		for i := range 4 {
+			nodeKey, err := besu.ParseNodeKey(string(keys()[fmt.Sprintf("besu-%d", i)]))
+			Expect(err).NotTo(HaveOccurred())
+			addresses = append(addresses, nodeKey.Address())
+		}
+
+		validators, err := besu.GenesisValidators([]byte(genesisConfigMap().Data["genesis.json"]))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(validators).To(Equal(addresses))
+		Expect(network.Status.GenesisValidators).To(Equal(addresses))
+		Expect(network.Status.Nodes).To(HaveLen(4))
+		Expect(network.Status.Nodes[0].Address).To(Equal(addresses[0]))
+
+		var genesis map[string]any
+		Expect(json.Unmarshal([]byte(genesisConfigMap().Data["genesis.json"]), &genesis)).To(Succeed())
+		Expect(genesis).To(HaveKeyWithValue("timestamp", "0x6553F100"))
+		Expect(genesis["config"]).To(HaveKeyWithValue("chainId", BeNumerically("==", 1337)))
+		Expect(genesis["alloc"]).To(HaveKey("0xfe3b557e8fb62b89f4916b721be55ceb828dbd73"))
+	})
+
+	It("should keep the genesis when the cache hasn't seen its ConfigMap", func() {
+		env.reconcileNetwork()
+		genesis := genesisConfigMap().Data["genesis.json"]
+
+		// A genesis rendered now would list the fifth validator too
+		env.update(func(spec *racecoursev1beta1.BesuNetworkSpec) { spec.Validators = 5 })
+		env.reconciler.Client = interceptor.NewClient(env.c.(client.WithWatch), interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*corev1.ConfigMap); ok && key.Name == "besu-genesis" {
+					return errors.NewNotFound(corev1.Resource("configmaps"), key.Name)
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		})
+		env.reconcileNetwork()
+		Expect(genesisConfigMap().Data["genesis.json"]).To(Equal(genesis))
+	})
+
+	It("should mark the network Degraded rather than render the genesis again once it's gone", func() {
+		env.reconcileNetwork()
+		configMap := genesisConfigMap()
+		genesis := configMap.Data["genesis.json"]
+		Expect(env.c.Delete(env.ctx, configMap)).To(Succeed())
+
+		// Counts the GenesisLost events recorded since the last call
+		lostEvents := func() int {
+			count := 0
+			for len(env.recorder.Events) > 0 {
+				if strings.Contains(<-env.recorder.Events, "GenesisLost") {
+					count++
+				}
+			}
+			return count
+		}
+		lostEvents()
+
+		_, network := env.reconcileNetwork()
+		err := env.c.Get(env.ctx, types.NamespacedName{Name: "besu-genesis", Namespace: "default"}, &corev1.ConfigMap{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		degraded := meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeDegraded)
+		Expect(degraded).NotTo(BeNil())
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(racecoursev1beta1.ReasonGenesisLost))
+		Expect(lostEvents()).To(Equal(1))
+
+		env.reconcileNetwork()
+		Expect(lostEvents()).To(BeZero())
+
+		// Restoring the ConfigMap brings the network back
+		Expect(env.c.Create(env.ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "besu-genesis", Namespace: "default"},
+			Data:       map[string]string{"genesis.json": genesis},
+		})).To(Succeed())
+		_, network = env.reconcileNetwork()
+		Expect(genesisConfigMap().Data["genesis.json"]).To(Equal(genesis))
+		Expect(genesisConfigMap().Data).To(HaveKey("static-nodes.json"))
+		degraded = meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeDegraded)
+		Expect(degraded.Reason).NotTo(Equal(racecoursev1beta1.ReasonGenesisLost))
+	})
+
+	It("should keep the genesis and grow the static nodes when scaled up", func() {
+		env.reconcileNetwork()
+		genesis := genesisConfigMap().Data["genesis.json"]
+		generated := keys()
+
+		env.update(func(spec *racecoursev1beta1.BesuNetworkSpec) { spec.Validators = 5 })
+		_, network := env.reconcileNetwork()
+
+		Expect(genesisConfigMap().Data["genesis.json"]).To(Equal(genesis))
+		Expect(keys()).To(HaveLen(5))
+		for name, value := range generated {
+			Expect(keys()).To(HaveKeyWithValue(name, value))
+		}
+
+		var staticNodes []string
+		Expect(json.Unmarshal([]byte(genesisConfigMap().Data["static-nodes.json"]), &staticNodes)).To(Succeed())
+		Expect(staticNodes).To(HaveLen(5))
+		Expect(staticNodes[4]).To(Equal(network.Status.Nodes[4].Enode))
+		Expect(staticNodes[4]).To(HaveSuffix("@besu-4.besu-headless.default.svc.cluster.local:30303"))
+		Expect(network.Status.GenesisValidators).To(HaveLen(4))
+
+		statefulSet := &appsv1.StatefulSet{}
+		Expect(env.c.Get(env.ctx, env.key, statefulSet)).To(Succeed())
+		Expect(statefulSet.Spec.Replicas).To(HaveValue(Equal(int32(5))))
+	})
+
+	It("should run the nodes with their keys, genesis and static nodes", func() {
+		env.reconcileNetwork()
+
+		statefulSet := &appsv1.StatefulSet{}
+		Expect(env.c.Get(env.ctx, env.key, statefulSet)).To(Succeed())
+		Expect(statefulSet.Spec.ServiceName).To(Equal("besu-headless"))
+		Expect(statefulSet.OwnerReferences).To(ContainElement(HaveField("Kind", "BesuNetwork")))
+		container := statefulSet.Spec.Template.Spec.Containers[0]
+		Expect(container.Args).To(ContainElements(
+			"--genesis-file=/config/genesis.json",
+			"--static-nodes-file=/config/static-nodes.json",
+			"--node-private-key-file=/keys/key",
+			"--rpc-http-api=ETH,NET,WEB3,QBFT,TXPOOL,ADMIN",
+		))
+
+		// Only the init container sees the keys Secret, and it copies the pod's own key
+		volumes := map[string]corev1.Volume{}
+		for _, volume := range statefulSet.Spec.Template.Spec.Volumes {
+			volumes[volume.Name] = volume
+		}
+		Expect(volumes["node-keys"].Secret).NotTo(BeNil())
+		Expect(volumes["node-keys"].Secret.SecretName).To(Equal("besu-node-keys"))
+		Expect(volumes["keys"].EmptyDir).NotTo(BeNil())
+		Expect(container.VolumeMounts).NotTo(ContainElement(HaveField("Name", "node-keys")))
+		Expect(statefulSet.Spec.Template.Spec.InitContainers).To(HaveLen(1))
+		initContainer := statefulSet.Spec.Template.Spec.InitContainers[0]
+		Expect(initContainer.Command).To(Equal([]string{"cp", "/node-keys/$(POD_NAME)", "/keys/key"}))
+		Expect(initContainer.VolumeMounts).To(ContainElements(
+			HaveField("Name", "node-keys"),
+			HaveField("Name", "keys"),
+		))
+
+		for _, name := range []string{"besu-headless", "besu-rpc", "besu-ws"} {
+			Expect(env.c.Get(env.ctx, types.NamespacedName{Name: name, Namespace: "default"}, &corev1.Service{})).To(Succeed())
+		}
+	})
+
+	It("should report the network available once a quorum of validators is ready", func() {
+		_, network := env.reconcileNetwork()
+		available := meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeAvailable)
+		Expect(available.Status).To(Equal(metav1.ConditionFalse))
+		Expect(available.Reason).To(Equal(racecoursev1beta1.ReasonQuorumUnavailable))
+		Expect(network.Status.RPCURL).To(Equal("http://besu-rpc.default.svc.cluster.local:8545"))
+
+		for i := range 3 {
+			env.runNode(fmt.Sprintf("besu-%d", i), "")
+		}
+
+		_, network = env.reconcileNetwork()
+		Expect(network.Status.ReadyNodes).To(Equal(int32(3)))
+		available = meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeAvailable)
+		Expect(available.Status).To(Equal(metav1.ConditionTrue))
+		Expect(available.Message).To(Equal("3/4 validators ready, 3 needed to produce blocks"))
+	})
+})
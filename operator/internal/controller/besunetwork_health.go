@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// How often the nodes' health is checked when nothing else is under way
+const besuHealthCheckInterval = 30 * time.Second
+
+// How many blocks a node may be behind the network, unless the spec says otherwise
+const defaultBesuMaxBlockLag = 10
+
+// How many block periods the network may go without a new block before it's degraded
+const besuStallBlockPeriods = 5
+
+// Fills in the health of each node in the status from its answers to net_peerCount,
+// eth_syncing and eth_blockNumber, whether it is a validator, and how far it is behind
+// the highest head on the network. Nodes whose pods aren't ready aren't asked. The
+// highest head is kept in the status with when it was first seen, so a chain that has
+// stopped altogether is noticed even though no node is behind.
+func (r *BesuNetworkReconciler) checkNodeHealth(ctx context.Context, network *racecoursev1beta1.BesuNetwork, validators []string) {
+	ctx, cancel := context.WithTimeout(ctx, besuRPCPhaseTimeout)
+	defer cancel()
+
+	nodes := network.Status.Nodes
+	var networkHead int64
+	responding := false
+	for i := range nodes {
+		node := &nodes[i]
+		node.Validator = slices.Contains(validators, node.Address)
+		if !node.Ready {
+			continue
+		}
+
+		client := r.nodeClient(network, node.Name)
+		peers, err := client.PeerCount(ctx)
+		if err != nil {
+			node.Message = err.Error()
+			continue
+		}
+		syncing, err := client.Syncing(ctx)
+		if err != nil {
+			node.Message = err.Error()
+			continue
+		}
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			node.Message = err.Error()
+			continue
+		}
+
+		node.Responding = true
+		node.Peers = int32(peers)
+		node.Syncing = syncing
+		node.HeadBlock = int64(head)
+		networkHead = max(networkHead, node.HeadBlock)
+		responding = true
+	}
+	if !responding {
+		return
+	}
+
+	for i := range nodes {
+		if nodes[i].Responding {
+			nodes[i].BlocksBehind = networkHead - nodes[i].HeadBlock
+		}
+	}
+	if network.Status.HeadBlockTime == nil || networkHead > network.Status.HeadBlock {
+		network.Status.HeadBlock = networkHead
+		network.Status.HeadBlockTime = ptr.To(metav1.Now())
+	}
+}
+
+// Sets the Degraded condition from the health of the nodes. It is true when the network
+// has gone several block periods without a new block, when fewer of the validators than
+// QBFT's quorum (2f+1 of 3f+1) are producing blocks, that is answering, caught up and no
+// more than spec.maxBlockLag blocks behind, or when any node is further behind than that.
+func setHealthCondition(network *racecoursev1beta1.BesuNetwork, validators int) {
+	maxLag := int64(defaultBesuMaxBlockLag)
+	if network.Spec.MaxBlockLag != nil {
+		maxLag = *network.Spec.MaxBlockLag
+	}
+
+	producing := 0
+	var lagging []string
+	for _, node := range network.Status.Nodes {
+		if !node.Responding {
+			continue
+		}
+		if node.BlocksBehind > maxLag {
+			lagging = append(lagging, fmt.Sprintf("%s is %d blocks behind", node.Name, node.BlocksBehind))
+		} else if node.Validator && !node.Syncing {
+			producing++
+		}
+	}
+
+	var stalled time.Duration
+	if network.Status.HeadBlockTime != nil {
+		stalled = time.Since(network.Status.HeadBlockTime.Time)
+	}
+	stallTimeout := time.Duration(besuStallBlockPeriods*max(network.Spec.BlockPeriodSeconds, 1)) * time.Second
+
+	producers := fmt.Sprintf("%d/%d validators producing blocks, %d needed", producing, validators, besuQuorum(validators))
+	switch {
+	case stalled > stallTimeout:
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, racecoursev1beta1.ReasonValidatorsNotProducing,
+			fmt.Sprintf("no block since %d for %s, more than %d block periods", network.Status.HeadBlock, stalled.Round(time.Second), besuStallBlockPeriods))
+	case producing < besuQuorum(validators):
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, racecoursev1beta1.ReasonValidatorsNotProducing, producers)
+	case len(lagging) > 0:
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, racecoursev1beta1.ReasonNodesLagging,
+			fmt.Sprintf("%s, more than the %d allowed", strings.Join(lagging, ", "), maxLag))
+	default:
+		setNetworkCondition(network, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionFalse, racecoursev1beta1.ReasonAsExpected, producers)
+	}
+}
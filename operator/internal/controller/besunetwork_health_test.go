@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("BesuNetwork health", func() {
+	var env *besuTestNetwork
+
+	// Reconciles the network, which has nothing to do but check on the nodes again later
+	reconcileHealthy := func() *racecoursev1beta1.BesuNetwork {
+		result, network := env.reconcileNetwork()
+		Expect(result).To(Equal(reconcile.Result{RequeueAfter: besuHealthCheckInterval}))
+		return network
+	}
+
+	degraded := func(network *racecoursev1beta1.BesuNetwork) *metav1.Condition {
+		return meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeDegraded)
+	}
+
+	BeforeEach(func() {
+		env = newBesuTestNetwork(4, func(network *racecoursev1beta1.BesuNetwork) {
+			network.Spec.MaxBlockLag = ptr.To(int64(5))
+		})
+
+		By("running every node")
+		env.reconcileNetwork()
+		env.startChain(100)
+		for i := range 4 {
+			env.runNode(fmt.Sprintf("besu-%d", i), "")
+		}
+	})
+
+	It("should report each node's health", func() {
+		env.nodes["besu-3"].set(98, 2, true)
+
+		network := reconcileHealthy()
+		Expect(network.Status.Nodes).To(HaveLen(4))
+		for _, node := range network.Status.Nodes[:3] {
+			Expect(node.Responding).To(BeTrue())
+			Expect(node.Peers).To(Equal(int32(3)))
+			Expect(node.Syncing).To(BeFalse())
+			Expect(node.HeadBlock).To(Equal(int64(100)))
+			Expect(node.BlocksBehind).To(BeZero())
+			Expect(node.Validator).To(BeTrue())
+		}
+		Expect(network.Status.Nodes[3].Peers).To(Equal(int32(2)))
+		Expect(network.Status.Nodes[3].Syncing).To(BeTrue())
+		Expect(network.Status.Nodes[3].BlocksBehind).To(Equal(int64(2)))
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionFalse))
+		Expect(degraded(network).Message).To(Equal("3/4 validators producing blocks, 3 needed"))
+	})
+
+	It("should report a node that doesn't answer", func() {
+		env.urls["besu-3"] = "http://127.0.0.1:1"
+
+		network := reconcileHealthy()
+		Expect(network.Status.Nodes[3].Responding).To(BeFalse())
+		Expect(network.Status.Nodes[3].Message).NotTo(BeEmpty())
+		Expect(network.Status.Nodes[3].Validator).To(BeTrue())
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should be degraded when the chain stops producing blocks", func() {
+		network := reconcileHealthy()
+		Expect(network.Status.HeadBlock).To(Equal(int64(100)))
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionFalse))
+
+		By("going a minute without a block")
+		network.Status.HeadBlockTime = ptr.To(metav1.NewTime(time.Now().Add(-time.Minute)))
+		Expect(env.c.Status().Update(env.ctx, network)).To(Succeed())
+		network = reconcileHealthy()
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded(network).Reason).To(Equal(racecoursev1beta1.ReasonValidatorsNotProducing))
+		Expect(degraded(network).Message).To(HavePrefix("no block since 100 for 1m"))
+
+		By("recovering once a block is produced")
+		for _, node := range env.nodes {
+			node.set(101, 3, false)
+		}
+		network = reconcileHealthy()
+		Expect(network.Status.HeadBlock).To(Equal(int64(101)))
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should be degraded when a node falls too far behind", func() {
+		env.nodes["besu-3"].set(90, 3, false)
+
+		network := reconcileHealthy()
+		Expect(network.Status.Nodes[3].BlocksBehind).To(Equal(int64(10)))
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded(network).Reason).To(Equal(racecoursev1beta1.ReasonNodesLagging))
+		Expect(degraded(network).Message).To(Equal("besu-3 is 10 blocks behind, more than the 5 allowed"))
+	})
+
+	It("should be degraded when too few validators are producing blocks", func() {
+		env.nodes["besu-2"].set(100, 3, true)
+		env.nodes["besu-3"].set(80, 3, false)
+
+		network := reconcileHealthy()
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded(network).Reason).To(Equal(racecoursev1beta1.ReasonValidatorsNotProducing))
+		Expect(degraded(network).Message).To(Equal("2/4 validators producing blocks, 3 needed"))
+
+		By("recovering once the nodes catch up")
+		env.nodes["besu-2"].set(100, 3, false)
+		env.nodes["besu-3"].set(100, 3, false)
+		network = reconcileHealthy()
+		Expect(degraded(network).Status).To(Equal(metav1.ConditionFalse))
+		Expect(degraded(network).Reason).To(Equal(racecoursev1beta1.ReasonAsExpected))
+	})
+})
@@ -0,0 +1,311 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/besu"
+)
+
+// The ports Besu nodes serve on
+const (
+	besuRPCPort = 8545
+	besuWSPort  = 8546
+	besuP2PPort = 30303
+)
+
+// The APIs each node serves over HTTP and WebSocket JSON-RPC
+const (
+	besuHTTPAPIs      = "ETH,NET,WEB3,QBFT,TXPOOL,ADMIN"
+	besuWebSocketAPIs = "ETH,NET,WEB3"
+)
+
+// Where the node containers find their chain data, genesis and keys
+const (
+	besuDataPath   = "/data"
+	besuConfigPath = "/config"
+	besuKeysPath   = "/keys"
+	// Where the init container finds the keys Secret, which only it mounts
+	besuAllKeysPath = "/node-keys"
+)
+
+// The user the Besu image runs as, which must be able to write the data volume
+const besuUserID = 1000
+
+// How long a node may take to open its RPC port while it starts up or catches up
+const besuStartupFailureThreshold = 40
+
+func besuLabels(network *racecoursev1beta1.BesuNetwork) map[string]string {
+	return map[string]string{
+		"app":         "besu",
+		"besunetwork": network.Name,
+	}
+}
+
+// The name of the pod of the node with the ordinal, which also names its key
+func besuNodeName(network *racecoursev1beta1.BesuNetwork, ordinal int) string {
+	return fmt.Sprintf("%s-%d", network.Name, ordinal)
+}
+
+func besuHeadlessServiceName(network *racecoursev1beta1.BesuNetwork) string {
+	return network.Name + "-headless"
+}
+
+func besuKeysSecretName(network *racecoursev1beta1.BesuNetwork) string {
+	return network.Name + "-node-keys"
+}
+
+func besuGenesisConfigMapName(network *racecoursev1beta1.BesuNetwork) string {
+	return network.Name + "-genesis"
+}
+
+// The enode URL peers reach the node at, through its stable DNS name
+func besuEnode(network *racecoursev1beta1.BesuNetwork, ordinal int, key besu.NodeIdentity) string {
+	return fmt.Sprintf("enode://%s@%s.%s.%s.svc.cluster.local:%d",
+		key.EnodeID(), besuNodeName(network, ordinal), besuHeadlessServiceName(network), network.Namespace, besuP2PPort)
+}
+
+// Renders the static-nodes.json every node peers with, which lists every node
+func besuStaticNodes(network *racecoursev1beta1.BesuNetwork, keys []besu.NodeIdentity) (string, error) {
+	enodes := make([]string, len(keys))
+	for i, key := range keys {
+		enodes[i] = besuEnode(network, i, key)
+	}
+	data, err := json.MarshalIndent(enodes, "", "  ")
+	return string(data), err
+}
+
+// Creates the controller owner reference set on every child of the BesuNetwork
+func besuNetworkOwnerReference(network *racecoursev1beta1.BesuNetwork) *metav1ac.OwnerReferenceApplyConfiguration {
+	return metav1ac.OwnerReference().
+		WithAPIVersion(racecoursev1beta1.GroupVersion.String()).
+		WithKind("BesuNetwork").
+		WithName(network.Name).
+		WithUID(network.UID).
+		WithController(true).
+		WithBlockOwnerDeletion(true)
+}
+
+// Creates the ConfigMap spec holding the genesis and the static nodes
+func (r *BesuNetworkReconciler) buildGenesisConfigMap(network *racecoursev1beta1.BesuNetwork, genesis, staticNodes string) *corev1ac.ConfigMapApplyConfiguration {
+	return corev1ac.ConfigMap(besuGenesisConfigMapName(network), network.Namespace).
+		WithLabels(besuLabels(network)).
+		WithOwnerReferences(besuNetworkOwnerReference(network)).
+		WithData(map[string]string{
+			"genesis.json":      genesis,
+			"static-nodes.json": staticNodes,
+		})
+}
+
+// Creates the Service specs: a headless Service giving each node a stable DNS name for
+// peering, and Services balancing JSON-RPC over HTTP and WebSocket across the nodes
+func (r *BesuNetworkReconciler) buildServices(network *racecoursev1beta1.BesuNetwork) []*corev1ac.ServiceApplyConfiguration {
+	labels := besuLabels(network)
+	port := func(name string, number int32, protocol corev1.Protocol) *corev1ac.ServicePortApplyConfiguration {
+		return corev1ac.ServicePort().
+			WithName(name).
+			WithProtocol(protocol).
+			WithPort(number).
+			WithTargetPort(intstr.FromString(name))
+	}
+	service := func(name string) *corev1ac.ServiceApplyConfiguration {
+		return corev1ac.Service(name, network.Namespace).
+			WithLabels(labels).
+			WithOwnerReferences(besuNetworkOwnerReference(network))
+	}
+
+	return []*corev1ac.ServiceApplyConfiguration{
+		service(besuHeadlessServiceName(network)).WithSpec(corev1ac.ServiceSpec().
+			WithClusterIP(corev1.ClusterIPNone).
+			// Nodes have to find each other before any of them can be ready
+			WithPublishNotReadyAddresses(true).
+			WithSelector(labels).
+			WithPorts(
+				port("p2p-tcp", besuP2PPort, corev1.ProtocolTCP),
+				port("p2p-udp", besuP2PPort, corev1.ProtocolUDP),
+				port("rpc", besuRPCPort, corev1.ProtocolTCP),
+				port("ws", besuWSPort, corev1.ProtocolTCP),
+			),
+		),
+		service(network.Name + "-rpc").WithSpec(corev1ac.ServiceSpec().
+			WithType(corev1.ServiceTypeClusterIP).
+			WithSelector(labels).
+			WithPorts(port("rpc", besuRPCPort, corev1.ProtocolTCP)),
+		),
+		service(network.Name + "-ws").WithSpec(corev1ac.ServiceSpec().
+			WithType(corev1.ServiceTypeClusterIP).
+			WithSelector(labels).
+			WithPorts(port("ws", besuWSPort, corev1.ProtocolTCP)),
+		),
+	}
+}
+
+// Creates the StatefulSet spec running a pod for each of the nodes. Each pod finds its
+// key in the keys Secret under its own name. The pods share a template, so the whole
+// Secret is mounted, but only in an init container that copies the pod's own key into
+// a memory volume: the Besu container never sees the other nodes' keys. The kubelet
+// still holds every key for each pod, so someone with root on a node can read them all.
+func (r *BesuNetworkReconciler) buildStatefulSet(network *racecoursev1beta1.BesuNetwork, nodes int32) *appsv1ac.StatefulSetApplyConfiguration {
+	labels := besuLabels(network)
+
+	args := []string{
+		"--data-path=" + besuDataPath,
+		"--genesis-file=" + besuConfigPath + "/genesis.json",
+		"--static-nodes-file=" + besuConfigPath + "/static-nodes.json",
+		"--node-private-key-file=" + besuKeysPath + "/key",
+		// Keeps the state of every block, which the Racecourse round indexer reads
+		"--data-storage-format=FOREST",
+		// Lets static nodes be named by their stable DNS names rather than pod IPs
+		"--Xdns-enabled=true",
+		"--Xdns-update-enabled=true",
+		"--p2p-host=$(POD_IP)",
+		fmt.Sprintf("--p2p-port=%d", besuP2PPort),
+		"--discovery-enabled=false",
+		"--rpc-http-enabled",
+		"--rpc-http-host=0.0.0.0",
+		fmt.Sprintf("--rpc-http-port=%d", besuRPCPort),
+		"--rpc-http-api=" + besuHTTPAPIs,
+		"--rpc-http-cors-origins=*",
+		"--rpc-ws-enabled",
+		"--rpc-ws-host=0.0.0.0",
+		fmt.Sprintf("--rpc-ws-port=%d", besuWSPort),
+		"--rpc-ws-api=" + besuWebSocketAPIs,
+		"--host-allowlist=*",
+		"--min-gas-price=0",
+		"--logging=INFO",
+	}
+
+	fieldEnv := func(name, path string) *corev1ac.EnvVarApplyConfiguration {
+		return corev1ac.EnvVar().
+			WithName(name).
+			WithValueFrom(corev1ac.EnvVarSource().
+				WithFieldRef(corev1ac.ObjectFieldSelector().WithFieldPath(path)),
+			)
+	}
+	rpcProbe := func(initialDelay, period, timeout, failureThreshold int32) *corev1ac.ProbeApplyConfiguration {
+		return corev1ac.Probe().
+			WithTCPSocket(corev1ac.TCPSocketAction().WithPort(intstr.FromString("rpc"))).
+			WithInitialDelaySeconds(initialDelay).
+			WithPeriodSeconds(period).
+			WithTimeoutSeconds(timeout).
+			WithFailureThreshold(failureThreshold)
+	}
+
+	size := network.Spec.Storage.Size
+	if size.IsZero() {
+		size = resource.MustParse("2Gi")
+	}
+	claim := corev1ac.PersistentVolumeClaimSpec().
+		WithAccessModes(corev1.ReadWriteOnce).
+		WithResources(corev1ac.VolumeResourceRequirements().
+			WithRequests(corev1.ResourceList{corev1.ResourceStorage: size}),
+		)
+	if network.Spec.Storage.StorageClassName != nil {
+		claim.WithStorageClassName(*network.Spec.Storage.StorageClassName)
+	}
+
+	return appsv1ac.StatefulSet(network.Name, network.Namespace).
+		WithLabels(labels).
+		WithOwnerReferences(besuNetworkOwnerReference(network)).
+		WithSpec(appsv1ac.StatefulSetSpec().
+			WithReplicas(nodes).
+			WithServiceName(besuHeadlessServiceName(network)).
+			// Validators can't make progress alone, so start them all together
+			WithPodManagementPolicy("Parallel").
+			// The operator restarts the nodes itself, one at a time, once the last one is back
+			WithUpdateStrategy(appsv1ac.StatefulSetUpdateStrategy().WithType(appsv1.OnDeleteStatefulSetStrategyType)).
+			WithSelector(metav1ac.LabelSelector().WithMatchLabels(labels)).
+			WithTemplate(corev1ac.PodTemplateSpec().
+				WithLabels(labels).
+				WithSpec(corev1ac.PodSpec().
+					WithEnableServiceLinks(false).
+					WithSecurityContext(corev1ac.PodSecurityContext().WithFSGroup(besuUserID)).
+					WithInitContainers(corev1ac.Container().
+						WithName("node-key").
+						WithImage(network.Spec.Image).
+						WithImagePullPolicy(network.Spec.ImagePullPolicy).
+						WithCommand("cp", besuAllKeysPath+"/$(POD_NAME)", besuKeysPath+"/key").
+						WithEnv(fieldEnv("POD_NAME", "metadata.name")).
+						WithVolumeMounts(
+							corev1ac.VolumeMount().WithName("node-keys").WithMountPath(besuAllKeysPath).WithReadOnly(true),
+							corev1ac.VolumeMount().WithName("keys").WithMountPath(besuKeysPath),
+						).
+						WithResources(resourceRequirements(network.Spec.Resources)),
+					).
+					WithContainers(corev1ac.Container().
+						WithName("besu").
+						WithImage(network.Spec.Image).
+						WithImagePullPolicy(network.Spec.ImagePullPolicy).
+						WithArgs(args...).
+						WithEnv(
+							fieldEnv("POD_NAME", "metadata.name"),
+							fieldEnv("POD_IP", "status.podIP"),
+						).
+						WithPorts(
+							corev1ac.ContainerPort().WithName("rpc").WithContainerPort(besuRPCPort).WithProtocol(corev1.ProtocolTCP),
+							corev1ac.ContainerPort().WithName("ws").WithContainerPort(besuWSPort).WithProtocol(corev1.ProtocolTCP),
+							corev1ac.ContainerPort().WithName("p2p-tcp").WithContainerPort(besuP2PPort).WithProtocol(corev1.ProtocolTCP),
+							corev1ac.ContainerPort().WithName("p2p-udp").WithContainerPort(besuP2PPort).WithProtocol(corev1.ProtocolUDP),
+						).
+						WithVolumeMounts(
+							corev1ac.VolumeMount().WithName("data").WithMountPath(besuDataPath),
+							corev1ac.VolumeMount().WithName("genesis").WithMountPath(besuConfigPath).WithReadOnly(true),
+							corev1ac.VolumeMount().WithName("keys").WithMountPath(besuKeysPath).WithReadOnly(true),
+						).
+						WithStartupProbe(rpcProbe(30, 15, 5, besuStartupFailureThreshold)).
+						WithLivenessProbe(rpcProbe(60, 30, 10, 3)).
+						WithReadinessProbe(rpcProbe(30, 10, 5, 3)).
+						WithResources(resourceRequirements(network.Spec.Resources)),
+					).
+					WithVolumes(
+						corev1ac.Volume().WithName("genesis").WithConfigMap(corev1ac.ConfigMapVolumeSource().
+							WithName(besuGenesisConfigMapName(network)),
+						),
+						corev1ac.Volume().WithName("node-keys").WithSecret(corev1ac.SecretVolumeSource().
+							WithSecretName(besuKeysSecretName(network)).
+							WithDefaultMode(0o440),
+						),
+						corev1ac.Volume().WithName("keys").WithEmptyDir(corev1ac.EmptyDirVolumeSource().
+							WithMedium(corev1.StorageMediumMemory).
+							WithSizeLimit(resource.MustParse("1Mi")),
+						),
+					),
+				),
+			).
+			// A network created again gets a new genesis, so the chain data goes with the
+			// network, but a node scaled away keeps its data for when it comes back
+			WithPersistentVolumeClaimRetentionPolicy(appsv1ac.StatefulSetPersistentVolumeClaimRetentionPolicy().
+				WithWhenDeleted(appsv1.DeletePersistentVolumeClaimRetentionPolicyType).
+				WithWhenScaled(appsv1.RetainPersistentVolumeClaimRetentionPolicyType),
+			).
+			WithVolumeClaimTemplates(corev1ac.PersistentVolumeClaim("data", "").
+				WithLabels(labels).
+				WithSpec(claim),
+			),
+		)
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How often an upgrade or a change to the validator set is checked on while it is under way
+const besuProgressCheckInterval = 10 * time.Second
+
+// How long the network may go without a new block during an upgrade, unless the spec says otherwise
+const defaultBesuStallTimeout = 2 * time.Minute
+
+// The reasons of the events recorded on a BesuNetwork during an upgrade
+const (
+	eventReasonUpgradingNode = "UpgradingNode"
+	eventReasonNodeUpgraded  = "NodeUpgraded"
+	eventReasonUpgradeHalted = "UpgradeHalted"
+	eventReasonUpgraded      = "Upgraded"
+)
+
+// Where an upgrade stands, for the Progressing condition
+type upgradeProgress struct {
+	halted  bool
+	reason  string
+	message string
+}
+
+// Upgrades the nodes running an older revision of the StatefulSet, which doesn't restart
+// them itself, one at a time. The progress is kept in status.upgrade, and nil is returned
+// when there is nothing to upgrade.
+func (r *BesuNetworkReconciler) reconcileUpgrade(ctx context.Context, network *racecoursev1beta1.BesuNetwork, count int) (*upgradeProgress, error) {
+	log := log.FromContext(ctx)
+	// Only the calls to the nodes are bounded, not the pod deletion
+	rpcCtx, cancel := context.WithTimeout(ctx, besuRPCPhaseTimeout)
+	defer cancel()
+
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: network.Name, Namespace: network.Namespace}, statefulSet); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	revision := statefulSet.Status.UpdateRevision
+	if revision == "" {
+		return nil, nil
+	}
+
+	nodes, err := r.listNodePods(ctx, network)
+	if err != nil {
+		return nil, err
+	}
+	// Leave out pods of nodes that are being scaled away
+	names := besuNodeNames(network, count)
+	maps.DeleteFunc(nodes, func(name string, _ *corev1.Pod) bool {
+		return !slices.Contains(names, name)
+	})
+
+	var outdated []string
+	for _, name := range names {
+		if pod := nodes[name]; pod != nil && pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+			outdated = append(outdated, name)
+		}
+	}
+
+	upgrade := network.Status.Upgrade
+	if upgrade == nil {
+		if len(outdated) == 0 {
+			return nil, nil
+		}
+		upgrade = &racecoursev1beta1.BesuUpgradeStatus{HeadBlockTime: metav1.Now()}
+	}
+	upgrade.Revision = revision
+	upgrade.UpdatedNodes = int32(len(nodes) - len(outdated))
+	network.Status.Upgrade = upgrade
+
+	// The node being restarted may have come back on an earlier revision, when the pod
+	// spec changed again in the meantime. Nothing will restart it again, so stop waiting
+	// for it and upgrade it in turn with the other outdated nodes.
+	if pod := nodes[upgrade.Node]; pod != nil && pod.DeletionTimestamp == nil && pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+		log.Info("Node came back on an earlier revision", "node", upgrade.Node, "revision", pod.Labels[appsv1.ControllerRevisionHashLabelKey])
+		upgrade.Node = ""
+		upgrade.PeersBefore = 0
+		upgrade.SyncedBlock = nil
+	}
+
+	// Follow the head of the chain across the nodes, so a stall is noticed whichever
+	// node is down
+	now := time.Now()
+	if head, ok := r.networkHead(rpcCtx, network, nodes); ok && int64(head) > upgrade.HeadBlock {
+		upgrade.HeadBlock = int64(head)
+		upgrade.HeadBlockTime = metav1.NewTime(now)
+	}
+	stallTimeout := defaultBesuStallTimeout
+	if network.Spec.Upgrade.StallTimeout != nil {
+		stallTimeout = network.Spec.Upgrade.StallTimeout.Duration
+	}
+	if stalled := now.Sub(upgrade.HeadBlockTime.Time); stalled > stallTimeout {
+		message := fmt.Sprintf("no block since %d for %s, so the upgrade has halted with %d/%d nodes upgraded",
+			upgrade.HeadBlock, stalled.Round(time.Second), upgrade.UpdatedNodes, len(nodes))
+		progressing := meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeProgressing)
+		if progressing == nil || progressing.Reason != racecoursev1beta1.ReasonBlockProductionStalled {
+			r.Recorder.Event(network, corev1.EventTypeWarning, eventReasonUpgradeHalted, message)
+		}
+		return &upgradeProgress{halted: true, reason: racecoursev1beta1.ReasonBlockProductionStalled, message: message}, nil
+	}
+
+	if upgrade.Node != "" {
+		waiting, err := r.checkUpgradedNode(rpcCtx, network, upgrade, nodes[upgrade.Node])
+		if err != nil {
+			return nil, err
+		}
+		if waiting != "" {
+			return &upgradeProgress{reason: racecoursev1beta1.ReasonUpgradingNode, message: waiting}, nil
+		}
+		r.Recorder.Eventf(network, corev1.EventTypeNormal, eventReasonNodeUpgraded, "Node %s is running revision %s and producing blocks", upgrade.Node, revision)
+		upgrade.Node = ""
+		upgrade.PeersBefore = 0
+		upgrade.SyncedBlock = nil
+	}
+
+	if len(outdated) == 0 {
+		r.Recorder.Eventf(network, corev1.EventTypeNormal, eventReasonUpgraded, "All %d nodes are running revision %s", len(nodes), revision)
+		network.Status.Upgrade = nil
+		return nil, nil
+	}
+
+	// Restarting a node while another is down could take the validators below quorum
+	for _, name := range names {
+		if pod := nodes[name]; pod == nil || !podReady(pod) {
+			return &upgradeProgress{
+				reason:  racecoursev1beta1.ReasonWaitingForNodes,
+				message: fmt.Sprintf("waiting for %s to be ready before upgrading %s", name, outdated[0]),
+			}, nil
+		}
+	}
+
+	next := nodes[outdated[0]]
+	peers, err := r.nodeClient(network, next.Name).PeerCount(rpcCtx)
+	if err != nil {
+		// Expect the node to get back to every other node
+		log.Info("Unable to count the peers of the node before upgrading it", "node", next.Name, "error", err.Error())
+		peers = uint64(len(nodes) - 1)
+	}
+	if err := r.Delete(ctx, next, client.Preconditions{UID: &next.UID}); err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	log.Info("Restarting node on the new revision", "node", next.Name, "revision", revision)
+	r.Recorder.Eventf(network, corev1.EventTypeNormal, eventReasonUpgradingNode, "Restarting node %s on revision %s", next.Name, revision)
+	upgrade.Node = next.Name
+	upgrade.PeersBefore = int32(peers)
+	upgrade.SyncedBlock = nil
+
+	return &upgradeProgress{
+		reason:  racecoursev1beta1.ReasonUpgradingNode,
+		message: fmt.Sprintf("waiting for %s to restart on the new revision", next.Name),
+	}, nil
+}
+
+// Checks whether the restarted node is back: running the new revision, caught up with the
+// chain, connected to as many peers as before and seeing new blocks. Returns what it is
+// still waiting for, or "" once the node is back.
+func (r *BesuNetworkReconciler) checkUpgradedNode(ctx context.Context, network *racecoursev1beta1.BesuNetwork, upgrade *racecoursev1beta1.BesuUpgradeStatus, pod *corev1.Pod) (string, error) {
+	name := upgrade.Node
+	switch {
+	case pod == nil || pod.Labels[appsv1.ControllerRevisionHashLabelKey] != upgrade.Revision:
+		return fmt.Sprintf("waiting for %s to restart on the new revision", name), nil
+	case !podReady(pod):
+		return fmt.Sprintf("waiting for %s to be ready", name), nil
+	}
+
+	node := r.nodeClient(network, name)
+	syncing, err := node.Syncing(ctx)
+	if err != nil {
+		return fmt.Sprintf("waiting for %s to answer: %v", name, err), nil
+	}
+	if syncing {
+		return fmt.Sprintf("waiting for %s to catch up with the chain", name), nil
+	}
+	peers, err := node.PeerCount(ctx)
+	if err != nil {
+		return fmt.Sprintf("waiting for %s to answer: %v", name, err), nil
+	}
+	if int32(peers) < upgrade.PeersBefore {
+		return fmt.Sprintf("waiting for %s to regain its peers (%d/%d)", name, peers, upgrade.PeersBefore), nil
+	}
+	head, err := node.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Sprintf("waiting for %s to answer: %v", name, err), nil
+	}
+	if upgrade.SyncedBlock == nil {
+		upgrade.SyncedBlock = ptr.To(int64(head))
+	}
+	if int64(head) <= *upgrade.SyncedBlock {
+		return fmt.Sprintf("waiting for %s to see a block after %d", name, *upgrade.SyncedBlock), nil
+	}
+	return "", nil
+}
+
+// Gets the highest block any of the ready nodes has
+func (r *BesuNetworkReconciler) networkHead(ctx context.Context, network *racecoursev1beta1.BesuNetwork, nodes map[string]*corev1.Pod) (uint64, bool) {
+	var head uint64
+	found := false
+	for name, pod := range nodes {
+		if !podReady(pod) {
+			continue
+		}
+		number, err := r.nodeClient(network, name).BlockNumber(ctx)
+		if err != nil {
+			continue
+		}
+		head = max(head, number)
+		found = true
+	}
+	return head, found
+}
+
+// Lists the pods of the nodes, by name
+func (r *BesuNetworkReconciler) listNodePods(ctx context.Context, network *racecoursev1beta1.BesuNetwork) (map[string]*corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(network.Namespace), client.MatchingLabels(besuLabels(network))); err != nil {
+		return nil, err
+	}
+	nodes := map[string]*corev1.Pod{}
+	for i := range pods.Items {
+		nodes[pods.Items[i].Name] = &pods.Items[i]
+	}
+	return nodes, nil
+}
+
+// The names of the first count nodes, in ordinal order
+func besuNodeNames(network *racecoursev1beta1.BesuNetwork, count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = besuNodeName(network, i)
+	}
+	return names
+}
+
+// Creates a JSON-RPC client for a node
+func (r *BesuNetworkReconciler) nodeClient(network *racecoursev1beta1.BesuNetwork, name string) *ethrpc.Client {
+	nodeURL := r.NodeURL
+	if nodeURL == nil {
+		nodeURL = besuNodeURL
+	}
+	return ethrpc.New(nodeURL(network, name), r.RPCOptions...)
+}
+
+// The in-cluster JSON-RPC URL of a single node, through the headless Service
+func besuNodeURL(network *racecoursev1beta1.BesuNetwork, name string) string {
+	return fmt.Sprintf("http://%s.%s.%s.svc.cluster.local:%d", name, besuHeadlessServiceName(network), network.Namespace, besuRPCPort)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("BesuNetwork upgrades", func() {
+	var env *besuTestNetwork
+
+	// The nodes whose pods exist
+	running := func() []string {
+		pods := &corev1.PodList{}
+		Expect(env.c.List(env.ctx, pods)).To(Succeed())
+		names := []string{}
+		for _, pod := range pods.Items {
+			names = append(names, pod.Name)
+		}
+		return names
+	}
+
+	progressing := func(network *racecoursev1beta1.BesuNetwork) *metav1.Condition {
+		return meta.FindStatusCondition(network.Status.Conditions, racecoursev1beta1.ConditionTypeProgressing)
+	}
+
+	BeforeEach(func() {
+		env = newBesuTestNetwork(4, nil)
+
+		By("running every node on the first revision")
+		env.reconcileNetwork()
+		env.startChain(10)
+		for i := range 4 {
+			env.runNode(fmt.Sprintf("besu-%d", i), "v1")
+		}
+		result, _ := env.reconcileNetwork()
+		Expect(result).To(Equal(reconcile.Result{RequeueAfter: besuHealthCheckInterval}))
+
+		By("changing the pod spec")
+		statefulSet := &appsv1.StatefulSet{}
+		Expect(env.c.Get(env.ctx, env.key, statefulSet)).To(Succeed())
+		Expect(statefulSet.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteStatefulSetStrategyType))
+		statefulSet.Status.CurrentRevision = "v1"
+		statefulSet.Status.UpdateRevision = "v2"
+		Expect(env.c.Status().Update(env.ctx, statefulSet)).To(Succeed())
+	})
+
+	It("should restart one node at a time once the last is back and producing blocks", func() {
+		result, network := env.reconcileNetwork()
+		Expect(result.RequeueAfter).To(Equal(besuProgressCheckInterval))
+		Expect(running()).To(ConsistOf("besu-1", "besu-2", "besu-3"))
+		Expect(network.Status.Upgrade.Node).To(Equal("besu-0"))
+		Expect(network.Status.Upgrade.PeersBefore).To(Equal(int32(3)))
+		Expect(progressing(network).Reason).To(Equal(racecoursev1beta1.ReasonUpgradingNode))
+
+		By("waiting while the node is down")
+		_, network = env.reconcileNetwork()
+		Expect(running()).To(HaveLen(3))
+		Expect(progressing(network).Message).To(Equal("waiting for besu-0 to restart on the new revision"))
+
+		By("waiting while the node catches up")
+		env.runNode("besu-0", "v2")
+		env.nodes["besu-0"].set(10, 3, true)
+		_, network = env.reconcileNetwork()
+		Expect(progressing(network).Message).To(Equal("waiting for besu-0 to catch up with the chain"))
+		Expect(network.Status.Upgrade.UpdatedNodes).To(Equal(int32(1)))
+
+		By("waiting until the node has its peers back")
+		env.nodes["besu-0"].set(10, 1, false)
+		_, network = env.reconcileNetwork()
+		Expect(progressing(network).Message).To(Equal("waiting for besu-0 to regain its peers (1/3)"))
+
+		By("waiting for a new block")
+		env.nodes["besu-0"].set(10, 3, false)
+		_, network = env.reconcileNetwork()
+		Expect(progressing(network).Message).To(Equal("waiting for besu-0 to see a block after 10"))
+		Expect(running()).To(HaveLen(4))
+
+		By("moving on to the next node")
+		for _, node := range env.nodes {
+			node.set(11, 3, false)
+		}
+		_, network = env.reconcileNetwork()
+		Expect(running()).To(ConsistOf("besu-0", "besu-2", "besu-3"))
+		Expect(network.Status.Upgrade.Node).To(Equal("besu-1"))
+	})
+
+	It("should restart a node again when the pod spec changes while it restarts", func() {
+		env.reconcileNetwork()
+		env.runNode("besu-0", "v2")
+
+		By("changing the pod spec again")
+		statefulSet := &appsv1.StatefulSet{}
+		Expect(env.c.Get(env.ctx, env.key, statefulSet)).To(Succeed())
+		statefulSet.Status.UpdateRevision = "v3"
+		Expect(env.c.Status().Update(env.ctx, statefulSet)).To(Succeed())
+
+		_, network := env.reconcileNetwork()
+		Expect(running()).To(ConsistOf("besu-1", "besu-2", "besu-3"))
+		Expect(network.Status.Upgrade.Node).To(Equal("besu-0"))
+		Expect(network.Status.Upgrade.Revision).To(Equal("v3"))
+		Expect(progressing(network).Message).To(Equal("waiting for besu-0 to restart on the new revision"))
+
+		By("moving on once the node is back on the latest revision")
+		env.runNode("besu-0", "v3")
+		env.reconcileNetwork()
+		for _, node := range env.nodes {
+			node.set(11, 3, false)
+		}
+		_, network = env.reconcileNetwork()
+		Expect(running()).To(ConsistOf("besu-0", "besu-2", "besu-3"))
+		Expect(network.Status.Upgrade.Node).To(Equal("besu-1"))
+		Expect(network.Status.Upgrade.UpdatedNodes).To(Equal(int32(1)))
+	})
+
+	It("should not restart a node while another is not ready", func() {
+		pod := &corev1.Pod{}
+		Expect(env.c.Get(env.ctx, types.NamespacedName{Name: "besu-2", Namespace: "default"}, pod)).To(Succeed())
+		pod.Status.Conditions[0].Status = corev1.ConditionFalse
+		Expect(env.c.Status().Update(env.ctx, pod)).To(Succeed())
+
+		_, network := env.reconcileNetwork()
+		Expect(running()).To(HaveLen(4))
+		Expect(progressing(network).Reason).To(Equal(racecoursev1beta1.ReasonWaitingForNodes))
+		Expect(progressing(network).Message).To(Equal("waiting for besu-2 to be ready before upgrading besu-0"))
+	})
+
+	It("should halt when blocks stop being produced and carry on once they resume", func() {
+		_, network := env.reconcileNetwork()
+		network.Status.Upgrade.HeadBlockTime = metav1.NewTime(time.Now().Add(-5 * time.Minute))
+		Expect(env.c.Status().Update(env.ctx, network)).To(Succeed())
+
+		env.runNode("besu-0", "v2")
+		_, network = env.reconcileNetwork()
+		Expect(progressing(network).Status).To(Equal(metav1.ConditionFalse))
+		Expect(progressing(network).Reason).To(Equal(racecoursev1beta1.ReasonBlockProductionStalled))
+		Expect(progressing(network).Message).To(HavePrefix("no block since 10 for 5m"))
+
+		By("leaving the other nodes alone")
+		_, _ = env.reconcileNetwork()
+		Expect(running()).To(HaveLen(4))
+
+		By("resuming once a block is produced")
+		for _, node := range env.nodes {
+			node.set(11, 3, false)
+		}
+		_, network = env.reconcileNetwork()
+		Expect(progressing(network).Status).To(Equal(metav1.ConditionTrue))
+		_, network = env.reconcileNetwork()
+		for _, node := range env.nodes {
+			node.set(12, 3, false)
+		}
+		_, network = env.reconcileNetwork()
+		Expect(running()).To(ConsistOf("besu-0", "besu-2", "besu-3"))
+		Expect(network.Status.Upgrade.Node).To(Equal("besu-1"))
+	})
+
+	It("should finish once every node runs the new revision", func() {
+		for i := range 4 {
+			name := fmt.Sprintf("besu-%d", i)
+			Expect(env.c.Delete(env.ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})).To(Succeed())
+			env.runNode(name, "v2")
+		}
+
+		result, network := env.reconcileNetwork()
+		Expect(result).To(Equal(reconcile.Result{RequeueAfter: besuHealthCheckInterval}))
+		Expect(network.Status.Upgrade).To(BeNil())
+	})
+})
@@ -0,0 +1,269 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/besu"
+)
+
+// How many applied validator changes the status keeps
+const besuAppliedValidatorChangesLimit = 10
+
+// The reasons of the events recorded on a BesuNetwork as its validator set changes
+const (
+	eventReasonValidatorVoteProposed = "ValidatorVoteProposed"
+	eventReasonValidatorAdded        = "ValidatorAdded"
+	eventReasonValidatorRemoved      = "ValidatorRemoved"
+)
+
+// The validator set of the chain, as a node reported it
+type validatorSet struct {
+	validators []string
+	// The head block of the node that reported the set
+	head uint64
+	// Whether a node reported the set, rather than it being the last one known
+	observed bool
+}
+
+// Asks the ready nodes, in ordinal order, for the validator set as of the latest block.
+// If none of them answers, the last known set is returned, or before there is one the
+// validators the genesis is made with.
+func (r *BesuNetworkReconciler) observeValidators(ctx context.Context, network *racecoursev1beta1.BesuNetwork, keys []besu.NodeIdentity) *validatorSet {
+	log := log.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(ctx, besuRPCPhaseTimeout)
+	defer cancel()
+
+	pods, err := r.listNodePods(ctx, network)
+	if err != nil {
+		log.Error(err, "Failed to list the nodes")
+	}
+	for _, name := range besuNodeNames(network, len(keys)) {
+		if pod := pods[name]; pod == nil || !podReady(pod) {
+			continue
+		}
+		node := r.nodeClient(network, name)
+		validators, err := besu.Validators(ctx, node, "latest")
+		if err != nil {
+			log.Info("Unable to get the validators from the node", "node", name, "error", err.Error())
+			continue
+		}
+		head, err := node.BlockNumber(ctx)
+		if err != nil {
+			log.Info("Unable to get the head block from the node", "node", name, "error", err.Error())
+			continue
+		}
+		return &validatorSet{validators: validators, head: head, observed: true}
+	}
+
+	if network.Status.Validators != nil {
+		return &validatorSet{validators: network.Status.Validators}
+	}
+	if network.Status.GenesisValidators != nil {
+		return &validatorSet{validators: network.Status.GenesisValidators}
+	}
+	validators := make([]string, network.Spec.Validators)
+	for i := range validators {
+		validators[i] = keys[i].Address()
+	}
+	return &validatorSet{validators: validators}
+}
+
+// The number of nodes to run: those the spec asks for, and any beyond them that are
+// still validators, since taking them away before they are voted out could leave the
+// chain without a quorum
+func besuNodeCount(network *racecoursev1beta1.BesuNetwork, keys []besu.NodeIdentity, validators []string) int {
+	count := int(network.Spec.Validators)
+	for i := count; i < len(keys); i++ {
+		if slices.Contains(validators, keys[i].Address()) {
+			count = i + 1
+		}
+	}
+	return count
+}
+
+// Brings the validator set in line with the spec, one change at a time: the nodes the
+// spec asks for are voted in once they have caught up with the chain, then the nodes
+// beyond them are voted out, the highest first. Every ready validator votes for the
+// change, and the chain makes it once more than half of them have. The changes are
+// kept in the status, and none are voted on while the nodes are being upgraded.
+func (r *BesuNetworkReconciler) reconcileValidatorSet(ctx context.Context, network *racecoursev1beta1.BesuNetwork, keys []besu.NodeIdentity, chain *validatorSet, upgrading bool) error {
+	log := log.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(ctx, besuRPCPhaseTimeout)
+	defer cancel()
+
+	nodeOf := map[string]string{}
+	for i, key := range keys {
+		nodeOf[key.Address()] = besuNodeName(network, i)
+	}
+
+	if chain.observed {
+		r.recordAppliedValidatorChanges(network, chain, nodeOf)
+		network.Status.Validators = chain.validators
+	}
+
+	var changes []racecoursev1beta1.BesuValidatorChange
+	for i := range int(network.Spec.Validators) {
+		if address := keys[i].Address(); !slices.Contains(chain.validators, address) {
+			changes = append(changes, racecoursev1beta1.BesuValidatorChange{
+				Node:    besuNodeName(network, i),
+				Address: address,
+				Action:  racecoursev1beta1.BesuValidatorActionAdd,
+			})
+		}
+	}
+	for i := len(keys) - 1; i >= int(network.Spec.Validators); i-- {
+		if address := keys[i].Address(); slices.Contains(chain.validators, address) {
+			changes = append(changes, racecoursev1beta1.BesuValidatorChange{
+				Node:    besuNodeName(network, i),
+				Address: address,
+				Action:  racecoursev1beta1.BesuValidatorActionRemove,
+			})
+		}
+	}
+
+	pods, err := r.listNodePods(ctx, network)
+	if err != nil {
+		return err
+	}
+
+	// Pick the change to vote on, leaving out nodes that would join before catching up
+	var active *racecoursev1beta1.BesuValidatorChange
+	for i := range changes {
+		change := &changes[i]
+		switch {
+		case !chain.observed:
+			change.Message = "waiting for a node to report the validator set"
+		case upgrading:
+			change.Message = "waiting for the upgrade of the nodes to finish"
+		case active != nil:
+			change.Message = fmt.Sprintf("waiting for the change to %s to be made first", active.Node)
+		case change.Action == racecoursev1beta1.BesuValidatorActionAdd && !r.nodeSynced(ctx, network, pods[change.Node]):
+			change.Message = fmt.Sprintf("waiting for %s to catch up with the chain", change.Node)
+		default:
+			active = change
+		}
+	}
+
+	// Every ready node withdraws its votes on anything but the active change, whether
+	// the chain has acted on them or the spec no longer asks for them, since a stray vote
+	// could still add or remove a validator. The validators among them vote for the
+	// active change.
+	voters := 0
+	proposed := false
+	for i, name := range besuNodeNames(network, len(keys)) {
+		if !chain.observed {
+			break
+		}
+		if pod := pods[name]; pod == nil || !podReady(pod) {
+			continue
+		}
+		node := r.nodeClient(network, name)
+		votes, err := besu.PendingVotes(ctx, node)
+		if err != nil {
+			log.Info("Unable to get the pending votes of the node", "node", name, "error", err.Error())
+			continue
+		}
+		for address := range votes {
+			if active != nil && address == active.Address {
+				continue
+			}
+			if err := besu.DiscardValidatorVote(ctx, node, address); err != nil {
+				log.Info("Unable to discard the vote of the node", "node", name, "address", address, "error", err.Error())
+			}
+		}
+
+		if active == nil || !slices.Contains(chain.validators, keys[i].Address()) {
+			continue
+		}
+		add := active.Action == racecoursev1beta1.BesuValidatorActionAdd
+		if vote, ok := votes[active.Address]; !ok || vote != add {
+			if err := besu.ProposeValidatorVote(ctx, node, active.Address, add); err != nil {
+				log.Info("Unable to propose the vote on the node", "node", name, "address", active.Address, "error", err.Error())
+				continue
+			}
+			proposed = true
+		}
+		voters++
+	}
+
+	if active != nil {
+		needed := len(chain.validators)/2 + 1
+		active.Votes = int32(voters)
+		active.Message = fmt.Sprintf("%d/%d validators voting, %d needed", voters, len(chain.validators), needed)
+		if proposed {
+			r.Recorder.Eventf(network, corev1.EventTypeNormal, eventReasonValidatorVoteProposed,
+				"Validators are voting to %s %s (%s)", strings.ToLower(string(active.Action)), active.Node, active.Address)
+		}
+	}
+	network.Status.PendingValidatorChanges = changes
+	return nil
+}
+
+// Records the changes the chain has made to the validator set since it was last seen
+func (r *BesuNetworkReconciler) recordAppliedValidatorChanges(network *racecoursev1beta1.BesuNetwork, chain *validatorSet, nodeOf map[string]string) {
+	previous := network.Status.Validators
+	if previous == nil {
+		return
+	}
+
+	record := func(address string, action racecoursev1beta1.BesuValidatorAction) {
+		change := racecoursev1beta1.BesuValidatorChange{
+			Node:    nodeOf[address],
+			Address: address,
+			Action:  action,
+			Block:   int64(chain.head),
+		}
+		network.Status.AppliedValidatorChanges = append(network.Status.AppliedValidatorChanges, change)
+		if action == racecoursev1beta1.BesuValidatorActionAdd {
+			r.Recorder.Eventf(network, corev1.EventTypeNormal, eventReasonValidatorAdded, "%s (%s) joined the validator set by block %d", change.Node, address, chain.head)
+		} else {
+			r.Recorder.Eventf(network, corev1.EventTypeNormal, eventReasonValidatorRemoved, "%s (%s) left the validator set by block %d", change.Node, address, chain.head)
+		}
+	}
+	for _, address := range chain.validators {
+		if !slices.Contains(previous, address) {
+			record(address, racecoursev1beta1.BesuValidatorActionAdd)
+		}
+	}
+	for _, address := range previous {
+		if !slices.Contains(chain.validators, address) {
+			record(address, racecoursev1beta1.BesuValidatorActionRemove)
+		}
+	}
+
+	if applied := network.Status.AppliedValidatorChanges; len(applied) > besuAppliedValidatorChangesLimit {
+		network.Status.AppliedValidatorChanges = applied[len(applied)-besuAppliedValidatorChangesLimit:]
+	}
+}
+
+// Reports whether the node's pod is ready and the node has caught up with the chain
+func (r *BesuNetworkReconciler) nodeSynced(ctx context.Context, network *racecoursev1beta1.BesuNetwork, pod *corev1.Pod) bool {
+	if pod == nil || !podReady(pod) {
+		return false
+	}
+	syncing, err := r.nodeClient(network, pod.Name).Syncing(ctx)
+	return err == nil && !syncing
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/besu"
+)
+
+var _ = Describe("BesuNetwork validator set", func() {
+	var env *besuTestNetwork
+
+	// The address of the node's key
+	address := func(name string) string {
+		secret := &corev1.Secret{}
+		Expect(env.c.Get(env.ctx, types.NamespacedName{Name: "besu-node-keys", Namespace: "default"}, secret)).To(Succeed())
+		nodeKey, err := besu.ParseNodeKey(string(secret.Data[name]))
+		Expect(err).NotTo(HaveOccurred())
+		return nodeKey.Address()
+	}
+
+	// Has every node report the validator set and the head block
+	setChain := func(head uint64, validators ...string) {
+		addresses := []string{}
+		for _, name := range validators {
+			addresses = append(addresses, address(name))
+		}
+		for _, node := range env.nodes {
+			node.set(head, 3, false)
+			node.setValidators(addresses)
+		}
+	}
+
+	scale := func(validators int32) {
+		env.update(func(spec *racecoursev1beta1.BesuNetworkSpec) { spec.Validators = validators })
+	}
+
+	replicas := func() int32 {
+		statefulSet := &appsv1.StatefulSet{}
+		Expect(env.c.Get(env.ctx, env.key, statefulSet)).To(Succeed())
+		return *statefulSet.Spec.Replicas
+	}
+
+	BeforeEach(func() {
+		env = newBesuTestNetwork(5, nil)
+
+		By("running the genesis validators")
+		env.reconcileNetwork()
+		setChain(10, "besu-0", "besu-1", "besu-2", "besu-3")
+		for i := range 4 {
+			env.runNode(fmt.Sprintf("besu-%d", i), "")
+		}
+		result, network := env.reconcileNetwork()
+		Expect(result).To(Equal(reconcile.Result{RequeueAfter: besuHealthCheckInterval}))
+		Expect(network.Status.Validators).To(Equal(network.Status.GenesisValidators))
+		Expect(network.Status.PendingValidatorChanges).To(BeEmpty())
+	})
+
+	It("should vote a new node in once it has caught up", func() {
+		scale(5)
+		result, network := env.reconcileNetwork()
+		Expect(result.RequeueAfter).To(Equal(besuProgressCheckInterval))
+		Expect(replicas()).To(Equal(int32(5)))
+		Expect(network.Status.PendingValidatorChanges).To(Equal([]racecoursev1beta1.BesuValidatorChange{{
+			Node:    "besu-4",
+			Address: address("besu-4"),
+			Action:  racecoursev1beta1.BesuValidatorActionAdd,
+			Message: "waiting for besu-4 to catch up with the chain",
+		}}))
+
+		By("voting once the node is running")
+		env.runNode("besu-4", "")
+		env.nodes["besu-4"].set(10, 3, false)
+		_, network = env.reconcileNetwork()
+		Expect(network.Status.PendingValidatorChanges).To(HaveLen(1))
+		Expect(network.Status.PendingValidatorChanges[0].Votes).To(Equal(int32(4)))
+		Expect(network.Status.PendingValidatorChanges[0].Message).To(Equal("4/4 validators voting, 3 needed"))
+		for i := range 4 {
+			Expect(env.nodes[fmt.Sprintf("besu-%d", i)].pendingVotes()).To(Equal(map[string]bool{address("besu-4"): true}))
+		}
+		Expect(env.nodes["besu-4"].pendingVotes()).To(BeEmpty())
+
+		By("recording the change once the chain has made it")
+		setChain(20, "besu-0", "besu-1", "besu-2", "besu-3", "besu-4")
+		result, network = env.reconcileNetwork()
+		Expect(result).To(Equal(reconcile.Result{RequeueAfter: besuHealthCheckInterval}))
+		Expect(network.Status.PendingValidatorChanges).To(BeEmpty())
+		Expect(network.Status.Validators).To(HaveLen(5))
+		Expect(network.Status.AppliedValidatorChanges).To(Equal([]racecoursev1beta1.BesuValidatorChange{{
+			Node:    "besu-4",
+			Address: address("besu-4"),
+			Action:  racecoursev1beta1.BesuValidatorActionAdd,
+			Block:   20,
+		}}))
+
+		By("withdrawing the votes the chain has acted on")
+		for i := range 4 {
+			Expect(env.nodes[fmt.Sprintf("besu-%d", i)].pendingVotes()).To(BeEmpty())
+		}
+	})
+
+	It("should keep a node running until it has been voted out", func() {
+		scale(3)
+		_, network := env.reconcileNetwork()
+		Expect(replicas()).To(Equal(int32(4)))
+		Expect(network.Status.Nodes).To(HaveLen(4))
+		Expect(network.Status.PendingValidatorChanges).To(HaveLen(1))
+		change := network.Status.PendingValidatorChanges[0]
+		Expect(change.Node).To(Equal("besu-3"))
+		Expect(change.Action).To(Equal(racecoursev1beta1.BesuValidatorActionRemove))
+		Expect(env.nodes["besu-0"].pendingVotes()).To(Equal(map[string]bool{address("besu-3"): false}))
+
+		setChain(30, "besu-0", "besu-1", "besu-2")
+		_, network = env.reconcileNetwork()
+		Expect(replicas()).To(Equal(int32(3)))
+		Expect(network.Status.Nodes).To(HaveLen(3))
+		Expect(network.Status.PendingValidatorChanges).To(BeEmpty())
+		Expect(network.Status.AppliedValidatorChanges).To(ConsistOf(HaveField("Action", racecoursev1beta1.BesuValidatorActionRemove)))
+	})
+
+	It("should withdraw the votes for a scale that is reversed before the chain acts on it", func() {
+		scale(5)
+		env.reconcileNetwork()
+		env.runNode("besu-4", "")
+		env.nodes["besu-4"].set(10, 3, false)
+		env.reconcileNetwork()
+		Expect(env.nodes["besu-0"].pendingVotes()).To(Equal(map[string]bool{address("besu-4"): true}))
+
+		By("scaling back down")
+		scale(4)
+		_, network := env.reconcileNetwork()
+		Expect(network.Status.PendingValidatorChanges).To(BeEmpty())
+		for i := range 4 {
+			Expect(env.nodes[fmt.Sprintf("besu-%d", i)].pendingVotes()).To(BeEmpty())
+		}
+
+		By("scaling down and back up")
+		scale(3)
+		env.reconcileNetwork()
+		Expect(env.nodes["besu-0"].pendingVotes()).To(Equal(map[string]bool{address("besu-3"): false}))
+		scale(4)
+		_, network = env.reconcileNetwork()
+		Expect(network.Status.PendingValidatorChanges).To(BeEmpty())
+		for i := range 4 {
+			Expect(env.nodes[fmt.Sprintf("besu-%d", i)].pendingVotes()).To(BeEmpty())
+		}
+	})
+
+	It("should not vote until a node reports the validator set", func() {
+		pods := &corev1.PodList{}
+		Expect(env.c.List(env.ctx, pods)).To(Succeed())
+		for i := range pods.Items {
+			pods.Items[i].Status.Conditions[0].Status = corev1.ConditionFalse
+			Expect(env.c.Status().Update(env.ctx, &pods.Items[i])).To(Succeed())
+		}
+
+		scale(3)
+		_, network := env.reconcileNetwork()
+		Expect(network.Status.PendingValidatorChanges).To(HaveLen(1))
+		Expect(network.Status.PendingValidatorChanges[0].Message).To(Equal("waiting for a node to report the validator set"))
+		Expect(env.nodes["besu-0"].pendingVotes()).To(BeEmpty())
+	})
+})
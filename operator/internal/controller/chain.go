@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// How often a wallet on the wrong chain is checked again, in case it's repointed
+const chainRecheckInterval = time.Minute
+
+// What the check of the wallet's chain against the one pinned in the spec found
+type chainVerification struct {
+	Status  metav1.ConditionStatus
+	Reason  string
+	Message string
+}
+
+// Reports whether the wallet is known to be on a chain other than the pinned one
+func (v *chainVerification) mismatched() bool {
+	return v.Status == metav1.ConditionFalse
+}
+
+// Checks the wallet is on the chain pinned in the spec, by its chain ID and the hash of
+// its genesis block, so a Racecourse can't end up wired to the wrong network
+func (r *RacecourseReconciler) verifyChain(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, wallet *walletHealth) *chainVerification {
+	chain := racecourse.Spec.Chain
+	if chain == nil || (chain.ChainID == 0 && chain.GenesisHash == "") {
+		return &chainVerification{
+			Status:  metav1.ConditionTrue,
+			Reason:  racecoursev1beta1.ReasonChainNotPinned,
+			Message: "no chain is pinned, so the wallet may be on any chain",
+		}
+	}
+	if wallet.ChainID == nil {
+		return &chainVerification{
+			Status:  metav1.ConditionUnknown,
+			Reason:  racecoursev1beta1.ReasonVerificationPending,
+			Message: "the chain can't be verified until the wallet answers",
+		}
+	}
+
+	if chain.ChainID != 0 && (!wallet.ChainID.IsInt64() || wallet.ChainID.Int64() != chain.ChainID) {
+		return &chainVerification{
+			Status:  metav1.ConditionFalse,
+			Reason:  racecoursev1beta1.ReasonChainIDMismatch,
+			Message: fmt.Sprintf("the wallet is on chain ID %s, but chain ID %d is pinned", wallet.ChainID, chain.ChainID),
+		}
+	}
+
+	if chain.GenesisHash != "" {
+		ctx, cancel := context.WithTimeout(ctx, walletProbeTimeout)
+		defer cancel()
+		hash, err := r.walletClient(racecourse).BlockHash(ctx, 0)
+		if err != nil {
+			return &chainVerification{
+				Status:  metav1.ConditionUnknown,
+				Reason:  racecoursev1beta1.ReasonVerificationPending,
+				Message: fmt.Sprintf("failed to get the genesis block: %v", err),
+			}
+		}
+		if !strings.EqualFold(hash, chain.GenesisHash) {
+			return &chainVerification{
+				Status:  metav1.ConditionFalse,
+				Reason:  racecoursev1beta1.ReasonGenesisHashMismatch,
+				Message: fmt.Sprintf("the wallet's genesis block is %s, but %s is pinned", hash, chain.GenesisHash),
+			}
+		}
+	}
+
+	return &chainVerification{
+		Status:  metav1.ConditionTrue,
+		Reason:  racecoursev1beta1.ReasonChainMatches,
+		Message: fmt.Sprintf("the wallet is on the pinned chain with chain ID %s", wallet.ChainID),
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+var _ = Describe("Racecourse chain pinning", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		signer     *fakeSignerState
+		wallet     *walletHealth
+		key        types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		signer = newFakeSignerState()
+		server := fakeSigner(signer)
+		DeferCleanup(server.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas: ptr.To(int32(2)),
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  server.URL,
+				},
+				// Hashes compare regardless of case
+				Chain: &racecoursev1beta1.ChainSpec{ChainID: 1337, GenesisHash: "0x" + strings.Repeat("AB", 32)},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().
+			WithObjects(racecourse).WithStatusSubresource(racecourse).Build()
+		reconciler = &RacecourseReconciler{
+			Client:     c,
+			Scheme:     testScheme,
+			Recorder:   record.NewFakeRecorder(100),
+			APIReader:  c,
+			RPCOptions: []ethrpc.Option{ethrpc.WithRetries(0, 0)},
+		}
+
+		var err error
+		wallet, err = reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Ready).To(BeTrue())
+	})
+
+	It("should accept any chain when none is pinned", func() {
+		racecourse.Spec.Chain = nil
+		Expect(reconciler.verifyChain(ctx, racecourse, wallet).Reason).To(Equal(racecoursev1beta1.ReasonChainNotPinned))
+	})
+
+	It("should accept the pinned chain", func() {
+		verification := reconciler.verifyChain(ctx, racecourse, wallet)
+		Expect(verification.Status).To(Equal(metav1.ConditionTrue))
+		Expect(verification.Reason).To(Equal(racecoursev1beta1.ReasonChainMatches))
+	})
+
+	It("should catch a different chain ID", func() {
+		racecourse.Spec.Chain.ChainID = 1
+		verification := reconciler.verifyChain(ctx, racecourse, wallet)
+		Expect(verification.mismatched()).To(BeTrue())
+		Expect(verification.Reason).To(Equal(racecoursev1beta1.ReasonChainIDMismatch))
+	})
+
+	It("should catch a different genesis block", func() {
+		racecourse.Spec.Chain = &racecoursev1beta1.ChainSpec{GenesisHash: "0x" + strings.Repeat("cd", 32)}
+		verification := reconciler.verifyChain(ctx, racecourse, wallet)
+		Expect(verification.mismatched()).To(BeTrue())
+		Expect(verification.Reason).To(Equal(racecoursev1beta1.ReasonGenesisHashMismatch))
+	})
+
+	It("should wait for the wallet before judging the chain", func() {
+		verification := reconciler.verifyChain(ctx, racecourse, &walletHealth{})
+		Expect(verification.Status).To(Equal(metav1.ConditionUnknown))
+	})
+
+	It("should refuse to deploy to or roll out on the wrong chain", func() {
+		racecourse.Spec.Chain.ChainID = 1
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(chainRecheckInterval))
+		Expect(signer.sent).To(BeEmpty())
+		Expect(errors.IsNotFound(c.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.Phase).To(Equal(racecoursev1beta1.RacecoursePhaseFailed))
+		available := meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeAvailable)
+		Expect(available.Status).To(Equal(metav1.ConditionFalse))
+		Expect(available.Reason).To(Equal(racecoursev1beta1.ReasonChainIDMismatch))
+		Expect(meta.IsStatusConditionFalse(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeChainVerified)).To(BeTrue())
+
+		By("rolling out once the pin matches")
+		racecourse.Spec.Chain.ChainID = 1337
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer.sent).To(HaveLen(1))
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeChainVerified)).To(BeTrue())
+	})
+})
@@ -0,0 +1,397 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/contracts"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// The gas the Race contract is deployed with, matching what the app would use. The
+// chains the app runs on don't charge for gas, so the gas price is zero.
+const contractDeployGas = 5000000
+
+// How often to check whether the deployment transaction has been mined, and how long to
+// wait before trying again when the deployment failed
+const (
+	contractReceiptPollInterval = 5 * time.Second
+	contractDeployRetryInterval = time.Minute
+)
+
+// Set to "true" on a Racecourse to roll it out against a contract whose code isn't a
+// supported Race contract, such as a locally modified build
+const skipContractVerificationAnnotation = "racecourse.kaleido.io/skip-contract-verification"
+
+// The event reasons recorded while deploying the Race contract
+const (
+	eventReasonContractDeploying    = "ContractDeploying"
+	eventReasonContractDeployed     = "ContractDeployed"
+	eventReasonContractDeployFailed = "ContractDeployFailed"
+)
+
+// Get the address of the Race contract the app uses: the one in the spec, or else the
+// one the operator deployed
+func contractAddress(racecourse *racecoursev1beta1.Racecourse) string {
+	if racecourse.Spec.ContractAddress != "" {
+		return racecourse.Spec.ContractAddress
+	}
+	return racecourse.Status.ContractAddress
+}
+
+// Deploys the Race contract through a ready wallet when the spec doesn't name one, so
+// every pod uses the same contract instead of each deploying its own. The deployment
+// spans reconciles. The nonce is pinned and saved to the status before the transaction
+// is sent, and its hash as soon as it is, so a send that may or may not have gone
+// through is never repeated: once the nonce has been used, the contract is looked up
+// by its sender and nonce instead. A transaction that leaves the pool without being
+// mined is sent again with the same nonce. Returns how soon to check back, or zero
+// once there's a contract address.
+func (r *RacecourseReconciler) reconcileContract(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, wallet *walletHealth) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	if contractAddress(racecourse) != "" {
+		return 0, nil
+	}
+
+	rpcCtx, cancel := context.WithTimeout(ctx, walletProbeTimeout)
+	defer cancel()
+	rpc := r.walletClient(racecourse)
+
+	if hash := racecourse.Status.ContractDeploymentTransaction; hash != "" {
+		receipt, err := rpc.TransactionReceipt(rpcCtx, hash)
+		if err != nil {
+			log.Error(err, "Failed to get the receipt of the contract deployment", "transaction", hash)
+			return contractReceiptPollInterval, nil
+		}
+		if receipt == nil {
+			requeue, err := r.checkContractDeploymentPending(rpcCtx, rpc, racecourse)
+			if err != nil || requeue != 0 {
+				return requeue, err
+			}
+			if racecourse.Status.ContractDeploymentNonce == nil {
+				return contractReceiptPollInterval, r.Status().Update(ctx, racecourse)
+			}
+		} else {
+			clearContractDeployment(racecourse)
+			if !receipt.Succeeded || receipt.ContractAddress == "" {
+				r.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonContractDeployFailed,
+					"Transaction %s deploying the Race contract failed in block %d", hash, receipt.BlockNumber)
+				return contractDeployRetryInterval, r.Status().Update(ctx, racecourse)
+			}
+
+			racecourse.Status.ContractAddress = receipt.ContractAddress
+			if err := r.Status().Update(ctx, racecourse); err != nil {
+				return 0, err
+			}
+			log.Info("Deployed the Race contract", "address", receipt.ContractAddress, "transaction", hash)
+			r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonContractDeployed,
+				"Deployed the Race contract at %s in block %d", receipt.ContractAddress, receipt.BlockNumber)
+			return 0, nil
+		}
+	} else if racecourse.Status.ContractDeploymentNonce == nil {
+		requeue, err := r.pinContractDeploymentNonce(ctx, rpcCtx, rpc, racecourse, wallet.Accounts[0])
+		if err != nil || requeue != 0 {
+			return requeue, err
+		}
+	} else {
+		// The deployment may have been sent before without its hash being saved
+		requeue, err := r.findContractDeployment(rpcCtx, rpc, racecourse)
+		if err != nil {
+			return 0, err
+		}
+		if racecourse.Status.ContractDeploymentNonce == nil {
+			// The nonce was used, and the contract found or the deployment given up on
+			return requeue, r.Status().Update(ctx, racecourse)
+		}
+		if requeue != 0 {
+			return requeue, nil
+		}
+	}
+
+	from := racecourse.Status.ContractDeploymentFrom
+	nonce := uint64(*racecourse.Status.ContractDeploymentNonce)
+	code, err := contracts.RaceCreationCode()
+	if err != nil {
+		return 0, err
+	}
+	// eth_sendTransaction is never retried, and the pinned nonce means sending it again
+	// on a later reconcile can only replace this transaction, not add a second contract
+	hash, err := rpc.SendTransaction(rpcCtx, ethrpc.TransactionArgs{
+		From:     from,
+		Data:     code,
+		Gas:      contractDeployGas,
+		GasPrice: big.NewInt(0),
+		Nonce:    &nonce,
+	})
+	if err != nil {
+		log.Error(err, "Failed to send the contract deployment", "nonce", nonce)
+		r.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonContractDeployFailed, "Failed to deploy the Race contract: %v", err)
+		if racecourse.Status.ContractDeploymentTransaction != "" {
+			// Forget the transaction that left the pool, so it isn't waited for again
+			racecourse.Status.ContractDeploymentTransaction = ""
+			return contractDeployRetryInterval, r.Status().Update(ctx, racecourse)
+		}
+		return contractDeployRetryInterval, nil
+	}
+
+	racecourse.Status.ContractDeploymentTransaction = hash
+	if err := r.Status().Update(ctx, racecourse); err != nil {
+		return 0, err
+	}
+	log.Info("Sent the Race contract deployment", "transaction", hash, "from", from, "nonce", nonce)
+	r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonContractDeploying,
+		"Deploying the Race contract from %s in transaction %s", from, hash)
+	return contractReceiptPollInterval, nil
+}
+
+// Pins the nonce the deployment is sent with and saves it to the status. Racecourses
+// can share a wallet account, so the nonces are handed out one at a time, skipping
+// those other Racecourses have pinned and may not have sent yet. Otherwise two of them
+// could send with the same nonce, and the one whose transaction lost would take the
+// other's contract for its own.
+func (r *RacecourseReconciler) pinContractDeploymentNonce(ctx, rpcCtx context.Context, rpc *ethrpc.Client, racecourse *racecoursev1beta1.Racecourse, from string) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	r.deploymentNonces.Lock()
+	defer r.deploymentNonces.Unlock()
+
+	nonce, err := rpc.TransactionCount(rpcCtx, from, ethrpc.PendingBlock)
+	if err != nil {
+		log.Error(err, "Failed to get the nonce for the contract deployment", "from", from)
+		return contractDeployRetryInterval, nil
+	}
+	// The cache may not have seen the nonce the last holder of the lock pinned yet
+	racecourses := &racecoursev1beta1.RacecourseList{}
+	if err := r.APIReader.List(ctx, racecourses); err != nil {
+		return 0, err
+	}
+	for _, other := range racecourses.Items {
+		pinned := other.Status.ContractDeploymentNonce
+		if other.UID != racecourse.UID && pinned != nil && strings.EqualFold(other.Status.ContractDeploymentFrom, from) {
+			nonce = max(nonce, uint64(*pinned)+1)
+		}
+	}
+
+	racecourse.Status.ContractDeploymentFrom = from
+	racecourse.Status.ContractDeploymentNonce = ptr.To(int64(nonce))
+	return 0, r.Status().Update(ctx, racecourse)
+}
+
+// Where a transaction with the pinned nonce stands on the chain
+type nonceState int
+
+const (
+	// Nothing holds the nonce: it was never sent, or its transaction left the pool
+	nonceUnused nonceState = iota
+	// A transaction with the nonce waits to be mined
+	noncePending
+	// A transaction with the nonce has been mined
+	nonceMined
+)
+
+// Finds out whether the nonce of the deployment account has been used
+func contractDeploymentNonceState(ctx context.Context, rpc *ethrpc.Client, from string, nonce uint64) (nonceState, error) {
+	mined, err := rpc.TransactionCount(ctx, from, ethrpc.Latest)
+	if err != nil {
+		return 0, err
+	}
+	if mined > nonce {
+		return nonceMined, nil
+	}
+	pending, err := rpc.TransactionCount(ctx, from, ethrpc.PendingBlock)
+	if err != nil {
+		return 0, err
+	}
+	if pending > nonce {
+		return noncePending, nil
+	}
+	return nonceUnused, nil
+}
+
+// Checks on a deployment transaction that has no receipt yet. It returns how soon to
+// check back while the transaction waits to be mined. If another transaction took its
+// nonce, the deployment is cleared so it's sent afresh with a new one. Otherwise the
+// transaction has left the pool, such as when the node restarted, and it returns zero
+// so it's sent again with the same nonce.
+func (r *RacecourseReconciler) checkContractDeploymentPending(ctx context.Context, rpc *ethrpc.Client, racecourse *racecoursev1beta1.Racecourse) (time.Duration, error) {
+	log := log.FromContext(ctx)
+	hash := racecourse.Status.ContractDeploymentTransaction
+	if racecourse.Status.ContractDeploymentNonce == nil {
+		return contractReceiptPollInterval, nil
+	}
+	from := racecourse.Status.ContractDeploymentFrom
+	nonce := uint64(*racecourse.Status.ContractDeploymentNonce)
+
+	state, err := contractDeploymentNonceState(ctx, rpc, from, nonce)
+	if err != nil {
+		log.Error(err, "Failed to get the transaction count of the deployment account", "from", from)
+		return contractReceiptPollInterval, nil
+	}
+	switch state {
+	case noncePending:
+		return contractReceiptPollInterval, nil
+	case nonceMined:
+		// Our transaction has no receipt, so it wasn't the one mined with the nonce
+		clearContractDeployment(racecourse)
+		r.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonContractDeployFailed,
+			"Transaction %s deploying the Race contract was replaced by another with nonce %d of %s", hash, nonce, from)
+		return 0, nil
+	}
+	log.Info("The contract deployment left the pool without being mined, sending it again",
+		"transaction", hash, "from", from, "nonce", nonce)
+	return 0, nil
+}
+
+// Looks for the outcome of a deployment that was about to be sent with the nonce in the
+// status. While a transaction with the nonce waits to be mined, it returns how soon to
+// check back. Once the nonce has been used, it fills in the address of the contract it
+// created, or clears the deployment if it created none so it's sent afresh. Otherwise
+// nothing was sent with the nonce, and it returns zero without changing the status.
+// The nonce was handed out to this Racecourse alone, so a contract it created is ours.
+func (r *RacecourseReconciler) findContractDeployment(ctx context.Context, rpc *ethrpc.Client, racecourse *racecoursev1beta1.Racecourse) (time.Duration, error) {
+	log := log.FromContext(ctx)
+	from := racecourse.Status.ContractDeploymentFrom
+	nonce := uint64(*racecourse.Status.ContractDeploymentNonce)
+
+	state, err := contractDeploymentNonceState(ctx, rpc, from, nonce)
+	if err != nil {
+		log.Error(err, "Failed to get the transaction count of the deployment account", "from", from)
+		return contractReceiptPollInterval, nil
+	}
+	switch state {
+	case noncePending:
+		return contractReceiptPollInterval, nil
+	case nonceUnused:
+		return 0, nil
+	}
+
+	// A contract's address follows from its creator and the nonce it was created with
+	address, err := ethrpc.CreateAddress(from, nonce)
+	if err != nil {
+		return 0, err
+	}
+	code, err := rpc.GetCode(ctx, address, ethrpc.Latest)
+	if err != nil {
+		log.Error(err, "Failed to get the code of the deployed contract", "address", address)
+		return contractReceiptPollInterval, nil
+	}
+
+	clearContractDeployment(racecourse)
+	if len(code) == 0 {
+		r.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonContractDeployFailed,
+			"Nonce %d of %s was used without deploying the Race contract", nonce, from)
+		return contractDeployRetryInterval, nil
+	}
+
+	racecourse.Status.ContractAddress = address
+	log.Info("Found the deployed Race contract", "address", address, "from", from, "nonce", nonce)
+	r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonContractDeployed,
+		"Deployed the Race contract at %s with nonce %d of %s", address, nonce, from)
+	return 0, nil
+}
+
+// Forgets the deployment transaction once its outcome is known
+func clearContractDeployment(racecourse *racecoursev1beta1.Racecourse) {
+	racecourse.Status.ContractDeploymentTransaction = ""
+	racecourse.Status.ContractDeploymentFrom = ""
+	racecourse.Status.ContractDeploymentNonce = nil
+}
+
+// What the check of the code at the contract address found
+type contractVerification struct {
+	Status  metav1.ConditionStatus
+	Reason  string
+	Message string
+}
+
+// Reports whether the code at the contract address rules out rolling the app out
+// against it. Only a definite mismatch does, and only without the override annotation.
+func (v *contractVerification) refusesRollout(racecourse *racecoursev1beta1.Racecourse) bool {
+	if racecourse.Annotations[skipContractVerificationAnnotation] == "true" {
+		return false
+	}
+	return v.Reason == racecoursev1beta1.ReasonContractCodeMissing || v.Reason == racecoursev1beta1.ReasonContractCodeMismatch
+}
+
+// Checks that the code at the contract address is a supported Race contract, since an
+// account without code or some other contract only shows up as confusing failures in
+// the app
+func (r *RacecourseReconciler) verifyContract(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, wallet *walletHealth) *contractVerification {
+	address := contractAddress(racecourse)
+	switch {
+	case address == "":
+		return &contractVerification{
+			Status:  metav1.ConditionUnknown,
+			Reason:  racecoursev1beta1.ReasonVerificationPending,
+			Message: "there is no contract to verify yet",
+		}
+	case !wallet.Ready:
+		return &contractVerification{
+			Status:  metav1.ConditionUnknown,
+			Reason:  racecoursev1beta1.ReasonVerificationPending,
+			Message: fmt.Sprintf("the contract at %s can't be verified until the wallet is ready", address),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, walletProbeTimeout)
+	defer cancel()
+	code, err := r.walletClient(racecourse).GetCode(ctx, address, ethrpc.Latest)
+	if err != nil {
+		return &contractVerification{
+			Status:  metav1.ConditionUnknown,
+			Reason:  racecoursev1beta1.ReasonVerificationPending,
+			Message: fmt.Sprintf("failed to get the code at %s: %v", address, err),
+		}
+	}
+
+	override := ""
+	if racecourse.Annotations[skipContractVerificationAnnotation] == "true" {
+		override = fmt.Sprintf(", but %s is set so it is used anyway", skipContractVerificationAnnotation)
+	}
+	if len(code) == 0 {
+		return &contractVerification{
+			Status:  metav1.ConditionFalse,
+			Reason:  racecoursev1beta1.ReasonContractCodeMissing,
+			Message: fmt.Sprintf("there is no contract at %s%s", address, override),
+		}
+	}
+	version, ok := contracts.RaceVersion(code)
+	if !ok {
+		return &contractVerification{
+			Status:  metav1.ConditionFalse,
+			Reason:  racecoursev1beta1.ReasonContractCodeMismatch,
+			Message: fmt.Sprintf("the code at %s is not a supported Race contract%s", address, override),
+		}
+	}
+	return &contractVerification{
+		Status:  metav1.ConditionTrue,
+		Reason:  racecoursev1beta1.ReasonContractCodeMatches,
+		Message: fmt.Sprintf("the code at %s is the Race contract from racecourse %s", address, version),
+	}
+}
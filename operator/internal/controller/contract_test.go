@@ -0,0 +1,334 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/contracts"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+var _ = Describe("Racecourse contract deployment", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		recorder   *record.FakeRecorder
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		signer     *fakeSignerState
+		key        types.NamespacedName
+	)
+
+	reconcileOnce := func() reconcile.Result {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		return result
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		signer = newFakeSignerState()
+		server := fakeSigner(signer)
+		DeferCleanup(server.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas: ptr.To(int32(2)),
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  server.URL,
+				},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().
+			WithObjects(racecourse).WithStatusSubresource(racecourse).Build()
+		recorder = record.NewFakeRecorder(100)
+		reconciler = &RacecourseReconciler{
+			Client:     c,
+			Scheme:     testScheme,
+			Recorder:   recorder,
+			APIReader:  c,
+			RPCOptions: []ethrpc.Option{ethrpc.WithRetries(0, 0)},
+		}
+	})
+
+	It("should deploy one contract and share it with every pod", func() {
+		By("sending the deployment and holding the rollout")
+		result := reconcileOnce()
+		Expect(result.RequeueAfter).To(Equal(contractReceiptPollInterval))
+		code, err := contracts.RaceCreationCode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer.sent).To(ConsistOf(map[string]string{
+			"from":     "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			"data":     ethrpc.EncodeHex(code),
+			"gas":      "0x4c4b40",
+			"gasPrice": "0x0",
+			"nonce":    "0x0",
+		}))
+		Expect(racecourse.Status.ContractDeploymentTransaction).NotTo(BeEmpty())
+		Expect(racecourse.Status.ContractDeploymentNonce).To(HaveValue(BeZero()))
+		Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractReady).Reason).
+			To(Equal(racecoursev1beta1.ReasonContractDeploying))
+		Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeProgressing).Reason).
+			To(Equal(racecoursev1beta1.ReasonWaitingForContract))
+		Expect(errors.IsNotFound(c.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+
+		By("waiting for the transaction to be mined without sending another")
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+
+		By("rolling out against the deployed contract")
+		signer.mined = true
+		Expect(reconcileOnce().RequeueAfter).NotTo(Equal(contractReceiptPollInterval))
+		Expect(racecourse.Status.ContractAddress).To(Equal("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+		Expect(racecourse.Status.ContractDeploymentTransaction).To(BeEmpty())
+		Expect(racecourse.Status.ContractDeploymentNonce).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractVerified)).To(BeTrue())
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "racecourse-config", Namespace: "default"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("contract-address", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+		Expect(c.Get(ctx, key, &appsv1.Deployment{})).To(Succeed())
+
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+	})
+
+	It("should try again when the deployment fails", func() {
+		reconcileOnce()
+		for len(recorder.Events) > 0 {
+			<-recorder.Events
+		}
+		signer.mined = true
+		signer.failed = true
+
+		Expect(reconcileOnce().RequeueAfter).To(Equal(contractDeployRetryInterval))
+		Expect(racecourse.Status.ContractDeploymentTransaction).To(BeEmpty())
+		Expect(racecourse.Status.ContractAddress).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning ContractDeployFailed")))
+
+		signer.mined = false
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(2))
+	})
+
+	It("should find a deployment whose hash wasn't saved instead of sending it again", func() {
+		signer.nonce, signer.pendingNonce = 3, 3
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+		Expect(signer.sent[0]).To(HaveKeyWithValue("nonce", "0x3"))
+
+		By("losing the hash")
+		racecourse.Status.ContractDeploymentTransaction = ""
+		Expect(c.Status().Update(ctx, racecourse)).To(Succeed())
+
+		By("waiting while the transaction with the nonce is pending")
+		signer.pendingNonce = 4
+		Expect(reconcileOnce().RequeueAfter).To(Equal(contractReceiptPollInterval))
+		Expect(signer.sent).To(HaveLen(1))
+		Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractReady).Message).
+			To(Equal("deploying the contract from 0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed with nonce 3"))
+
+		By("using the contract the nonce created once it's mined")
+		address, err := ethrpc.CreateAddress(signer.accounts[0], 3)
+		Expect(err).NotTo(HaveOccurred())
+		signer.code[address] = signer.code[fakeContractAddress]
+		signer.nonce = 4
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+		Expect(racecourse.Status.ContractAddress).To(Equal(address))
+		Expect(racecourse.Status.ContractDeploymentNonce).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractVerified)).To(BeTrue())
+	})
+
+	It("should send a failed deployment again with the same nonce", func() {
+		signer.unavailable = true
+		Expect(reconcileOnce().RequeueAfter).To(Equal(contractDeployRetryInterval))
+		Expect(signer.sent).To(BeEmpty())
+		Expect(racecourse.Status.ContractDeploymentNonce).To(HaveValue(BeZero()))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning ContractDeployFailed")))
+
+		By("sending it again once the wallet is back")
+		signer.unavailable = false
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+		Expect(signer.sent[0]).To(HaveKeyWithValue("nonce", "0x0"))
+	})
+
+	It("should deploy again when the nonce went to another transaction", func() {
+		signer.unavailable = true
+		reconcileOnce()
+		signer.unavailable = false
+		for len(recorder.Events) > 0 {
+			<-recorder.Events
+		}
+
+		signer.nonce, signer.pendingNonce = 1, 1
+		Expect(reconcileOnce().RequeueAfter).To(Equal(contractDeployRetryInterval))
+		Expect(racecourse.Status.ContractDeploymentNonce).To(BeNil())
+		Expect(racecourse.Status.ContractAddress).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Nonce 0 of 0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed was used without deploying")))
+
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+		Expect(signer.sent[0]).To(HaveKeyWithValue("nonce", "0x1"))
+	})
+
+	It("should send the deployment again when it leaves the pool without being mined", func() {
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(1))
+		first := racecourse.Status.ContractDeploymentTransaction
+
+		By("waiting while it is in the pool")
+		Expect(reconcileOnce().RequeueAfter).To(Equal(contractReceiptPollInterval))
+		Expect(signer.sent).To(HaveLen(1))
+
+		By("restarting the node, which forgets its pool")
+		signer.pendingNonce = 0
+		Expect(reconcileOnce().RequeueAfter).To(Equal(contractReceiptPollInterval))
+		Expect(signer.sent).To(HaveLen(2))
+		Expect(signer.sent[1]).To(Equal(signer.sent[0]))
+		Expect(racecourse.Status.ContractDeploymentTransaction).NotTo(Equal(first))
+		Expect(racecourse.Status.ContractDeploymentNonce).To(HaveValue(BeZero()))
+
+		signer.mined = true
+		reconcileOnce()
+		Expect(racecourse.Status.ContractAddress).To(Equal(fakeContractAddress))
+	})
+
+	It("should deploy afresh when another transaction is mined with the nonce it waits on", func() {
+		reconcileOnce()
+		for len(recorder.Events) > 0 {
+			<-recorder.Events
+		}
+
+		signer.nonce = 1
+		reconcileOnce()
+		Expect(racecourse.Status.ContractDeploymentTransaction).To(BeEmpty())
+		Expect(racecourse.Status.ContractDeploymentNonce).To(BeNil())
+		Expect(racecourse.Status.ContractAddress).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("was replaced by another with nonce 0")))
+
+		reconcileOnce()
+		Expect(signer.sent).To(HaveLen(2))
+		Expect(signer.sent[1]).To(HaveKeyWithValue("nonce", "0x1"))
+	})
+
+	It("should give Racecourses that share a wallet account their own nonces", func() {
+		By("pinning a nonce the wallet doesn't take")
+		signer.unavailable = true
+		reconcileOnce()
+		Expect(racecourse.Status.ContractDeploymentNonce).To(HaveValue(BeZero()))
+
+		other := &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "5678"},
+			Spec:       *racecourse.Spec.DeepCopy(),
+		}
+		Expect(c.Create(ctx, other)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+		Expect(other.Status.ContractDeploymentNonce).To(HaveValue(Equal(int64(1))))
+
+		By("sending both once the wallet is back")
+		signer.unavailable = false
+		reconcileOnce()
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer.sent).To(HaveLen(2))
+		Expect(signer.sent[0]).To(HaveKeyWithValue("nonce", "0x0"))
+		Expect(signer.sent[1]).To(HaveKeyWithValue("nonce", "0x1"))
+	})
+
+	It("should refuse to roll out against a contract that isn't a Race contract", func() {
+		racecourse.Spec.ContractAddress = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+
+		reconcileOnce()
+		Expect(signer.sent).To(BeEmpty())
+		Expect(errors.IsNotFound(c.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "racecourse-config", Namespace: "default"}, &corev1.ConfigMap{}))).To(BeTrue())
+		verified := meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractVerified)
+		Expect(verified.Status).To(Equal(metav1.ConditionFalse))
+		Expect(verified.Reason).To(Equal(racecoursev1beta1.ReasonContractCodeMissing))
+		Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeProgressing).Reason).
+			To(Equal(racecoursev1beta1.ReasonContractNotVerified))
+
+		By("holding an existing rollout too")
+		signer.code["0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"] = []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+		racecourse.Spec.ContractAddress = ""
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+		signer.mined = true
+		reconcileOnce()
+		reconcileOnce()
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		hash := deployment.Spec.Template.Annotations[configHashAnnotation]
+
+		racecourse.Spec.ContractAddress = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+		reconcileOnce()
+		Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractVerified).Reason).
+			To(Equal(racecoursev1beta1.ReasonContractCodeMismatch))
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(configHashAnnotation, hash))
+
+		By("rolling out anyway when told to")
+		racecourse.Annotations = map[string]string{skipContractVerificationAnnotation: "true"}
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+		reconcileOnce()
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Annotations[configHashAnnotation]).NotTo(Equal(hash))
+		Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractVerified).Status).
+			To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should not deploy a contract when the spec names one", func() {
+		racecourse.Spec.ContractAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+
+		reconcileOnce()
+		Expect(signer.sent).To(BeEmpty())
+		Expect(c.Get(ctx, key, &appsv1.Deployment{})).To(Succeed())
+	})
+})
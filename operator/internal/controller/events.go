@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// The reasons of the events recorded on a Racecourse
+const (
+	eventReasonCreated      = "Created"
+	eventReasonUpdated      = "Updated"
+	eventReasonDeleted      = "Deleted"
+	eventReasonApplyFailed  = "ApplyFailed"
+	eventReasonDeleteFailed = "DeleteFailed"
+	eventReasonPhaseChanged = "PhaseChanged"
+)
+
+// Records the outcome of applying a child resource
+func (r *RacecourseReconciler) recordApply(racecourse *racecoursev1beta1.Racecourse, kind, name string, result controllerutil.OperationResult, err error) {
+	recordApply(r.Recorder, racecourse, kind, name, result, err)
+}
+
+// Records the outcome of applying a child resource on its owner
+func recordApply(recorder record.EventRecorder, owner runtime.Object, kind, name string, result controllerutil.OperationResult, err error) {
+	switch {
+	case err != nil:
+		recorder.Eventf(owner, corev1.EventTypeWarning, eventReasonApplyFailed, "Failed to apply %s %s: %v", kind, name, err)
+	case result == controllerutil.OperationResultCreated:
+		recorder.Eventf(owner, corev1.EventTypeNormal, eventReasonCreated, "Created %s %s", kind, name)
+	case result == controllerutil.OperationResultUpdated:
+		recorder.Eventf(owner, corev1.EventTypeNormal, eventReasonUpdated, "Updated %s %s", kind, name)
+	}
+}
+
+// Records the status changes worth telling the user about: the phase, and the wallet and
+// contract conditions
+func (r *RacecourseReconciler) recordStatusChanges(racecourse *racecoursev1beta1.Racecourse, original *racecoursev1beta1.RacecourseStatus) {
+	if original.Phase != racecourse.Status.Phase {
+		eventType := corev1.EventTypeNormal
+		if racecourse.Status.Phase == racecoursev1beta1.RacecoursePhaseFailed {
+			eventType = corev1.EventTypeWarning
+		}
+		from := original.Phase
+		if from == "" {
+			from = "None"
+		}
+		r.Recorder.Eventf(racecourse, eventType, eventReasonPhaseChanged, "Phase changed from %s to %s", from, racecourse.Status.Phase)
+	}
+
+	for _, conditionType := range []string{
+		racecoursev1beta1.ConditionTypeWalletReachable,
+		racecoursev1beta1.ConditionTypeChainVerified,
+		racecoursev1beta1.ConditionTypeContractReady,
+		racecoursev1beta1.ConditionTypeContractVerified,
+	} {
+		condition := meta.FindStatusCondition(racecourse.Status.Conditions, conditionType)
+		if condition == nil {
+			continue
+		}
+		previous := meta.FindStatusCondition(original.Conditions, conditionType)
+		if previous != nil && previous.Status == condition.Status && previous.Reason == condition.Reason && previous.Message == condition.Message {
+			continue
+		}
+
+		eventType := corev1.EventTypeNormal
+		if condition.Status == metav1.ConditionFalse {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Event(racecourse, eventType, condition.Reason, condition.Message)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse events", func() {
+	var (
+		recorder   *record.FakeRecorder
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+	)
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &RacecourseReconciler{Recorder: recorder}
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type:    racecoursev1beta1.WalletEndpointTypeService,
+					Service: &racecoursev1beta1.WalletServiceRef{Name: "firefly-signer", Namespace: "default"},
+				},
+			},
+		}
+	})
+
+	It("should record phase transitions", func() {
+		original := racecourse.Status.DeepCopy()
+		racecourse.Status.Phase = racecoursev1beta1.RacecoursePhaseFailed
+		reconciler.recordStatusChanges(racecourse, original)
+
+		Expect(recorder.Events).To(Receive(Equal("Warning PhaseChanged Phase changed from None to Failed")))
+	})
+
+	It("should record wallet failures once", func() {
+		setWalletCondition(racecourse, &walletHealth{
+			Reason:  racecoursev1beta1.ReasonWalletServiceNotFound,
+			Message: "the wallet Service default/firefly-signer does not exist",
+		})
+		original := racecourse.Status.DeepCopy()
+		reconciler.recordStatusChanges(racecourse, &racecoursev1beta1.RacecourseStatus{})
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning WalletServiceNotFound")))
+
+		reconciler.recordStatusChanges(racecourse, original)
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should record the contract in use", func() {
+		racecourse.Spec.ContractAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		setContractCondition(racecourse)
+		reconciler.recordStatusChanges(racecourse, &racecoursev1beta1.RacecourseStatus{})
+
+		Expect(recorder.Events).To(Receive(Equal("Normal ContractAddressSet using the contract at 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")))
+	})
+})
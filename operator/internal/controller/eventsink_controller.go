@@ -0,0 +1,332 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/cloudevents"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How long to wait before trying a sink that didn't accept an event again
+const eventSinkRetryInterval = 30 * time.Second
+
+// The types of the CloudEvents sent to the event sink
+const (
+	eventTypeBetPlaced        = "io.kaleido.racecourse.bet.placed"
+	eventTypePlayerReady      = "io.kaleido.racecourse.player.ready"
+	eventTypeRaceFinished     = "io.kaleido.racecourse.race.finished"
+	eventTypeContractDeployed = "io.kaleido.racecourse.contract.deployed"
+	eventTypePhaseChanged     = "io.kaleido.racecourse.phase.changed"
+)
+
+// The data of every CloudEvent sent to the event sink; each type fills in what applies
+type sinkEventData struct {
+	Racecourse      string                     `json:"racecourse"`
+	Namespace       string                     `json:"namespace"`
+	ContractAddress string                     `json:"contractAddress,omitempty"`
+	Round           int64                      `json:"round,omitempty"`
+	BlockNumber     uint64                     `json:"blockNumber,omitempty"`
+	TransactionHash string                     `json:"transactionHash,omitempty"`
+	Bet             *racecoursev1beta1.RaceBet `json:"bet,omitempty"`
+	WinnerHorse     *int64                     `json:"winnerHorse,omitempty"`
+	Jackpot         string                     `json:"jackpot,omitempty"`
+	Phase           string                     `json:"phase,omitempty"`
+	PreviousPhase   string                     `json:"previousPhase,omitempty"`
+}
+
+// EventSinkReconciler delivers CloudEvents about each Racecourse to its event sink:
+// the contract's events once the RaceRound indexer has indexed them, the contract
+// deployment and phase changes
+type EventSinkReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Reads the sink's auth Secret straight from the API server, so Secrets aren't cached
+	APIReader client.Reader
+
+	// Options for the JSON-RPC clients used to talk to wallets, and for the senders
+	// used to talk to sinks
+	RPCOptions    []ethrpc.Option
+	SenderOptions []cloudevents.Option
+}
+
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racerounds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+
+func (r *EventSinkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	racecourse := &racecoursev1beta1.Racecourse{}
+	if err := r.Get(ctx, req.NamespacedName, racecourse); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !racecourse.DeletionTimestamp.IsZero() || racecourse.Spec.EventSink == nil {
+		return ctrl.Result{}, nil
+	}
+
+	cursor := racecourse.Status.EventSink.DeepCopy()
+	if cursor == nil {
+		cursor = &racecoursev1beta1.EventSinkStatus{Block: -1}
+	}
+	original := cursor.DeepCopy()
+
+	pending, err := r.deliver(ctx, racecourse, cursor)
+	cursor.Message = ""
+	if err != nil {
+		log.Error(err, "Failed to deliver events to the sink, retrying")
+		cursor.Message = err.Error()
+	}
+
+	if !equality.Semantic.DeepEqual(original, cursor) {
+		patch := client.MergeFrom(racecourse.DeepCopy())
+		racecourse.Status.EventSink = cursor
+		if err := r.Status().Patch(ctx, racecourse, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err != nil {
+		return ctrl.Result{RequeueAfter: eventSinkRetryInterval}, nil
+	}
+	if pending {
+		return ctrl.Result{RequeueAfter: roundIndexCatchUpDelay}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// Sends whatever the sink hasn't been sent yet, moving the cursor past everything it
+// accepts, and reports whether indexed events are still waiting to be sent
+func (r *EventSinkReconciler) deliver(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, cursor *racecoursev1beta1.EventSinkStatus) (bool, error) {
+	sender, err := r.sender(ctx, racecourse)
+	if err != nil {
+		return false, err
+	}
+
+	if deployed := racecourse.Status.ContractAddress; racecourse.Spec.ContractAddress == "" && deployed != "" &&
+		!strings.EqualFold(cursor.DeployedContract, deployed) {
+		data := r.eventData(racecourse, deployed)
+		data.TransactionHash = racecourse.Status.ContractDeploymentTransaction
+		err := sender.Send(ctx, r.event(racecourse, strings.ToLower(deployed)+"/deployed", eventTypeContractDeployed, "", data))
+		if err != nil {
+			return false, err
+		}
+		cursor.DeployedContract = deployed
+	}
+
+	if phase := racecourse.Status.Phase; phase != "" && phase != cursor.Phase {
+		data := r.eventData(racecourse, "")
+		data.Phase, data.PreviousPhase = string(phase), string(cursor.Phase)
+		id := fmt.Sprintf("%s/phase/%d", racecourse.UID, cursor.PhaseChanges+1)
+		if err := sender.Send(ctx, r.event(racecourse, id, eventTypePhaseChanged, "", data)); err != nil {
+			return false, err
+		}
+		cursor.Phase = phase
+		cursor.PhaseChanges++
+	}
+
+	return r.deliverContractEvents(ctx, racecourse, sender, cursor)
+}
+
+// Sends the contract's events up to where the RaceRound indexer has got to, so the
+// rounds they belong to can be looked up
+func (r *EventSinkReconciler) deliverContractEvents(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, sender *cloudevents.Sender, cursor *racecoursev1beta1.EventSinkStatus) (bool, error) {
+	index := racecourse.Status.RoundIndex
+	if index == nil {
+		return false, nil
+	}
+	if !strings.EqualFold(cursor.ContractAddress, index.ContractAddress) {
+		cursor.ContractAddress = index.ContractAddress
+		cursor.Block = -1
+	}
+
+	rounds, err := listRounds(ctx, r.Client, racecourse)
+	if err != nil {
+		return false, err
+	}
+	rpc := ethrpc.New(walletEndpointURL(racecourse), r.RPCOptions...)
+
+	for range maxLogRangesPerIndex {
+		if cursor.Block >= index.Block {
+			return false, nil
+		}
+		from := uint64(cursor.Block + 1)
+		to := min(from+maxLogBlockRange-1, uint64(index.Block))
+		logs, err := rpc.GetLogs(ctx, ethrpc.FilterQuery{
+			FromBlock: from,
+			ToBlock:   to,
+			Address:   index.ContractAddress,
+			Topics:    []string{topicBetPlaced, topicPlayersReadyToRaceChanged, topicFinishedRace},
+		})
+		if err != nil {
+			return false, err
+		}
+
+		for _, entry := range logs {
+			event, ok := r.contractEvent(racecourse, rounds, entry)
+			if !ok {
+				continue
+			}
+			if err := sender.Send(ctx, event); err != nil {
+				// Every block before this one has been delivered in full
+				cursor.Block = max(cursor.Block, int64(entry.BlockNumber)-1)
+				return false, err
+			}
+		}
+		cursor.Block = int64(to)
+	}
+	return cursor.Block < index.Block, nil
+}
+
+// Builds the CloudEvent for one of the contract's events, filling in what the indexed
+// rounds know about it
+func (r *EventSinkReconciler) contractEvent(racecourse *racecoursev1beta1.Racecourse, rounds []racecoursev1beta1.RaceRound, entry ethrpc.Log) (cloudevents.Event, bool) {
+	if len(entry.Topics) == 0 {
+		return cloudevents.Event{}, false
+	}
+	var eventType string
+	switch entry.Topics[0] {
+	case topicBetPlaced:
+		eventType = eventTypeBetPlaced
+	case topicPlayersReadyToRaceChanged:
+		eventType = eventTypePlayerReady
+	case topicFinishedRace:
+		eventType = eventTypeRaceFinished
+	default:
+		return cloudevents.Event{}, false
+	}
+
+	address := racecourse.Status.RoundIndex.ContractAddress
+	data := r.eventData(racecourse, address)
+	data.BlockNumber = entry.BlockNumber
+	data.TransactionHash = entry.TransactionHash
+	block := int64(entry.BlockNumber)
+
+	// Newest first, since the event is most likely in the latest round
+	var round *racecoursev1beta1.RaceRound
+	for i := len(rounds) - 1; i >= 0 && round == nil; i-- {
+		candidate := &rounds[i]
+		if !strings.EqualFold(candidate.Spec.ContractAddress, address) {
+			continue
+		}
+		switch eventType {
+		case eventTypeBetPlaced:
+			for _, bet := range candidate.Status.Bets {
+				if bet.TransactionHash == entry.TransactionHash {
+					data.Bet = bet.DeepCopy()
+					round = candidate
+					break
+				}
+			}
+		case eventTypePlayerReady:
+			if candidate.Status.StartBlock <= block && (!candidate.Status.Finished || candidate.Status.FinishedBlock >= block) {
+				round = candidate
+			}
+		case eventTypeRaceFinished:
+			if candidate.Status.FinishedBlock == block {
+				data.WinnerHorse = candidate.Status.WinnerHorse
+				data.Jackpot = candidate.Status.Jackpot
+				round = candidate
+			}
+		}
+	}
+
+	subject := ""
+	if round != nil {
+		data.Round = round.Spec.Round
+		subject = round.Name
+	}
+	// The position of the log identifies it, so redelivered events keep their ID
+	id := fmt.Sprintf("%s/%d/%d", strings.ToLower(address), entry.BlockNumber, entry.LogIndex)
+	return r.event(racecourse, id, eventType, subject, data), true
+}
+
+// Starts the data of an event about the Racecourse
+func (r *EventSinkReconciler) eventData(racecourse *racecoursev1beta1.Racecourse, address string) *sinkEventData {
+	return &sinkEventData{Racecourse: racecourse.Name, Namespace: racecourse.Namespace, ContractAddress: address}
+}
+
+// Wraps the data in a CloudEvent sourced from the Racecourse
+func (r *EventSinkReconciler) event(racecourse *racecoursev1beta1.Racecourse, id, eventType, subject string, data *sinkEventData) cloudevents.Event {
+	return cloudevents.Event{
+		ID:      id,
+		Source:  fmt.Sprintf("/apis/%s/namespaces/%s/racecourses/%s", racecoursev1beta1.GroupVersion, racecourse.Namespace, racecourse.Name),
+		Type:    eventType,
+		Subject: subject,
+		Time:    time.Now(),
+		Data:    data,
+	}
+}
+
+// Creates a sender for the Racecourse's sink, with the bearer token from its Secret
+func (r *EventSinkReconciler) sender(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (*cloudevents.Sender, error) {
+	sink := racecourse.Spec.EventSink
+	opts := r.SenderOptions
+	if ref := sink.AuthSecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: racecourse.Namespace}, secret); err != nil {
+			return nil, fmt.Errorf("unable to read the event sink's auth Secret: %w", err)
+		}
+		token, ok := secret.Data[ref.Key]
+		if !ok {
+			return nil, fmt.Errorf("the event sink's auth Secret %s has no key %s", ref.Name, ref.Key)
+		}
+		opts = append(append([]cloudevents.Option{}, opts...), cloudevents.WithToken(strings.TrimSpace(string(token))))
+	}
+	return cloudevents.New(sink.URL, opts...), nil
+}
+
+// Only reconciles a Racecourse when there is something new to deliver or the sink
+// changes, and not on its own cursor updates
+var eventSinkInputsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		old, okOld := e.ObjectOld.(*racecoursev1beta1.Racecourse)
+		updated, okNew := e.ObjectNew.(*racecoursev1beta1.Racecourse)
+		if !okOld || !okNew {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(old.Spec.EventSink, updated.Spec.EventSink) ||
+			old.Status.Phase != updated.Status.Phase ||
+			old.Status.ContractAddress != updated.Status.ContractAddress ||
+			!equality.Semantic.DeepEqual(old.Status.RoundIndex, updated.Status.RoundIndex)
+	},
+}
+
+func (r *EventSinkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("eventsink").
+		For(&racecoursev1beta1.Racecourse{}, builder.WithPredicates(eventSinkInputsChanged)).
+		Complete(r)
+}
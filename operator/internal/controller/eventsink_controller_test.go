@@ -0,0 +1,259 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/cloudevents"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// An event received by the fake sink
+type sunkEvent struct {
+	ID            string
+	Type          string
+	Subject       string
+	Authorization string
+	Data          sinkEventData
+}
+
+// Collects the events posted to it, rejecting any that reject returns true for
+type fakeSink struct {
+	mu       sync.Mutex
+	received []sunkEvent
+	reject   func(eventType string) bool
+}
+
+func (s *fakeSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reject != nil && s.reject(r.Header.Get("ce-type")) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	Expect(err).NotTo(HaveOccurred())
+	event := sunkEvent{
+		ID:            r.Header.Get("ce-id"),
+		Type:          r.Header.Get("ce-type"),
+		Subject:       r.Header.Get("ce-subject"),
+		Authorization: r.Header.Get("Authorization"),
+	}
+	Expect(json.Unmarshal(body, &event.Data)).To(Succeed())
+	s.received = append(s.received, event)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// The types of the events received so far
+func (s *fakeSink) types() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := []string{}
+	for _, event := range s.received {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+var _ = Describe("Racecourse event sink", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *EventSinkReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		sink       *fakeSink
+		key        types.NamespacedName
+	)
+
+	const address = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	deliver := func() reconcile.Result {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		chainServer := fakeRaceChainServer(newFakeRaceChain())
+		DeferCleanup(chainServer.Close)
+		sink = &fakeSink{}
+		sinkServer := httptest.NewServer(sink)
+		DeferCleanup(sinkServer.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas: ptr.To(int32(2)),
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  chainServer.URL,
+				},
+				EventSink: &racecoursev1beta1.EventSinkSpec{URL: sinkServer.URL},
+			},
+			Status: racecoursev1beta1.RacecourseStatus{
+				Phase:                         racecoursev1beta1.RacecoursePhaseRunning,
+				ContractAddress:               address,
+				ContractDeploymentTransaction: "0x01",
+				Conditions: []metav1.Condition{{
+					Type:               racecoursev1beta1.ConditionTypeContractVerified,
+					Status:             metav1.ConditionTrue,
+					Reason:             racecoursev1beta1.ReasonContractCodeMatches,
+					LastTransitionTime: metav1.Now(),
+				}},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).
+			WithObjects(racecourse).WithStatusSubresource(racecourse, &racecoursev1beta1.RaceRound{}).Build()
+		rpcOptions := []ethrpc.Option{ethrpc.WithRetries(0, 0)}
+
+		By("indexing the contract's rounds first")
+		indexer := &RaceRoundReconciler{Client: c, Scheme: testScheme, Recorder: record.NewFakeRecorder(100), APIReader: c, RPCOptions: rpcOptions}
+		_, err := indexer.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler = &EventSinkReconciler{
+			Client:        c,
+			Scheme:        testScheme,
+			APIReader:     c,
+			RPCOptions:    rpcOptions,
+			SenderOptions: []cloudevents.Option{cloudevents.WithRetries(0, 0)},
+		}
+	})
+
+	It("should deliver the deployment, the phase and the indexed contract events", func() {
+		Expect(deliver()).To(Equal(reconcile.Result{}))
+
+		Expect(sink.types()).To(Equal([]string{
+			eventTypeContractDeployed,
+			eventTypePhaseChanged,
+			eventTypeBetPlaced,
+			eventTypeBetPlaced,
+			eventTypePlayerReady,
+			eventTypePlayerReady,
+			eventTypeRaceFinished,
+			eventTypeBetPlaced,
+		}))
+		Expect(sink.received[0].Data.TransactionHash).To(Equal("0x01"))
+		Expect(sink.received[1].Data.Phase).To(Equal("Running"))
+		Expect(sink.received[2].ID).To(Equal("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed/3/0"))
+		Expect(sink.received[2].Subject).To(Equal("racecourse-5aaeb605-1"))
+		Expect(sink.received[2].Data.Bet).To(HaveValue(HaveField("Player", fakePlayerA)))
+		Expect(sink.received[6].Data.Round).To(Equal(int64(1)))
+		Expect(sink.received[6].Data.WinnerHorse).To(HaveValue(Equal(int64(2))))
+		Expect(sink.received[7].Data.Round).To(Equal(int64(2)))
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.EventSink).To(Equal(&racecoursev1beta1.EventSinkStatus{
+			ContractAddress:  address,
+			Block:            10,
+			Phase:            racecoursev1beta1.RacecoursePhaseRunning,
+			PhaseChanges:     1,
+			DeployedContract: address,
+		}))
+
+		By("sending nothing again")
+		deliver()
+		Expect(sink.received).To(HaveLen(8))
+	})
+
+	It("should send events again from the cursor until the sink accepts them", func() {
+		sink.reject = func(eventType string) bool { return eventType == eventTypeRaceFinished }
+		Expect(deliver().RequeueAfter).To(Equal(eventSinkRetryInterval))
+		Expect(sink.received).To(HaveLen(6))
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.EventSink.Block).To(Equal(int64(6)))
+		Expect(racecourse.Status.EventSink.Message).To(ContainSubstring("HTTP 503"))
+
+		By("resending the rest of the block the sink stopped in")
+		sink.reject = nil
+		deliver()
+		Expect(sink.types()[6:]).To(Equal([]string{eventTypePlayerReady, eventTypeRaceFinished, eventTypeBetPlaced}))
+		Expect(sink.received[6].ID).To(Equal(sink.received[5].ID))
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.EventSink.Block).To(Equal(int64(10)))
+		Expect(racecourse.Status.EventSink.Message).To(BeEmpty())
+	})
+
+	It("should announce a phase change once", func() {
+		deliver()
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		racecourse.Status.Phase = racecoursev1beta1.RacecoursePhaseFailed
+		Expect(c.Status().Update(ctx, racecourse)).To(Succeed())
+
+		deliver()
+		deliver()
+		last := sink.received[len(sink.received)-1]
+		Expect(last.Type).To(Equal(eventTypePhaseChanged))
+		Expect(last.ID).To(Equal("1234/phase/2"))
+		Expect(last.Data.PreviousPhase).To(Equal("Running"))
+		Expect(last.Data.Phase).To(Equal("Failed"))
+		Expect(sink.received).To(HaveLen(9))
+	})
+
+	It("should send the bearer token from the auth Secret", func() {
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		racecourse.Spec.EventSink.AuthSecretRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "sink-auth"},
+			Key:                  "token",
+		}
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+
+		By("holding delivery while the Secret is missing")
+		Expect(deliver().RequeueAfter).To(Equal(eventSinkRetryInterval))
+		Expect(sink.received).To(BeEmpty())
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.EventSink.Message).To(ContainSubstring("auth Secret"))
+
+		Expect(c.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sink-auth", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("s3cret\n")},
+		})).To(Succeed())
+		deliver()
+		Expect(sink.received).NotTo(BeEmpty())
+		Expect(sink.received[0].Authorization).To(Equal("Bearer s3cret"))
+	})
+})
@@ -0,0 +1,235 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How long to wait before subscribing to new heads again after a subscription fails,
+// doubling with every failure in a row
+const (
+	headResubscribeMinBackoff = time.Second
+	headResubscribeMaxBackoff = time.Minute
+)
+
+// How many triggers each controller can have waiting before more are dropped. A dropped
+// trigger only matters if the Racecourse isn't already waiting to be reconciled.
+const headEventBuffer = 100
+
+// HeadWatcher subscribes to new blocks on the WebSocket endpoint of every Racecourse
+// that has one, and triggers its controllers whenever a block may hold events from its
+// contract, so the race state is read as it changes rather than polled for. A nil
+// HeadWatcher watches nothing.
+type HeadWatcher struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	watches map[types.NamespacedName]*headWatch
+	sinks   []chan event.GenericEvent
+}
+
+// The subscription kept open for one Racecourse
+type headWatch struct {
+	url string
+	// The contract whose events trigger the Racecourse, empty while there's none to read
+	address string
+	live    atomic.Bool
+	cancel  context.CancelFunc
+}
+
+// Start runs the subscriptions until the manager stops
+func (w *HeadWatcher) Start(ctx context.Context) error {
+	w.mu.Lock()
+	w.ctx = ctx
+	for key, watch := range w.watches {
+		w.run(key, watch)
+	}
+	w.mu.Unlock()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Only the leader reconciles, so only the leader needs subscriptions
+func (w *HeadWatcher) NeedLeaderElection() bool {
+	return true
+}
+
+// Source returns a source for a controller that fires for a Racecourse when a block
+// may hold its contract's events, and when its subscription comes up or goes down
+func (w *HeadWatcher) Source() source.Source {
+	return source.Channel(w.channel(), &handler.EnqueueRequestForObject{})
+}
+
+func (w *HeadWatcher) channel() chan event.GenericEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sink := make(chan event.GenericEvent, headEventBuffer)
+	w.sinks = append(w.sinks, sink)
+	return sink
+}
+
+// Watch keeps a subscription open to the URL for the Racecourse, triggering it on
+// blocks that may hold events from the address. An empty URL stops watching it.
+func (w *HeadWatcher) Watch(key types.NamespacedName, url, address string) {
+	if w == nil {
+		return
+	}
+	if url == "" {
+		w.Stop(key)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if watch, ok := w.watches[key]; ok && watch.url == url {
+		watch.address = address
+		return
+	}
+	w.stop(key)
+
+	watch := &headWatch{url: url, address: address}
+	if w.watches == nil {
+		w.watches = map[types.NamespacedName]*headWatch{}
+	}
+	w.watches[key] = watch
+	if w.ctx != nil {
+		w.run(key, watch)
+	}
+}
+
+// Stop closes the Racecourse's subscription, if it has one
+func (w *HeadWatcher) Stop(key types.NamespacedName) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stop(key)
+}
+
+// Live reports whether the Racecourse's subscription is up, so it's triggered by new
+// blocks and doesn't need polling
+func (w *HeadWatcher) Live(key types.NamespacedName) bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	watch, ok := w.watches[key]
+	return ok && watch.live.Load()
+}
+
+func (w *HeadWatcher) stop(key types.NamespacedName) {
+	if watch, ok := w.watches[key]; ok {
+		if watch.cancel != nil {
+			watch.cancel()
+		}
+		delete(w.watches, key)
+	}
+}
+
+func (w *HeadWatcher) run(key types.NamespacedName, watch *headWatch) {
+	ctx, cancel := context.WithCancel(w.ctx)
+	watch.cancel = cancel
+	go w.follow(ctx, key, watch)
+}
+
+// Keeps a subscription open until the watch is stopped, subscribing again with a
+// backoff whenever it fails
+func (w *HeadWatcher) follow(ctx context.Context, key types.NamespacedName, watch *headWatch) {
+	log := log.FromContext(ctx).WithName("head-watcher").WithValues("racecourse", key, "url", watch.url)
+
+	backoff := headResubscribeMinBackoff
+	for {
+		subscribed, err := w.subscribe(ctx, key, watch)
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			backoff = headResubscribeMinBackoff
+		}
+		log.Error(err, "New heads subscription failed, subscribing again", "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, headResubscribeMaxBackoff)
+	}
+}
+
+// Follows new heads until the subscription fails. The Racecourse is triggered when the
+// subscription comes up, to catch up on blocks missed while it was down, and when it
+// goes down, so its controllers go back to polling.
+func (w *HeadWatcher) subscribe(ctx context.Context, key types.NamespacedName, watch *headWatch) (bool, error) {
+	subscription, err := ethrpc.SubscribeNewHeads(ctx, watch.url)
+	if err != nil {
+		return false, err
+	}
+	defer subscription.Close() //nolint:errcheck
+
+	watch.live.Store(true)
+	w.trigger(key)
+	defer func() {
+		watch.live.Store(false)
+		if ctx.Err() == nil {
+			w.trigger(key)
+		}
+	}()
+
+	for {
+		header, err := subscription.Next()
+		if err != nil {
+			return true, err
+		}
+		w.mu.Lock()
+		address := watch.address
+		w.mu.Unlock()
+		if address != "" && header.MayContainLogsFrom(address) {
+			w.trigger(key)
+		}
+	}
+}
+
+// Queues the Racecourse with every controller, dropping the trigger for controllers
+// that are too far behind
+func (w *HeadWatcher) trigger(key types.NamespacedName) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	racecourse := &racecoursev1beta1.Racecourse{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	for _, sink := range w.sinks {
+		select {
+		case sink <- event.GenericEvent{Object: racecourse}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/websocket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// Serves a newHeads subscription that sends each logs bloom passed on the channel as
+// a new block, and drops the connection when the channel is closed
+func fakeHeadsServer(blooms <-chan []byte) *httptest.Server {
+	return httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		defer GinkgoRecover()
+		request := map[string]any{}
+		Expect(websocket.JSON.Receive(conn, &request)).To(Succeed())
+		Expect(request["method"]).To(Equal("eth_subscribe"))
+		Expect(websocket.JSON.Send(conn, map[string]any{"jsonrpc": "2.0", "id": request["id"], "result": "0x1"})).To(Succeed())
+
+		number := 0
+		for bloom := range blooms {
+			number++
+			header := map[string]string{"number": ethrpc.BlockTag(uint64(number)), "hash": "0x01"}
+			if bloom != nil {
+				header["logsBloom"] = ethrpc.EncodeHex(bloom)
+			}
+			Expect(websocket.JSON.Send(conn, map[string]any{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params":  map[string]any{"subscription": "0x1", "result": header},
+			})).To(Succeed())
+		}
+	}))
+}
+
+var _ = Describe("New heads watcher", func() {
+	const address = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	var (
+		watcher  *HeadWatcher
+		triggers chan event.GenericEvent
+		blooms   chan []byte
+		url      string
+		key      = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		blooms = make(chan []byte)
+		server := fakeHeadsServer(blooms)
+		DeferCleanup(server.Close)
+		url = "ws" + strings.TrimPrefix(server.URL, "http")
+
+		watcher = &HeadWatcher{}
+		triggers = watcher.channel()
+	})
+
+	start := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		go func() {
+			defer GinkgoRecover()
+			Expect(watcher.Start(ctx)).To(Succeed())
+		}()
+	}
+
+	It("should trigger the Racecourse on blocks that may hold its contract's events", func() {
+		watcher.Watch(key, url, "")
+		start()
+
+		By("triggering it once subscribed, to catch up")
+		Eventually(triggers).Should(Receive(HaveField("Object.GetName()", "racecourse")))
+		Expect(watcher.Live(key)).To(BeTrue())
+
+		By("ignoring blocks while there's no contract to read")
+		blooms <- nil
+		Consistently(triggers, 200*time.Millisecond).ShouldNot(Receive())
+
+		By("ignoring blocks whose bloom rules the contract out")
+		watcher.Watch(key, url, address)
+		blooms <- make([]byte, 256)
+		Consistently(triggers, 200*time.Millisecond).ShouldNot(Receive())
+
+		blooms <- nil
+		Eventually(triggers).Should(Receive())
+
+		By("triggering it again when the subscription goes down")
+		close(blooms)
+		Eventually(triggers).Should(Receive())
+		Expect(watcher.Live(key)).To(BeFalse())
+	})
+
+	It("should close the subscription when the Racecourse stops watching", func() {
+		start()
+		watcher.Watch(key, url, address)
+		Eventually(triggers).Should(Receive())
+
+		watcher.Watch(key, "", address)
+		Expect(watcher.Live(key)).To(BeFalse())
+		Consistently(triggers, 200*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should watch nothing when there's no watcher", func() {
+		var none *HeadWatcher
+		none.Watch(key, url, address)
+		Expect(none.Live(key)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Racecourse resync", func() {
+	It("should requeue after the delay or the resync interval, whichever is sooner", func() {
+		reconciler := &RacecourseReconciler{ResyncInterval: 10 * time.Minute}
+		racecourse := &racecoursev1beta1.Racecourse{}
+
+		Expect(reconciler.requeue(racecourse, 30*time.Second).RequeueAfter).To(Equal(30 * time.Second))
+		Expect(reconciler.requeue(racecourse, time.Hour).RequeueAfter).To(Equal(10 * time.Minute))
+		Expect(reconciler.requeue(racecourse, 0).RequeueAfter).To(Equal(10 * time.Minute))
+
+		By("letting the Racecourse override the interval")
+		racecourse.Spec.ResyncInterval = &metav1.Duration{Duration: time.Minute}
+		Expect(reconciler.requeue(racecourse, 0).RequeueAfter).To(Equal(time.Minute))
+		racecourse.Spec.ResyncInterval = &metav1.Duration{}
+		Expect(reconciler.requeue(racecourse, 0).RequeueAfter).To(BeZero())
+		Expect(reconciler.requeue(racecourse, time.Hour).RequeueAfter).To(Equal(time.Hour))
+	})
+})
@@ -0,0 +1,220 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// Standings across every finished round of a Racecourse. Winners split the jackpot
+// evenly, as the app's race results show it.
+type leaderboard struct {
+	Racecourse string `json:"racecourse"`
+	Namespace  string `json:"namespace"`
+	// The finished rounds counted, and those left out because their winner or bets
+	// couldn't be read
+	Rounds           int              `json:"rounds"`
+	IncompleteRounds int              `json:"incompleteRounds,omitempty"`
+	Players          []playerStanding `json:"players"`
+	Horses           []horseStanding  `json:"horses"`
+}
+
+type playerStanding struct {
+	Address string `json:"address"`
+	Rounds  int    `json:"rounds"`
+	Wins    int    `json:"wins"`
+	Wagered string `json:"wagered"`
+	Won     string `json:"won"`
+	Net     string `json:"net"`
+
+	wagered, won *big.Int
+}
+
+type horseStanding struct {
+	Horse int64 `json:"horse"`
+	Wins  int   `json:"wins"`
+	Bets  int   `json:"bets"`
+	// What was bet on the horse, and what those bets won
+	Wagered string `json:"wagered"`
+	Won     string `json:"won"`
+	Net     string `json:"net"`
+
+	wagered, won *big.Int
+}
+
+// The name of the ConfigMap holding a Racecourse's leaderboard
+func leaderboardName(racecourse *racecoursev1beta1.Racecourse) string {
+	return racecourse.Name + "-leaderboard"
+}
+
+// Tallies the finished rounds into standings by player and by horse
+func computeLeaderboard(racecourse *racecoursev1beta1.Racecourse, rounds []racecoursev1beta1.RaceRound) *leaderboard {
+	board := &leaderboard{Racecourse: racecourse.Name, Namespace: racecourse.Namespace}
+	players := map[string]*playerStanding{}
+	horses := map[int64]*horseStanding{}
+
+	for _, round := range rounds {
+		if !round.Status.Finished {
+			continue
+		}
+		if !roundComplete(&round) {
+			board.IncompleteRounds++
+			continue
+		}
+		board.Rounds++
+
+		winner := *round.Status.WinnerHorse
+		jackpot, _ := new(big.Int).SetString(round.Status.Jackpot, 10)
+		winners := 0
+		for _, bet := range round.Status.Bets {
+			if bet.Horse == winner {
+				winners++
+			}
+		}
+		award := new(big.Int)
+		if winners > 0 {
+			award.Quo(jackpot, big.NewInt(int64(winners)))
+		}
+
+		horseFor(horses, winner).Wins++
+		for _, bet := range round.Status.Bets {
+			amount, _ := new(big.Int).SetString(bet.Amount, 10)
+			player, ok := players[bet.Player]
+			if !ok {
+				player = &playerStanding{Address: bet.Player, wagered: new(big.Int), won: new(big.Int)}
+				players[bet.Player] = player
+			}
+			horse := horseFor(horses, bet.Horse)
+
+			player.Rounds++
+			player.wagered.Add(player.wagered, amount)
+			horse.Bets++
+			horse.wagered.Add(horse.wagered, amount)
+			if bet.Horse == winner {
+				player.Wins++
+				player.won.Add(player.won, award)
+				horse.won.Add(horse.won, award)
+			}
+		}
+	}
+
+	board.Players = make([]playerStanding, 0, len(players))
+	for _, player := range players {
+		player.Wagered, player.Won = player.wagered.String(), player.won.String()
+		player.Net = new(big.Int).Sub(player.won, player.wagered).String()
+		board.Players = append(board.Players, *player)
+	}
+	// Best first, by net winnings
+	slices.SortFunc(board.Players, func(a, b playerStanding) int {
+		net := new(big.Int).Sub(b.won, b.wagered).Cmp(new(big.Int).Sub(a.won, a.wagered))
+		return cmp.Or(net, strings.Compare(a.Address, b.Address))
+	})
+
+	board.Horses = make([]horseStanding, 0, len(horses))
+	for _, horse := range horses {
+		horse.Wagered, horse.Won = horse.wagered.String(), horse.won.String()
+		horse.Net = new(big.Int).Sub(horse.won, horse.wagered).String()
+		board.Horses = append(board.Horses, *horse)
+	}
+	slices.SortFunc(board.Horses, func(a, b horseStanding) int {
+		return cmp.Compare(a.Horse, b.Horse)
+	})
+
+	return board
+}
+
+// Whether everything the leaderboard needs from a finished round could be read
+func roundComplete(round *racecoursev1beta1.RaceRound) bool {
+	if round.Status.WinnerHorse == nil {
+		return false
+	}
+	if _, ok := new(big.Int).SetString(round.Status.Jackpot, 10); !ok {
+		return false
+	}
+	for _, bet := range round.Status.Bets {
+		if _, ok := new(big.Int).SetString(bet.Amount, 10); !ok || bet.Player == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func horseFor(horses map[int64]*horseStanding, index int64) *horseStanding {
+	horse, ok := horses[index]
+	if !ok {
+		horse = &horseStanding{Horse: index, wagered: new(big.Int), won: new(big.Int)}
+		horses[index] = horse
+	}
+	return horse
+}
+
+// Writes the leaderboard from the Racecourse's rounds to an owned ConfigMap, when a
+// round has finished since it was last written or it doesn't exist
+func (r *RaceRoundReconciler) reconcileLeaderboard(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, stale bool) error {
+	if !stale {
+		err := r.Get(ctx, types.NamespacedName{Name: leaderboardName(racecourse), Namespace: racecourse.Namespace}, &corev1.ConfigMap{})
+		if !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	rounds, err := listRounds(ctx, r.Client, racecourse)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(computeLeaderboard(racecourse, rounds), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := corev1ac.ConfigMap(leaderboardName(racecourse), racecourse.Namespace).
+		WithLabels(labelsForRacecourse(racecourse.Name)).
+		WithOwnerReferences(ownerReference(racecourse)).
+		WithData(map[string]string{"leaderboard.json": string(data)})
+	return r.Apply(ctx, configMap, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// Lists the rounds the Racecourse owns, across every contract it has used, oldest first
+func listRounds(ctx context.Context, c client.Reader, racecourse *racecoursev1beta1.Racecourse) ([]racecoursev1beta1.RaceRound, error) {
+	list := &racecoursev1beta1.RaceRoundList{}
+	if err := c.List(ctx, list, client.InNamespace(racecourse.Namespace), client.MatchingLabels(labelsForRacecourse(racecourse.Name))); err != nil {
+		return nil, err
+	}
+
+	rounds := slices.DeleteFunc(list.Items, func(round racecoursev1beta1.RaceRound) bool {
+		owner := metav1.GetControllerOf(&round)
+		return owner == nil || owner.UID != racecourse.UID
+	})
+	slices.SortFunc(rounds, func(a, b racecoursev1beta1.RaceRound) int {
+		return cmp.Or(cmp.Compare(a.Status.StartBlock, b.Status.StartBlock), cmp.Compare(a.Spec.Round, b.Spec.Round))
+	})
+	return rounds, nil
+}
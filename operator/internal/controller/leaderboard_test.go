@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse leaderboard", func() {
+	racecourse := &racecoursev1beta1.Racecourse{ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default"}}
+
+	// A finished round with the bets
+	finishedRound := func(winner int64, jackpot string, bets ...racecoursev1beta1.RaceBet) racecoursev1beta1.RaceRound {
+		return racecoursev1beta1.RaceRound{Status: racecoursev1beta1.RaceRoundStatus{
+			Bets:        bets,
+			Finished:    true,
+			WinnerHorse: ptr.To(winner),
+			Jackpot:     jackpot,
+		}}
+	}
+
+	It("should split the jackpot between the players who backed the winner", func() {
+		board := computeLeaderboard(racecourse, []racecoursev1beta1.RaceRound{
+			finishedRound(2, "300",
+				racecoursev1beta1.RaceBet{Horse: 2, Player: fakePlayerA, Amount: "100"},
+				racecoursev1beta1.RaceBet{Horse: 2, Player: fakePlayerB, Amount: "50"},
+				racecoursev1beta1.RaceBet{Horse: 0, Player: "0x3333333333333333333333333333333333333333", Amount: "150"},
+			),
+			// Nobody backed the winner, so the jackpot carries over and nobody wins
+			finishedRound(1, "40", racecoursev1beta1.RaceBet{Horse: 3, Player: fakePlayerB, Amount: "40"}),
+		})
+
+		Expect(board.Rounds).To(Equal(2))
+		Expect(board.Players).To(HaveLen(3))
+		Expect(board.Players[0]).To(SatisfyAll(
+			HaveField("Address", fakePlayerB), HaveField("Rounds", 2), HaveField("Wins", 1), HaveField("Wagered", "90"), HaveField("Won", "150"), HaveField("Net", "60")))
+		Expect(board.Players[1]).To(SatisfyAll(
+			HaveField("Address", fakePlayerA), HaveField("Rounds", 1), HaveField("Wins", 1), HaveField("Wagered", "100"), HaveField("Won", "150"), HaveField("Net", "50")))
+		Expect(board.Players[2]).To(HaveField("Net", "-150"))
+
+		Expect(board.Horses).To(HaveLen(4))
+		Expect(board.Horses[0]).To(SatisfyAll(HaveField("Horse", int64(0)), HaveField("Bets", 1), HaveField("Net", "-150")))
+		Expect(board.Horses[1]).To(SatisfyAll(HaveField("Horse", int64(1)), HaveField("Wins", 1), HaveField("Bets", 0), HaveField("Net", "0")))
+		Expect(board.Horses[2]).To(SatisfyAll(HaveField("Horse", int64(2)), HaveField("Wins", 1), HaveField("Bets", 2), HaveField("Won", "300")))
+	})
+
+	It("should leave out rounds that are open or couldn't be read", func() {
+		open := finishedRound(0, "10", racecoursev1beta1.RaceBet{Horse: 0, Player: fakePlayerA, Amount: "10"})
+		open.Status.Finished = false
+		unread := finishedRound(0, "10", racecoursev1beta1.RaceBet{Horse: 0, Amount: "10"})
+		noWinner := finishedRound(0, "10", racecoursev1beta1.RaceBet{Horse: 0, Player: fakePlayerA, Amount: "10"})
+		noWinner.Status.WinnerHorse = nil
+
+		board := computeLeaderboard(racecourse, []racecoursev1beta1.RaceRound{open, unread, noWinner})
+		Expect(board.Rounds).To(BeZero())
+		Expect(board.IncompleteRounds).To(Equal(2))
+		Expect(board.Players).To(BeEmpty())
+	})
+})
@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// How long a running container may stay unready before its readiness probe is
+// considered to be failing rather than the app still starting
+const probeFailureGracePeriod = 2 * time.Minute
+
+// The annotation the Deployment controller uses to number rollouts
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// ManagedPodSelector selects the racecourse and Besu node pods the controllers
+// run, and the ReplicaSets that carry their labels. The manager only needs to
+// cache these, rather than every pod and ReplicaSet in the cluster.
+func ManagedPodSelector() labels.Selector {
+	managed, err := labels.NewRequirement("app", selection.In, []string{"racecourse", "besu"})
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*managed)
+}
+
+// Describes why the racecourse pods can't run
+type podFailure struct {
+	Reason  string
+	Message string
+}
+
+// Lists the pods of the Deployment's current ReplicaSet, so that pods of an older
+// revision that are being scaled down don't count
+func (r *RacecourseReconciler) listCurrentPods(ctx context.Context, deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := r.List(ctx, replicaSets, client.InNamespace(deployment.Namespace), client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+		return nil, err
+	}
+
+	revision := deployment.Annotations[deploymentRevisionAnnotation]
+	var current *appsv1.ReplicaSet
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		owner := metav1.GetControllerOf(replicaSet)
+		if owner == nil || owner.UID != deployment.UID {
+			continue
+		}
+		if replicaSet.Annotations[deploymentRevisionAnnotation] == revision {
+			current = replicaSet
+			break
+		}
+	}
+	if current == nil {
+		return nil, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(deployment.Namespace), client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+		return nil, err
+	}
+
+	var owned []corev1.Pod
+	for _, pod := range pods.Items {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.UID == current.UID {
+			owned = append(owned, pod)
+		}
+	}
+	return owned, nil
+}
+
+// Finds the first pod that can't run and classifies why, or returns nil if all of the
+// pods are running or still starting
+func detectPodFailure(pods []corev1.Pod, now time.Time) *podFailure {
+	for i := range pods {
+		if failure := classifyPod(&pods[i], now); failure != nil {
+			return failure
+		}
+	}
+	return nil
+}
+
+func classifyPod(pod *corev1.Pod, now time.Time) *podFailure {
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == corev1.PodReasonUnschedulable {
+			return &podFailure{
+				Reason:  racecoursev1beta1.ReasonUnschedulable,
+				Message: fmt.Sprintf("pod %s can't be scheduled: %s", pod.Name, condition.Message),
+			}
+		}
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil {
+			switch waiting.Reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+				return &podFailure{
+					Reason: racecoursev1beta1.ReasonImagePullFailed,
+					Message: fmt.Sprintf("pod %s can't pull image %s (%s): %s",
+						pod.Name, status.Image, waiting.Reason, waiting.Message),
+				}
+			case "CrashLoopBackOff":
+				return &podFailure{
+					Reason: racecoursev1beta1.ReasonCrashLooping,
+					Message: fmt.Sprintf("container %s in pod %s keeps crashing after %d restarts%s",
+						status.Name, pod.Name, status.RestartCount, lastTermination(status)),
+				}
+			}
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		running := status.State.Running
+		if running != nil && !status.Ready && now.Sub(running.StartedAt.Time) > probeFailureGracePeriod {
+			return &podFailure{
+				Reason: racecoursev1beta1.ReasonProbeFailing,
+				Message: fmt.Sprintf("container %s in pod %s has been running for %s without passing its readiness probe",
+					status.Name, pod.Name, now.Sub(running.StartedAt.Time).Round(time.Second)),
+			}
+		}
+	}
+
+	return nil
+}
+
+// Describes how the container last exited, if it has
+func lastTermination(status corev1.ContainerStatus) string {
+	terminated := status.LastTerminationState.Terminated
+	if terminated == nil {
+		return ""
+	}
+	return fmt.Sprintf(", last exiting with code %d (%s)", terminated.ExitCode, terminated.Reason)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse pod failure detection", func() {
+	now := time.Now()
+
+	podWith := func(status corev1.ContainerStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse-abc"},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+		}
+	}
+
+	It("should ignore healthy and starting pods", func() {
+		pods := []corev1.Pod{
+			podWith(corev1.ContainerStatus{Name: "racecourse", Ready: true, State: corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			}}),
+			podWith(corev1.ContainerStatus{Name: "racecourse", State: corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-10 * time.Second))},
+			}}),
+			podWith(corev1.ContainerStatus{Name: "racecourse", State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"},
+			}}),
+		}
+		Expect(detectPodFailure(pods, now)).To(BeNil())
+	})
+
+	It("should detect image pull failures", func() {
+		pods := []corev1.Pod{podWith(corev1.ContainerStatus{Name: "racecourse", Image: "racecourse:missing", State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"},
+		}})}
+		failure := detectPodFailure(pods, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.Reason).To(Equal(racecoursev1beta1.ReasonImagePullFailed))
+		Expect(failure.Message).To(ContainSubstring("racecourse:missing"))
+	})
+
+	It("should detect crash loops", func() {
+		pods := []corev1.Pod{podWith(corev1.ContainerStatus{
+			Name:         "racecourse",
+			RestartCount: 5,
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+			},
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+			},
+		})}
+		failure := detectPodFailure(pods, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.Reason).To(Equal(racecoursev1beta1.ReasonCrashLooping))
+		Expect(failure.Message).To(ContainSubstring("exiting with code 1"))
+	})
+
+	It("should detect unschedulable pods", func() {
+		pods := []corev1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse-abc"},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}}},
+		}}
+		failure := detectPodFailure(pods, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.Reason).To(Equal(racecoursev1beta1.ReasonUnschedulable))
+		Expect(failure.Message).To(ContainSubstring("Insufficient cpu"))
+	})
+
+	It("should detect readiness probes that keep failing", func() {
+		pods := []corev1.Pod{podWith(corev1.ContainerStatus{Name: "racecourse", State: corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-5 * time.Minute))},
+		}})}
+		failure := detectPodFailure(pods, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.Reason).To(Equal(racecoursev1beta1.ReasonProbeFailing))
+	})
+
+	It("should ignore pods that are being deleted", func() {
+		pod := podWith(corev1.ContainerStatus{Name: "racecourse", State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+		}})
+		pod.DeletionTimestamp = &metav1.Time{Time: now}
+		Expect(detectPodFailure([]corev1.Pod{pod}, now)).To(BeNil())
+	})
+})
+
+var _ = Describe("Managed pod selector", func() {
+	It("should select the racecourse and Besu node pods and nothing else", func() {
+		network := &racecoursev1beta1.BesuNetwork{ObjectMeta: metav1.ObjectMeta{Name: "besu"}}
+		Expect(ManagedPodSelector().Matches(labels.Set(labelsForRacecourse("racecourse")))).To(BeTrue())
+		Expect(ManagedPodSelector().Matches(labels.Set(besuLabels(network)))).To(BeTrue())
+		Expect(ManagedPodSelector().Matches(labels.Set{"app": "nginx"})).To(BeFalse())
+		Expect(ManagedPodSelector().Matches(labels.Set{})).To(BeFalse())
+	})
+})
@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// The most pruned resources kept in the status
+const maxPrunedResources = 10
+
+// A kind of resource the Racecourse owns
+type childKind struct {
+	// The kind, as reported in the status
+	Kind string
+
+	// Creates an empty object of the kind, for watching it
+	New func() client.Object
+
+	// Creates an empty list of the kind, for finding the children to prune
+	NewList func() client.ObjectList
+}
+
+// Every kind of child the Racecourse can own. Optional resources must be listed here
+// so that they're deleted once the spec stops asking for them.
+var childKinds = []childKind{
+	{Kind: "ConfigMap", New: func() client.Object { return &corev1.ConfigMap{} }, NewList: func() client.ObjectList { return &corev1.ConfigMapList{} }},
+	{Kind: "Service", New: func() client.Object { return &corev1.Service{} }, NewList: func() client.ObjectList { return &corev1.ServiceList{} }},
+	{Kind: "Deployment", New: func() client.Object { return &appsv1.Deployment{} }, NewList: func() client.ObjectList { return &appsv1.DeploymentList{} }},
+	{Kind: "Ingress", New: func() client.Object { return &networkingv1.Ingress{} }, NewList: func() client.ObjectList { return &networkingv1.IngressList{} }},
+}
+
+// Identifies a child resource by kind and name
+type childKey struct {
+	Kind string
+	Name string
+}
+
+// Lists the children the spec currently asks for
+func desiredChildren(racecourse *racecoursev1beta1.Racecourse) sets.Set[childKey] {
+	desired := sets.New(
+		childKey{Kind: "ConfigMap", Name: racecourse.Name + "-config"},
+		childKey{Kind: "ConfigMap", Name: leaderboardName(racecourse)},
+		childKey{Kind: "Service", Name: racecourse.Name},
+		childKey{Kind: "Deployment", Name: racecourse.Name},
+	)
+	if racecourse.Spec.Ingress.IsEnabled() {
+		desired.Insert(childKey{Kind: "Ingress", Name: racecourse.Name})
+	}
+	return desired
+}
+
+// Deletes the children the Racecourse controls that aren't desired any more, and
+// returns what was deleted
+func (r *RacecourseReconciler) pruneChildren(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, desired sets.Set[childKey]) ([]racecoursev1beta1.PrunedResource, error) {
+	log := log.FromContext(ctx)
+
+	var pruned []racecoursev1beta1.PrunedResource
+	for _, kind := range childKinds {
+		list := kind.NewList()
+		if err := r.List(ctx, list, client.InNamespace(racecourse.Namespace)); err != nil {
+			return pruned, err
+		}
+
+		err := meta.EachListItem(list, func(item runtime.Object) error {
+			child := item.(client.Object)
+			owner := metav1.GetControllerOf(child)
+			if owner == nil || owner.UID != racecourse.UID || child.GetDeletionTimestamp() != nil {
+				return nil
+			}
+			if desired.Has(childKey{Kind: kind.Kind, Name: child.GetName()}) {
+				return nil
+			}
+
+			log.Info("Pruning child resource", "kind", kind.Kind, "name", child.GetName())
+			err := r.Delete(ctx, child, client.Preconditions{UID: ptr.To(child.GetUID())}, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !errors.IsNotFound(err) {
+				r.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonDeleteFailed, "Failed to delete %s %s: %v", kind.Kind, child.GetName(), err)
+				return err
+			}
+			r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonDeleted, "Deleted %s %s as the spec no longer asks for it", kind.Kind, child.GetName())
+			pruned = append(pruned, racecoursev1beta1.PrunedResource{
+				Kind:     kind.Kind,
+				Name:     child.GetName(),
+				PrunedAt: metav1.Now(),
+			})
+			return nil
+		})
+		if err != nil {
+			return pruned, err
+		}
+	}
+
+	return pruned, nil
+}
+
+// Adds newly pruned resources to the front of the status list, keeping it bounded
+func recordPruned(racecourse *racecoursev1beta1.Racecourse, pruned []racecoursev1beta1.PrunedResource) {
+	if len(pruned) == 0 {
+		return
+	}
+	all := append(pruned, racecourse.Status.PrunedResources...)
+	if len(all) > maxPrunedResources {
+		all = all[:maxPrunedResources]
+	}
+	racecourse.Status.PrunedResources = all
+}
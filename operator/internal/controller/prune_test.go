@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse child pruning", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		key        types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).Build()
+		reconciler = &RacecourseReconciler{Client: c, Scheme: testScheme, Recorder: record.NewFakeRecorder(100)}
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Ingress: racecoursev1beta1.IngressSpec{
+					Enabled:   ptr.To(true),
+					ClassName: "nginx",
+					Host:      "racecourse.example.com",
+					Path:      "/",
+				},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+		Expect(reconciler.reconcileService(ctx, racecourse)).To(Succeed())
+		Expect(reconciler.reconcileIngress(ctx, racecourse)).To(Succeed())
+	})
+
+	It("should keep children that are still desired", func() {
+		pruned, err := reconciler.pruneChildren(ctx, racecourse, desiredChildren(racecourse))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(BeEmpty())
+		Expect(c.Get(ctx, key, &networkingv1.Ingress{})).To(Succeed())
+	})
+
+	It("should delete the Ingress once ingress is disabled", func() {
+		racecourse.Spec.Ingress.Enabled = ptr.To(false)
+		pruned, err := reconciler.pruneChildren(ctx, racecourse, desiredChildren(racecourse))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(HaveLen(1))
+		Expect(pruned[0].Kind).To(Equal("Ingress"))
+		Expect(pruned[0].Name).To(Equal("racecourse"))
+
+		Expect(errors.IsNotFound(c.Get(ctx, key, &networkingv1.Ingress{}))).To(BeTrue())
+		Expect(c.Get(ctx, key, &corev1.Service{})).To(Succeed())
+
+		recordPruned(racecourse, pruned)
+		Expect(racecourse.Status.PrunedResources).To(Equal(pruned))
+
+		events := reconciler.Recorder.(*record.FakeRecorder).Events
+		Expect(events).To(Receive(Equal("Normal Created Created Service racecourse")))
+		Expect(events).To(Receive(Equal("Normal Created Created Ingress racecourse")))
+		Expect(events).To(Receive(Equal("Normal Deleted Deleted Ingress racecourse as the spec no longer asks for it")))
+	})
+
+	It("should not delete resources owned by something else", func() {
+		other := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+		Expect(c.Create(ctx, other)).To(Succeed())
+
+		racecourse.Spec.Ingress.Enabled = ptr.To(false)
+		_, err := reconciler.pruneChildren(ctx, racecourse, desiredChildren(racecourse))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(other), &networkingv1.Ingress{})).To(Succeed())
+	})
+
+	It("should keep the pruned list bounded", func() {
+		for range maxPrunedResources + 5 {
+			recordPruned(racecourse, []racecoursev1beta1.PrunedResource{{Kind: "Ingress", Name: "racecourse"}})
+		}
+		Expect(racecourse.Status.PrunedResources).To(HaveLen(maxPrunedResources))
+	})
+})
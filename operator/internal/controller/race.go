@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How often the state of a running race is read back into the status
+const raceStatusInterval = 30 * time.Second
+
+// The values of the Race contract's public getters, all read at the same block
+type raceSummary struct {
+	HorseCount         *big.Int
+	BetCount           *big.Int
+	PlayersCount       *big.Int
+	PlayersReadyToRace *big.Int
+	RaceFinished       bool
+	WinnerHorse        *big.Int
+	Jackpot            *big.Int
+}
+
+// Calls a view function of the Race contract at the given block
+func callRace(ctx context.Context, rpc *ethrpc.Client, address, block, signature string, args ...*big.Int) (ethrpc.Result, error) {
+	msg := ethrpc.CallMsg{To: address, Data: ethrpc.EncodeCall(signature, args...)}
+	result, err := rpc.EthCall(ctx, msg, block)
+	if err != nil {
+		return nil, err
+	}
+	return ethrpc.Result(result), nil
+}
+
+// Reads the Race contract's public getters at the given block
+func readRaceSummary(ctx context.Context, rpc *ethrpc.Client, address, block string) (*raceSummary, error) {
+	summary := &raceSummary{}
+	for _, getter := range []struct {
+		signature string
+		into      **big.Int
+	}{
+		{"horseCount()", &summary.HorseCount},
+		{"betCount()", &summary.BetCount},
+		{"playersCount()", &summary.PlayersCount},
+		{"playersReadyToRace()", &summary.PlayersReadyToRace},
+		{"winnerHorse()", &summary.WinnerHorse},
+		{"jackpot()", &summary.Jackpot},
+	} {
+		result, err := callRace(ctx, rpc, address, block, getter.signature)
+		if err != nil {
+			return nil, err
+		}
+		if *getter.into, err = result.Uint(0); err != nil {
+			return nil, fmt.Errorf("%s: %w", getter.signature, err)
+		}
+	}
+
+	finished, err := callRace(ctx, rpc, address, block, "raceFinished()")
+	if err != nil {
+		return nil, err
+	}
+	if summary.RaceFinished, err = finished.Bool(0); err != nil {
+		return nil, fmt.Errorf("raceFinished(): %w", err)
+	}
+	return summary, nil
+}
+
+// Reads the current state of the race for the status, at the latest block
+func (r *RacecourseReconciler) readRace(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (*racecoursev1beta1.RaceStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, walletProbeTimeout)
+	defer cancel()
+
+	rpc := r.walletClient(racecourse)
+	blockNumber, err := rpc.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := readRaceSummary(ctx, rpc, contractAddress(racecourse), ethrpc.BlockTag(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	race := &racecoursev1beta1.RaceStatus{RaceFinished: summary.RaceFinished, Jackpot: summary.Jackpot.String()}
+	for _, count := range []struct {
+		name  string
+		value *big.Int
+		into  *int64
+	}{
+		{"horseCount()", summary.HorseCount, &race.HorseCount},
+		{"betCount()", summary.BetCount, &race.BetCount},
+		{"playersCount()", summary.PlayersCount, &race.PlayersCount},
+		{"playersReadyToRace()", summary.PlayersReadyToRace, &race.PlayersReadyToRace},
+		{"winnerHorse()", summary.WinnerHorse, &race.WinnerHorse},
+	} {
+		if !count.value.IsInt64() {
+			return nil, fmt.Errorf("%s: %s is out of range", count.name, count.value)
+		}
+		*count.into = count.value.Int64()
+	}
+	return race, nil
+}
+
+// Whether the race state can be read from the contract: its code has been verified,
+// or verification is skipped and there's an address to read from
+func (v *contractVerification) readable(racecourse *racecoursev1beta1.Racecourse) bool {
+	if v.Status == metav1.ConditionTrue {
+		return true
+	}
+	return racecourse.Annotations[skipContractVerificationAnnotation] == "true" && contractAddress(racecourse) != ""
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+var _ = Describe("Racecourse race status", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		server     *httptest.Server
+		key        types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = fakeRaceServer()
+		DeferCleanup(server.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas:        ptr.To(int32(2)),
+				ContractAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  server.URL,
+				},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().
+			WithObjects(racecourse).WithStatusSubresource(racecourse).Build()
+		reconciler = &RacecourseReconciler{
+			Client:     c,
+			Scheme:     testScheme,
+			Recorder:   record.NewFakeRecorder(100),
+			APIReader:  c,
+			RPCOptions: []ethrpc.Option{ethrpc.WithRetries(0, 0)},
+		}
+	})
+
+	It("should read the contract's getters into the status and poll them", func() {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(raceStatusInterval))
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.Race).To(HaveValue(Equal(racecoursev1beta1.RaceStatus{
+			HorseCount:         2,
+			BetCount:           1,
+			PlayersCount:       1,
+			PlayersReadyToRace: 0,
+			RaceFinished:       false,
+			WinnerHorse:        0,
+			Jackpot:            "250",
+		})))
+	})
+
+	It("should keep the last known state while the wallet can't be reached", func() {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		server.Close()
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.Race).NotTo(BeNil())
+		Expect(racecourse.Status.Race.Jackpot).To(Equal("250"))
+	})
+
+	It("should not read a contract that failed verification", func() {
+		verification := &contractVerification{Status: metav1.ConditionFalse, Reason: racecoursev1beta1.ReasonContractCodeMismatch}
+		Expect(verification.readable(racecourse)).To(BeFalse())
+
+		By("reading it anyway when verification is skipped")
+		racecourse.Annotations = map[string]string{skipContractVerificationAnnotation: "true"}
+		Expect(verification.readable(racecourse)).To(BeTrue())
+		race, err := reconciler.readRace(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(race.Jackpot).To(Equal("250"))
+	})
+})
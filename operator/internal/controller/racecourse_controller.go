@@ -19,33 +19,63 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	networkingv1ac "k8s.io/client-go/applyconfigurations/networking/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
 )
 
 // RacecourseReconciler reconciles a Racecourse object
 type RacecourseReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Reads the nonces other Racecourses have pinned straight from the API server
+	APIReader client.Reader
+
+	// Options for the JSON-RPC clients used to talk to wallets
+	RPCOptions []ethrpc.Option
+
+	// How often to check each Racecourse again when nothing has changed, unless its
+	// spec sets its own interval. Zero only checks again when something changes.
+	ResyncInterval time.Duration
+
+	// Triggers a Racecourse on new blocks that may hold its contract's events
+	Heads *HeadWatcher
+
+	// Held while a contract deployment's nonce is pinned
+	deploymentNonces sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
@@ -54,11 +84,12 @@ type RacecourseReconciler struct {
 func (r *RacecourseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
-	racecourse := &racecoursev1alpha1.Racecourse{}
+	racecourse := &racecoursev1beta1.Racecourse{}
 	if err := r.Get(ctx, req.NamespacedName, racecourse); err != nil {
 		if errors.IsNotFound(err) {
 
 			log.Info("Racecourse resource not found. Ignoring since object must be deleted")
+			r.Heads.Stop(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "Failed to get Racecourse")
@@ -67,7 +98,56 @@ func (r *RacecourseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	log.Info("Reconciling Racecourse", "name", racecourse.Name, "namespace", racecourse.Namespace)
 
-	if err := r.reconcileConfigMap(ctx, racecourse); err != nil {
+	if !racecourse.DeletionTimestamp.IsZero() {
+		r.Heads.Stop(req.NamespacedName)
+		return r.finalize(ctx, racecourse)
+	}
+
+	if controllerutil.AddFinalizer(racecourse, settlementFinalizer) {
+		if err := r.Update(ctx, racecourse); err != nil {
+			log.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	wallet, err := r.checkWallet(ctx, racecourse)
+	if err != nil {
+		log.Error(err, "Failed to check the wallet")
+		return ctrl.Result{}, err
+	}
+
+	checks := &preflight{wallet: wallet, chain: r.verifyChain(ctx, racecourse, wallet)}
+
+	// The contract address goes into the ConfigMap, so settle it first. Nothing is
+	// deployed to, or checked on, a chain other than the pinned one.
+	var contractRequeue time.Duration
+	if wallet.Ready && !checks.chain.mismatched() {
+		contractRequeue, err = r.reconcileContract(ctx, racecourse, wallet)
+		if err != nil {
+			log.Error(err, "Failed to deploy the Race contract")
+			return ctrl.Result{}, err
+		}
+		checks.contract = r.verifyContract(ctx, racecourse, wallet)
+		if checks.contract.readable(racecourse) {
+			// A failed read only leaves the last known race state in place
+			if checks.race, err = r.readRace(ctx, racecourse); err != nil {
+				log.Error(err, "Failed to read the race state")
+			}
+		}
+	} else {
+		checks.contract = &contractVerification{
+			Status:  metav1.ConditionUnknown,
+			Reason:  racecoursev1beta1.ReasonVerificationPending,
+			Message: "the contract can't be verified until the wallet is ready and on the pinned chain",
+		}
+	}
+
+	// Keep the app's config and pods as they are while the chain or contract fails
+	// verification, so pods never start against them
+	refused := checks.refusesRollout(racecourse)
+	if refused {
+		log.Info("Refusing to roll out", "chain", checks.chain.Message, "contract", checks.contract.Message)
+	} else if err := r.reconcileConfigMap(ctx, racecourse); err != nil {
 		log.Error(err, "Failed to reconcile ConfigMap")
 		return ctrl.Result{}, err
 	}
@@ -77,226 +157,342 @@ func (r *RacecourseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileDeployment(ctx, racecourse); err != nil {
-		log.Error(err, "Failed to reconcile Deployment")
-		return ctrl.Result{}, err
+	// Don't start any pods until the wallet works, since the app can't recover from a
+	// wallet it fails to connect to on startup, or until there's a contract for them all
+	// to share. Once rolled out, a wallet outage only shows in the status. A chain or
+	// contract that fails verification holds every rollout, not just the first.
+	rollout := !refused && wallet.Ready && contractAddress(racecourse) != ""
+	if !rollout && !refused {
+		err := r.Get(ctx, types.NamespacedName{Name: racecourse.Name, Namespace: racecourse.Namespace}, &appsv1.Deployment{})
+		if err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		rollout = err == nil
+	}
+	switch {
+	case rollout:
+		if err := r.reconcileDeployment(ctx, racecourse); err != nil {
+			log.Error(err, "Failed to reconcile Deployment")
+			return ctrl.Result{}, err
+		}
+	case !refused:
+		log.Info("Holding the first rollout", "walletReady", wallet.Ready, "reason", wallet.Reason, "message", wallet.Message)
 	}
 
-	if racecourse.Spec.Ingress.Enabled {
+	if racecourse.Spec.Ingress.IsEnabled() {
 		if err := r.reconcileIngress(ctx, racecourse); err != nil {
 			log.Error(err, "Failed to reconcile Ingress")
 			return ctrl.Result{}, err
 		}
 	}
 
-	if err := r.updateStatus(ctx, racecourse); err != nil {
+	pruned, err := r.pruneChildren(ctx, racecourse, desiredChildren(racecourse))
+	if err != nil {
+		log.Error(err, "Failed to prune child resources")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.updateStatus(ctx, racecourse, checks, pruned); err != nil {
 		log.Error(err, "Failed to update status")
 		return ctrl.Result{}, err
 	}
 
 	log.Info("Successfully reconciled Racecourse")
-	return ctrl.Result{}, nil
-}
 
-func (r *RacecourseReconciler) reconcileConfigMap(ctx context.Context, racecourse *racecoursev1alpha1.Racecourse) error {
-	log := log.FromContext(ctx)
+	// Blocks only trigger a read of the race once the contract is readable
+	readable := checks.contract.readable(racecourse)
+	watchedContract := ""
+	if readable {
+		watchedContract = contractAddress(racecourse)
+	}
+	r.Heads.Watch(req.NamespacedName, racecourse.Spec.Wallet.WebSocketURL, watchedContract)
 
-	walletNamespace := racecourse.Spec.WalletService.Namespace
-	if walletNamespace == "" {
-		walletNamespace = racecourse.Namespace
+	if !wallet.Ready && !rollout {
+		return r.requeue(racecourse, walletGateBackoff(racecourse, time.Now())), nil
 	}
-	walletPort := racecourse.Spec.WalletService.Port
-	if walletPort == 0 {
-		walletPort = 8545
+	if checks.chain.mismatched() {
+		return r.requeue(racecourse, chainRecheckInterval), nil
 	}
-
-	walletURL := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d",
-		racecourse.Spec.WalletService.Name,
-		walletNamespace,
-		walletPort,
-	)
-
-	configMap := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      racecourse.Name + "-config",
-			Namespace: racecourse.Namespace,
-		},
-		Data: map[string]string{
-			"signer-url":       walletURL,
-			"contract-address": racecourse.Spec.ContractAddress,
-		},
+	if contractRequeue > 0 {
+		return r.requeue(racecourse, contractRequeue), nil
 	}
 
-	if err := controllerutil.SetControllerReference(racecourse, configMap, r.Scheme); err != nil {
-		return err
+	// Kubernetes isn't notified when bets come in, so poll the contract while it's
+	// readable, unless new blocks are already triggering reads
+	if readable && !r.Heads.Live(req.NamespacedName) {
+		return r.requeue(racecourse, raceStatusInterval), nil
 	}
 
-	found := &corev1.ConfigMap{}
-	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating ConfigMap", "name", configMap.Name)
-		return r.Create(ctx, configMap)
-	} else if err != nil {
-		return err
+	// Pods whose readiness probe keeps failing don't generate any events once they're
+	// running, so check back on them
+	if racecourse.Status.Phase == racecoursev1beta1.RacecoursePhasePending {
+		return r.requeue(racecourse, probeFailureGracePeriod), nil
 	}
+	return r.requeue(racecourse, 0), nil
+}
 
-	if found.Data["signer-url"] != configMap.Data["signer-url"] ||
-		found.Data["contract-address"] != configMap.Data["contract-address"] {
-		log.Info("Updating ConfigMap", "name", configMap.Name)
-		found.Data = configMap.Data
-		return r.Update(ctx, found)
+// Requeues the Racecourse after the delay, or after its resync interval if that's
+// sooner or there's no delay
+func (r *RacecourseReconciler) requeue(racecourse *racecoursev1beta1.Racecourse, after time.Duration) ctrl.Result {
+	resync := r.ResyncInterval
+	if racecourse.Spec.ResyncInterval != nil {
+		resync = racecourse.Spec.ResyncInterval.Duration
 	}
+	if after <= 0 || (resync > 0 && resync < after) {
+		after = resync
+	}
+	return ctrl.Result{RequeueAfter: after}
+}
 
-	return nil
+// The outcome of the checks of the wallet, chain and contract made before rolling out
+type preflight struct {
+	wallet   *walletHealth
+	chain    *chainVerification
+	contract *contractVerification
+
+	// The state of the race, nil if it wasn't read
+	race *racecoursev1beta1.RaceStatus
 }
 
-func (r *RacecourseReconciler) reconcileService(ctx context.Context, racecourse *racecoursev1alpha1.Racecourse) error {
+// Reports whether the checks rule out rolling the app out at all
+func (p *preflight) refusesRollout(racecourse *racecoursev1beta1.Racecourse) bool {
+	return p.chain.mismatched() || p.contract.refusesRollout(racecourse)
+}
+
+func (r *RacecourseReconciler) reconcileConfigMap(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) error {
 	log := log.FromContext(ctx)
 
-	service := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      racecourse.Name,
-			Namespace: racecourse.Namespace,
-			Labels:    labelsForRacecourse(racecourse.Name),
-		},
-		Spec: corev1.ServiceSpec{
-			Type:     corev1.ServiceTypeClusterIP,
-			Selector: labelsForRacecourse(racecourse.Name),
-			Ports: []corev1.ServicePort{
-				{
-					Name:       "http",
-					Protocol:   corev1.ProtocolTCP,
-					Port:       3000,
-					TargetPort: intstr.FromInt(3000),
-				},
-			},
-		},
-	}
-
-	if err := controllerutil.SetControllerReference(racecourse, service, r.Scheme); err != nil {
+	configMap := r.buildConfigMap(racecourse)
+	result, err := applyChild(ctx, r.Client, &corev1.ConfigMap{}, configMap, corev1ac.ExtractConfigMap)
+	r.recordApply(racecourse, "ConfigMap", *configMap.Name, result, err)
+	if err != nil {
 		return err
 	}
-
-	found := &corev1.Service{}
-	err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, found)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating Service", "name", service.Name)
-		return r.Create(ctx, service)
-	} else if err != nil {
-		return err
+	if result != controllerutil.OperationResultNone {
+		log.Info("Applied ConfigMap", "name", *configMap.Name, "result", result)
 	}
-
-	found.Labels = service.Labels
-	found.Spec.Selector = service.Spec.Selector
-	found.Spec.Ports = service.Spec.Ports
-	log.Info("Updating Service", "name", service.Name)
-	return r.Update(ctx, found)
+	return nil
 }
 
-func (r *RacecourseReconciler) reconcileDeployment(ctx context.Context, racecourse *racecoursev1alpha1.Racecourse) error {
+func (r *RacecourseReconciler) reconcileService(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) error {
 	log := log.FromContext(ctx)
 
-	deployment := r.buildDeployment(racecourse)
-
-	if err := controllerutil.SetControllerReference(racecourse, deployment, r.Scheme); err != nil {
+	service := r.buildService(racecourse)
+	result, err := applyChild(ctx, r.Client, &corev1.Service{}, service, corev1ac.ExtractService)
+	r.recordApply(racecourse, "Service", *service.Name, result, err)
+	if err != nil {
 		return err
 	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Applied Service", "name", *service.Name, "result", result)
+	}
+	return nil
+}
+
+func (r *RacecourseReconciler) reconcileDeployment(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) error {
+	log := log.FromContext(ctx)
 
+	// Leave the replica count alone once something else, like an autoscaler, scales the Deployment
 	found := &appsv1.Deployment{}
-	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating Deployment", "name", deployment.Name)
-		return r.Create(ctx, deployment)
-	} else if err != nil {
+	err := r.Get(ctx, types.NamespacedName{Name: racecourse.Name, Namespace: racecourse.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
+	manageReplicas := err != nil || !managedElsewhere(found, "spec", "replicas")
 
-	found.Spec = deployment.Spec
-	log.Info("Updating Deployment", "name", deployment.Name)
-	return r.Update(ctx, found)
+	deployment := r.buildDeployment(racecourse, manageReplicas)
+	result, err := applyChild(ctx, r.Client, found, deployment, appsv1ac.ExtractDeployment)
+	r.recordApply(racecourse, "Deployment", *deployment.Name, result, err)
+	if err != nil {
+		return err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Applied Deployment", "name", *deployment.Name, "result", result, "manageReplicas", manageReplicas)
+	}
+	return nil
 }
 
-func (r *RacecourseReconciler) reconcileIngress(ctx context.Context, racecourse *racecoursev1alpha1.Racecourse) error {
+func (r *RacecourseReconciler) reconcileIngress(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) error {
 	log := log.FromContext(ctx)
 
 	ingress := r.buildIngress(racecourse)
-
-	if err := controllerutil.SetControllerReference(racecourse, ingress, r.Scheme); err != nil {
+	result, err := applyChild(ctx, r.Client, &networkingv1.Ingress{}, ingress, networkingv1ac.ExtractIngress)
+	r.recordApply(racecourse, "Ingress", *ingress.Name, result, err)
+	if err != nil {
 		return err
 	}
-
-	found := &networkingv1.Ingress{}
-	err := r.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating Ingress", "name", ingress.Name)
-		return r.Create(ctx, ingress)
-	} else if err != nil {
-		return err
+	if result != controllerutil.OperationResultNone {
+		log.Info("Applied Ingress", "name", *ingress.Name, "result", result)
 	}
-
-	found.Annotations = ingress.Annotations
-	found.Spec = ingress.Spec
-	log.Info("Updating Ingress", "name", ingress.Name)
-	return r.Update(ctx, found)
+	return nil
 }
 
-func (r *RacecourseReconciler) updateStatus(ctx context.Context, racecourse *racecoursev1alpha1.Racecourse) error {
+func (r *RacecourseReconciler) updateStatus(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, checks *preflight, pruned []racecoursev1beta1.PrunedResource) error {
 	log := log.FromContext(ctx)
+	original := racecourse.Status.DeepCopy()
+
+	recordPruned(racecourse, pruned)
 
 	deployment := &appsv1.Deployment{}
 	err := r.Get(ctx, types.NamespacedName{Name: racecourse.Name, Namespace: racecourse.Namespace}, deployment)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			racecourse.Status.Phase = racecoursev1alpha1.RacecoursePhasePending
-			racecourse.Status.DeploymentReady = false
-			racecourse.Status.AvailableReplicas = 0
-		} else {
+		if !errors.IsNotFound(err) {
 			return err
 		}
+		deployment = nil
+	}
+
+	if deployment == nil {
+		racecourse.Status.DeploymentReady = false
+		racecourse.Status.AvailableReplicas = 0
 	} else {
 		racecourse.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+		racecourse.Status.DeploymentReady = deployment.Status.AvailableReplicas > 0
+	}
+	setDeploymentConditions(racecourse, deployment)
+	switch {
+	case checks.chain.mismatched():
+		// Pods on the wrong chain mustn't count as serving the race
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeAvailable, metav1.ConditionFalse, checks.chain.Reason, checks.chain.Message)
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionFalse, checks.chain.Reason,
+			"refusing to roll out until the wallet is on the pinned chain: "+checks.chain.Message)
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, checks.chain.Reason, checks.chain.Message)
+	case checks.contract.refusesRollout(racecourse):
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionFalse, racecoursev1beta1.ReasonContractNotVerified,
+			fmt.Sprintf("refusing to roll out until the contract is fixed or %s is set: %s", skipContractVerificationAnnotation, checks.contract.Message))
+	case deployment != nil:
+	case !checks.wallet.Ready:
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionFalse, racecoursev1beta1.ReasonWaitingForWallet,
+			"holding the first rollout until the wallet is ready: "+checks.wallet.Message)
+	case contractAddress(racecourse) == "":
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionFalse, racecoursev1beta1.ReasonWaitingForContract,
+			"holding the first rollout until the Race contract is deployed")
+	}
 
-		if deployment.Status.AvailableReplicas > 0 {
-			racecourse.Status.Phase = racecoursev1alpha1.RacecoursePhaseRunning
-			racecourse.Status.DeploymentReady = true
-		} else {
-			racecourse.Status.Phase = racecoursev1alpha1.RacecoursePhasePending
-			racecourse.Status.DeploymentReady = false
+	var failure *podFailure
+	if deployment != nil {
+		pods, err := r.listCurrentPods(ctx, deployment)
+		if err != nil {
+			return err
+		}
+		failure = detectPodFailure(pods, time.Now())
+	}
+	if failure != nil {
+		previous := meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeDegraded)
+		if racecourse.Status.Phase != racecoursev1beta1.RacecoursePhaseFailed || previous == nil || previous.Reason != failure.Reason {
+			r.Recorder.Event(racecourse, corev1.EventTypeWarning, failure.Reason, failure.Message)
 		}
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, failure.Reason, failure.Message)
 	}
 
-	walletNamespace := racecourse.Spec.WalletService.Namespace
-	if walletNamespace == "" {
-		walletNamespace = racecourse.Namespace
+	setWalletCondition(racecourse, checks.wallet)
+	setChainVerifiedCondition(racecourse, checks.chain)
+
+	setContractCondition(racecourse)
+	setContractVerifiedCondition(racecourse, checks.contract)
+
+	if contractAddress(racecourse) == "" {
+		racecourse.Status.Race = nil
+	} else if checks.race != nil {
+		racecourse.Status.Race = checks.race
 	}
-	walletPort := racecourse.Spec.WalletService.Port
-	if walletPort == 0 {
-		walletPort = 8545
+
+	var ingress *networkingv1.Ingress
+	if racecourse.Spec.Ingress.IsEnabled() {
+		ingress = &networkingv1.Ingress{}
+		err := r.Get(ctx, types.NamespacedName{Name: racecourse.Name, Namespace: racecourse.Namespace}, ingress)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			ingress = nil
+		}
 	}
-	racecourse.Status.WalletServiceEndpoint = fmt.Sprintf("http://%s.%s.svc.cluster.local:%d",
-		racecourse.Spec.WalletService.Name,
-		walletNamespace,
-		walletPort,
-	)
+	setIngressCondition(racecourse, ingress)
+
+	if failure != nil || checks.chain.mismatched() {
+		racecourse.Status.Phase = racecoursev1beta1.RacecoursePhaseFailed
+	} else if meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeAvailable) {
+		racecourse.Status.Phase = racecoursev1beta1.RacecoursePhaseRunning
+	} else {
+		racecourse.Status.Phase = racecoursev1beta1.RacecoursePhasePending
+	}
+
+	racecourse.Status.WalletServiceEndpoint = walletEndpointURL(racecourse)
+
+	racecourse.Status.URL = ""
+	if racecourse.Spec.Ingress.IsEnabled() {
+		scheme := "http"
+		if racecourse.Spec.Ingress.TLS != nil {
+			scheme = "https"
+		}
+		racecourse.Status.URL = fmt.Sprintf("%s://%s", scheme, racecourse.Spec.Ingress.HostOrDefault())
+	}
+
+	racecourse.Status.ObservedGeneration = racecourse.Generation
 
-	if racecourse.Spec.Ingress.Enabled && racecourse.Spec.Ingress.Host != "" {
-		racecourse.Status.URL = fmt.Sprintf("http://%s", racecourse.Spec.Ingress.Host)
+	if equality.Semantic.DeepEqual(original, &racecourse.Status) {
+		return nil
 	}
+	r.recordStatusChanges(racecourse, original)
 
 	log.Info("Updating status", "phase", racecourse.Status.Phase, "replicas", racecourse.Status.AvailableReplicas)
 	return r.Status().Update(ctx, racecourse)
 }
 
 func (r *RacecourseReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&racecoursev1alpha1.Racecourse{}).
-		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{}).
-		Owns(&corev1.ConfigMap{}).
-		Owns(&networkingv1.Ingress{}).
+	builder := ctrl.NewControllerManagedBy(mgr).
+		For(&racecoursev1beta1.Racecourse{})
+	for _, kind := range childKinds {
+		builder = builder.Owns(kind.New())
+	}
+	if r.Heads != nil {
+		builder = builder.WatchesRawSource(r.Heads.Source())
+	}
+	return builder.
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(racecourseForPod)).
 		Complete(r)
 }
 
+// Maps a racecourse pod to the Racecourse that runs it, so pod failures are noticed
+// without waiting for the Deployment status to change
+func racecourseForPod(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels["app"] != "racecourse" || labels["racecourse"] == "" {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: labels["racecourse"], Namespace: obj.GetNamespace()},
+	}}
+}
+
+// Get the JSON-RPC URL of the wallet, resolving a Service to its in-cluster address
+func walletEndpointURL(racecourse *racecoursev1beta1.Racecourse) string {
+	wallet := racecourse.Spec.Wallet
+	if wallet.Type == racecoursev1beta1.WalletEndpointTypeURL || wallet.Service == nil {
+		return wallet.URL
+	}
+	port := wallet.Service.Port
+	if port == 0 {
+		port = racecoursev1beta1.DefaultWalletServicePort
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d",
+		wallet.Service.Name,
+		walletServiceNamespace(racecourse),
+		port,
+	)
+}
+
+// Get the namespace of the wallet Service. The defaulting webhook fills it in, but
+// objects stored before it ran, or with webhooks disabled, leave it empty until the
+// storage migration saves them again.
+func walletServiceNamespace(racecourse *racecoursev1beta1.Racecourse) string {
+	if racecourse.Spec.Wallet.Service == nil || racecourse.Spec.Wallet.Service.Namespace == "" {
+		return racecourse.Namespace
+	}
+	return racecourse.Spec.Wallet.Service.Namespace
+}
+
 // Get labels for selecting Racecourse resources
 func labelsForRacecourse(name string) map[string]string {
 	return map[string]string{
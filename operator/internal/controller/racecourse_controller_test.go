@@ -21,13 +21,18 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
 )
 
 var _ = Describe("Racecourse Controller", func() {
@@ -40,18 +45,40 @@ var _ = Describe("Racecourse Controller", func() {
 			Name:      resourceName,
 			Namespace: "default", // TODO(user):Modify as needed
 		}
-		racecourse := &racecoursev1alpha1.Racecourse{}
+		racecourse := &racecoursev1beta1.Racecourse{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind Racecourse")
 			err := k8sClient.Get(ctx, typeNamespacedName, racecourse)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &racecoursev1alpha1.Racecourse{
+				resource := &racecoursev1beta1.Racecourse{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					// The defaulting webhook isn't running here, so spell out what it would store
+					Spec: racecoursev1beta1.RacecourseSpec{
+						Replicas: ptr.To(racecoursev1beta1.DefaultReplicas),
+						Image: racecoursev1beta1.ImageSpec{
+							Repository: racecoursev1beta1.DefaultImageRepository,
+							Tag:        racecoursev1beta1.DefaultImageTag,
+							PullPolicy: racecoursev1beta1.DefaultImagePullPolicy,
+						},
+						Wallet: racecoursev1beta1.WalletEndpoint{
+							Type: racecoursev1beta1.WalletEndpointTypeService,
+							Service: &racecoursev1beta1.WalletServiceRef{
+								Name:      "firefly-signer",
+								Namespace: "default",
+								Port:      racecoursev1beta1.DefaultWalletServicePort,
+							},
+						},
+						Ingress: racecoursev1beta1.IngressSpec{
+							Enabled:   ptr.To(true),
+							ClassName: racecoursev1beta1.DefaultIngressClassName,
+							Host:      racecoursev1beta1.DefaultIngressHost,
+							Path:      racecoursev1beta1.DefaultIngressPath,
+						},
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -59,26 +86,75 @@ var _ = Describe("Racecourse Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &racecoursev1alpha1.Racecourse{}
+			resource := &racecoursev1beta1.Racecourse{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Cleanup the specific resource instance Racecourse")
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Reconciling until the finalizer lets the deletion complete")
+			controllerReconciler := &RacecourseReconciler{
+				Client:    k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				Recorder:  record.NewFakeRecorder(10),
+				APIReader: k8sClient,
+			}
+			Eventually(func(g Gomega) {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &racecoursev1beta1.Racecourse{}))).To(BeTrue())
+			}).Should(Succeed())
+
+			By("checking the settlement snapshot outlives the Racecourse")
+			settlement := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: settlementName(resource), Namespace: "default"}, settlement)).To(Succeed())
+			Expect(settlement.OwnerReferences).To(BeEmpty())
+			Expect(settlement.Data).To(HaveKey("settlement.json"))
+			Expect(k8sClient.Delete(ctx, settlement)).To(Succeed())
 		})
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &RacecourseReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:    k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				Recorder:  record.NewFakeRecorder(10),
+				APIReader: k8sClient,
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			By("checking the finalizer was added")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, racecourse)).To(Succeed())
+			Expect(racecourse.Finalizers).To(ContainElement(settlementFinalizer))
+
+			By("checking the status conditions")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, racecourse)).To(Succeed())
+			Expect(racecourse.Status.ObservedGeneration).To(Equal(racecourse.Generation))
+			Expect(racecourse.Status.Phase).To(Equal(racecoursev1beta1.RacecoursePhasePending))
+			for _, conditionType := range []string{
+				racecoursev1beta1.ConditionTypeAvailable,
+				racecoursev1beta1.ConditionTypeProgressing,
+				racecoursev1beta1.ConditionTypeDegraded,
+				racecoursev1beta1.ConditionTypeWalletReachable,
+				racecoursev1beta1.ConditionTypeChainVerified,
+				racecoursev1beta1.ConditionTypeContractReady,
+				racecoursev1beta1.ConditionTypeContractVerified,
+				racecoursev1beta1.ConditionTypeIngressReady,
+			} {
+				condition := meta.FindStatusCondition(racecourse.Status.Conditions, conditionType)
+				Expect(condition).NotTo(BeNil(), conditionType)
+				Expect(condition.ObservedGeneration).To(Equal(racecourse.Generation))
+			}
+			Expect(meta.IsStatusConditionFalse(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeAvailable)).To(BeTrue())
+
+			By("holding the first rollout while the wallet Service is missing")
+			Expect(meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeWalletReachable).Reason).
+				To(Equal(racecoursev1beta1.ReasonWalletServiceNotFound))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &appsv1.Deployment{}))).To(BeTrue())
 		})
 	})
 })
@@ -0,0 +1,517 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How often the indexer checks a caught up contract for new events
+const roundIndexInterval = 15 * time.Second
+
+// The most blocks asked for in one eth_getLogs call, and the most calls made in one
+// reconcile before handing over to other Racecourses
+const (
+	maxLogBlockRange       = 5000
+	maxLogRangesPerIndex   = 10
+	roundIndexCatchUpDelay = time.Second
+)
+
+// The events the Race contract emits, none of which carry any data
+var (
+	topicBetPlaced                 = ethrpc.EventTopic("betPlaced()")
+	topicPlayersReadyToRaceChanged = ethrpc.EventTopic("playersReadyToRaceChanged()")
+	topicFinishedRace              = ethrpc.EventTopic("finishedRace()")
+)
+
+// Set to "true" on a Racecourse to index its current contract again from block 0,
+// replacing its rounds and leaderboard. The indexer removes it once it has started over.
+const reindexRoundsAnnotation = "racecourse.kaleido.io/reindex-rounds"
+
+// The reasons of the events recorded by the indexer
+const (
+	eventReasonRaceFinished = "RaceFinished"
+	eventReasonReindexing   = "Reindexing"
+)
+
+// RaceRoundReconciler indexes the events of each Racecourse's Race contract into
+// RaceRounds owned by the Racecourse
+type RaceRoundReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Reads rounds straight from the API server, since the cache may not have seen
+	// the round the last reconcile created yet
+	APIReader client.Reader
+
+	// Options for the JSON-RPC clients used to talk to wallets
+	RPCOptions []ethrpc.Option
+
+	// Triggers indexing on new blocks that may hold the contract's events
+	Heads *HeadWatcher
+}
+
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racecourses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racerounds,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=racecourse.kaleido.io,resources=racerounds/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *RaceRoundReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	racecourse := &racecoursev1beta1.Racecourse{}
+	if err := r.Get(ctx, req.NamespacedName, racecourse); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !racecourse.DeletionTimestamp.IsZero() || !roundsIndexable(racecourse) {
+		return ctrl.Result{}, nil
+	}
+
+	address := contractAddress(racecourse)
+	if racecourse.Annotations[reindexRoundsAnnotation] == "true" {
+		if err := r.resetRounds(ctx, racecourse, address); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	index := racecourse.Status.RoundIndex.DeepCopy()
+	if index == nil || !strings.EqualFold(index.ContractAddress, address) {
+		index = &racecoursev1beta1.RaceRoundIndex{ContractAddress: address, Block: -1}
+	}
+
+	rpc := ethrpc.New(walletEndpointURL(racecourse), r.RPCOptions...)
+	latest, err := rpc.BlockNumber(ctx)
+	if err != nil {
+		log.Error(err, "Failed to read the latest block, retrying")
+		return ctrl.Result{RequeueAfter: roundIndexInterval}, nil
+	}
+
+	// The leaderboard only changes when a race is run
+	var finished bool
+	for range maxLogRangesPerIndex {
+		if index.Block >= int64(latest) {
+			break
+		}
+		from := uint64(index.Block + 1)
+		to := min(from+maxLogBlockRange-1, latest)
+		logs, err := rpc.GetLogs(ctx, ethrpc.FilterQuery{
+			FromBlock: from,
+			ToBlock:   to,
+			Address:   address,
+			Topics:    []string{topicBetPlaced, topicPlayersReadyToRaceChanged, topicFinishedRace},
+		})
+		if err != nil {
+			log.Error(err, "Failed to read the contract's events, retrying", "from", from, "to", to)
+			return ctrl.Result{RequeueAfter: roundIndexInterval}, nil
+		}
+
+		finishedNow, err := r.indexLogs(ctx, racecourse, rpc, index, logs)
+		if err != nil {
+			log.Error(err, "Failed to index the contract's events")
+			return ctrl.Result{}, err
+		}
+		finished = finished || finishedNow
+
+		// The rounds are written before the cursor moves, so a failure in between only
+		// means indexing the same blocks again, which the rounds skip
+		index.Block = int64(to)
+		patch := client.MergeFrom(racecourse.DeepCopy())
+		racecourse.Status.RoundIndex = index.DeepCopy()
+		if err := r.Status().Patch(ctx, racecourse, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.reconcileLeaderboard(ctx, racecourse, finished); err != nil {
+		log.Error(err, "Failed to write the leaderboard")
+		return ctrl.Result{}, err
+	}
+
+	if index.Block < int64(latest) {
+		return ctrl.Result{RequeueAfter: roundIndexCatchUpDelay}, nil
+	}
+	// New blocks with the contract's events trigger indexing while the Racecourse's
+	// subscription is up, and it's triggered again if the subscription goes down
+	if r.Heads.Live(req.NamespacedName) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: roundIndexInterval}, nil
+}
+
+// Deletes the rounds of the contract and the cursor, so indexing starts over from
+// block 0, and then removes the annotation that asked for it
+func (r *RaceRoundReconciler) resetRounds(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, address string) error {
+	rounds, err := listRounds(ctx, r.Client, racecourse)
+	if err != nil {
+		return err
+	}
+	for _, round := range rounds {
+		if !strings.EqualFold(round.Spec.ContractAddress, address) {
+			continue
+		}
+		if err := r.Delete(ctx, &round); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	statusPatch := client.MergeFrom(racecourse.DeepCopy())
+	racecourse.Status.RoundIndex = nil
+	if err := r.Status().Patch(ctx, racecourse, statusPatch); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(racecourse.DeepCopy())
+	delete(racecourse.Annotations, reindexRoundsAnnotation)
+	if err := r.Patch(ctx, racecourse, patch); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonReindexing, "Indexing the rounds of contract %s again from block 0", address)
+	return nil
+}
+
+// Whether the Racecourse's contract can be indexed: it has an address, and the code
+// there has been verified or verification is skipped
+func roundsIndexable(racecourse *racecoursev1beta1.Racecourse) bool {
+	if contractAddress(racecourse) == "" {
+		return false
+	}
+	return meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeContractVerified) ||
+		racecourse.Annotations[skipContractVerificationAnnotation] == "true"
+}
+
+// Applies a range of the contract's events to its rounds, writes the rounds that
+// changed, and reports whether any race was run. A round skips events from blocks
+// it had already indexed, so indexing the same blocks again changes nothing.
+func (r *RaceRoundReconciler) indexLogs(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, rpc *ethrpc.Client, index *racecoursev1beta1.RaceRoundIndex, logs []ethrpc.Log) (bool, error) {
+	var round *racecoursev1beta1.RaceRound
+	// The last block each round had indexed before this range
+	indexedBefore := map[*racecoursev1beta1.RaceRound]int64{}
+	if index.Rounds > 0 {
+		var err error
+		if round, err = r.getRound(ctx, racecourse, index.ContractAddress, index.Rounds); err != nil {
+			return false, err
+		}
+		if round == nil {
+			// The round has been deleted, so its events go into a new one with the same number
+			round = newRound(racecourse, index.ContractAddress, index.Rounds, uint64(index.Block+1))
+			round.Status.Message = "the round was indexed from part way through"
+		}
+		indexedBefore[round] = round.Status.LastIndexedBlock
+	}
+
+	var changed, finished []*racecoursev1beta1.RaceRound
+	for i, entry := range logs {
+		if len(entry.Topics) == 0 {
+			continue
+		}
+
+		// The first event starts round 1, and a bet after the race has been run starts
+		// the next round
+		if round == nil || (entry.Topics[0] == topicBetPlaced && round.Status.Finished) {
+			index.Rounds++
+			next, err := r.getRound(ctx, racecourse, index.ContractAddress, index.Rounds)
+			if err != nil {
+				return false, err
+			}
+			if next == nil {
+				next = newRound(racecourse, index.ContractAddress, index.Rounds, entry.BlockNumber)
+				if entry.Topics[0] != topicBetPlaced {
+					next.Status.Message = "the round was indexed from part way through"
+				}
+			}
+			round = next
+			indexedBefore[round] = round.Status.LastIndexedBlock
+		}
+		if int64(entry.BlockNumber) <= indexedBefore[round] {
+			continue
+		}
+
+		if err := applyLog(ctx, rpc, round, entry, betsLaterInBlock(logs, i)); err != nil {
+			return false, err
+		}
+		round.Status.LastIndexedBlock = int64(entry.BlockNumber)
+		if len(changed) == 0 || changed[len(changed)-1] != round {
+			changed = append(changed, round)
+		}
+		if entry.Topics[0] == topicFinishedRace {
+			finished = append(finished, round)
+		}
+	}
+
+	for _, round := range changed {
+		if err := r.writeRound(ctx, round); err != nil {
+			return false, err
+		}
+	}
+	for _, round := range finished {
+		r.recordFinished(racecourse, round)
+	}
+	return len(finished) > 0, nil
+}
+
+// Applies one of the contract's events to the round, reading what the event doesn't
+// carry from the contract's state at the event's block. laterBets is how many of the
+// round's bets were placed after the event in the same block, or -1 if the state at the
+// end of the block is a later round's.
+func applyLog(ctx context.Context, rpc *ethrpc.Client, round *racecoursev1beta1.RaceRound, entry ethrpc.Log, laterBets int) error {
+	block := ethrpc.BlockTag(entry.BlockNumber)
+	address := round.Spec.ContractAddress
+
+	switch entry.Topics[0] {
+	case topicBetPlaced:
+		bet := racecoursev1beta1.RaceBet{Block: int64(entry.BlockNumber), TransactionHash: entry.TransactionHash}
+		if laterBets < 0 {
+			round.Status.Message = fmt.Sprintf("the next round started in block %d, so the bets placed in it could not be read", entry.BlockNumber)
+			round.Status.Bets = append(round.Status.Bets, bet)
+			break
+		}
+		err := readBet(ctx, rpc, address, block, laterBets, &bet)
+		if err == nil {
+			round.Status.Jackpot, err = readJackpot(ctx, rpc, address, block)
+		}
+		if err != nil && !stateUnavailable(err, round, entry.BlockNumber) {
+			return err
+		}
+		round.Status.Bets = append(round.Status.Bets, bet)
+		if bet.Player != "" {
+			round.Status.Players = append(round.Status.Players, bet.Player)
+		}
+
+	case topicPlayersReadyToRaceChanged:
+		round.Status.PlayersReadyToRace++
+
+	case topicFinishedRace:
+		round.Status.Finished = true
+		round.Status.FinishedBlock = int64(entry.BlockNumber)
+
+		summary, err := readRaceSummary(ctx, rpc, address, block)
+		switch {
+		case err != nil:
+			if !stateUnavailable(err, round, entry.BlockNumber) {
+				return err
+			}
+		case !summary.RaceFinished:
+			// The contract resets itself when the next bet comes in, and that happened
+			// in the same block
+			round.Status.Message = fmt.Sprintf("the next round started in block %d, so the winner and jackpot could not be read", entry.BlockNumber)
+		default:
+			winner := summary.WinnerHorse.Int64()
+			round.Status.WinnerHorse = &winner
+			round.Status.Jackpot = summary.Jackpot.String()
+		}
+	}
+	return nil
+}
+
+// Reads a bet from the contract at a block, given how many bets were placed after it in
+// the block. Its place in the contract's bets comes from the count at the end of the block
+// rather than from the bets the round has recorded, which miss the earlier ones when the
+// round was indexed from part way through.
+func readBet(ctx context.Context, rpc *ethrpc.Client, address, block string, laterBets int, bet *racecoursev1beta1.RaceBet) error {
+	count, err := callRace(ctx, rpc, address, block, "betCount()")
+	if err != nil {
+		return err
+	}
+	betCount, err := count.Uint(0)
+	if err != nil {
+		return fmt.Errorf("betCount(): %w", err)
+	}
+	index := betCount.Int64() - int64(laterBets) - 1
+	if index < 0 {
+		return fmt.Errorf("betCount() is %s, fewer than the %d bets placed in block %s", betCount, laterBets+1, block)
+	}
+
+	result, err := callRace(ctx, rpc, address, block, "bets(uint256)", big.NewInt(index))
+	if err != nil {
+		return err
+	}
+	horse, err := result.Uint(0)
+	if err != nil {
+		return fmt.Errorf("bets(%d): %w", index, err)
+	}
+	player, err := result.Address(1)
+	if err != nil {
+		return fmt.Errorf("bets(%d): %w", index, err)
+	}
+	amount, err := result.Uint(2)
+	if err != nil {
+		return fmt.Errorf("bets(%d): %w", index, err)
+	}
+	bet.Horse, bet.Player, bet.Amount = horse.Int64(), player, amount.String()
+	return nil
+}
+
+// Counts the bets placed after the log at i in the same block, or returns -1 if the race
+// is run and a bet starts the next round later in the block, since the contract's state
+// at the end of the block is then the next round's
+func betsLaterInBlock(logs []ethrpc.Log, i int) int {
+	later, finished := 0, false
+	for _, entry := range logs[i+1:] {
+		if entry.BlockNumber != logs[i].BlockNumber {
+			break
+		}
+		switch {
+		case len(entry.Topics) == 0:
+		case entry.Topics[0] == topicFinishedRace:
+			finished = true
+		case entry.Topics[0] == topicBetPlaced && finished:
+			return -1
+		case entry.Topics[0] == topicBetPlaced:
+			later++
+		}
+	}
+	return later
+}
+
+// Reads the jackpot from the contract at a block
+func readJackpot(ctx context.Context, rpc *ethrpc.Client, address, block string) (string, error) {
+	result, err := callRace(ctx, rpc, address, block, "jackpot()")
+	if err != nil {
+		return "", err
+	}
+	jackpot, err := result.Uint(0)
+	if err != nil {
+		return "", fmt.Errorf("jackpot(): %w", err)
+	}
+	return jackpot.String(), nil
+}
+
+// Notes on the round that the contract state at a block couldn't be read, when the node
+// answered with an error rather than not answering at all. Nodes that don't keep
+// historical state, like Besu with Bonsai storage, refuse calls against old blocks,
+// and retrying won't change that.
+func stateUnavailable(err error, round *racecoursev1beta1.RaceRound, block uint64) bool {
+	rpcErr := &ethrpc.RPCError{}
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	round.Status.Message = fmt.Sprintf("the contract state at block %d is not available from the node: %s", block, rpcErr.Message)
+	return true
+}
+
+// The name of a round of a Racecourse's contract, which includes the start of the
+// contract address so rounds of an earlier contract are kept
+func roundName(racecourse *racecoursev1beta1.Racecourse, address string, round int64) string {
+	prefix := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	return fmt.Sprintf("%s-%s-%d", racecourse.Name, prefix, round)
+}
+
+// Returns a round of the Racecourse's contract, or nil if it hasn't been created
+func (r *RaceRoundReconciler) getRound(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, address string, number int64) (*racecoursev1beta1.RaceRound, error) {
+	round := &racecoursev1beta1.RaceRound{}
+	err := r.APIReader.Get(ctx, types.NamespacedName{Name: roundName(racecourse, address, number), Namespace: racecourse.Namespace}, round)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return round, nil
+}
+
+// Builds a round that hasn't been created yet
+func newRound(racecourse *racecoursev1beta1.Racecourse, address string, number int64, startBlock uint64) *racecoursev1beta1.RaceRound {
+	return &racecoursev1beta1.RaceRound{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      roundName(racecourse, address, number),
+			Namespace: racecourse.Namespace,
+			Labels:    labelsForRacecourse(racecourse.Name),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(racecourse, racecoursev1beta1.GroupVersion.WithKind("Racecourse")),
+			},
+		},
+		Spec: racecoursev1beta1.RaceRoundSpec{
+			Racecourse:      racecourse.Name,
+			ContractAddress: address,
+			Round:           number,
+		},
+		Status: racecoursev1beta1.RaceRoundStatus{StartBlock: int64(startBlock), LastIndexedBlock: -1},
+	}
+}
+
+// Creates the round if it's new, then writes its status
+func (r *RaceRoundReconciler) writeRound(ctx context.Context, round *racecoursev1beta1.RaceRound) error {
+	if round.ResourceVersion == "" {
+		status := round.Status
+		if err := r.Create(ctx, round); err != nil {
+			return err
+		}
+		round.Status = status
+	}
+	return r.Status().Update(ctx, round)
+}
+
+// Records an event on the Racecourse for a race that has been run
+func (r *RaceRoundReconciler) recordFinished(racecourse *racecoursev1beta1.Racecourse, round *racecoursev1beta1.RaceRound) {
+	if round.Status.WinnerHorse == nil {
+		r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonRaceFinished, "Round %d was run in block %d", round.Spec.Round, round.Status.FinishedBlock)
+		return
+	}
+	r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonRaceFinished, "Round %d was run in block %d: horse %d won a jackpot of %s",
+		round.Spec.Round, round.Status.FinishedBlock, *round.Status.WinnerHorse, round.Status.Jackpot)
+}
+
+// Only reconciles a Racecourse when something the indexer depends on changes, and not
+// on its own cursor updates; the rest is driven by polling
+var roundIndexInputsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		old, okOld := e.ObjectOld.(*racecoursev1beta1.Racecourse)
+		updated, okNew := e.ObjectNew.(*racecoursev1beta1.Racecourse)
+		if !okOld || !okNew {
+			return true
+		}
+		return contractAddress(old) != contractAddress(updated) || roundsIndexable(old) != roundsIndexable(updated) ||
+			walletEndpointURL(old) != walletEndpointURL(updated) ||
+			old.Annotations[reindexRoundsAnnotation] != updated.Annotations[reindexRoundsAnnotation]
+	},
+}
+
+func (r *RaceRoundReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller := ctrl.NewControllerManagedBy(mgr).
+		Named("raceround").
+		For(&racecoursev1beta1.Racecourse{}, builder.WithPredicates(roundIndexInputsChanged))
+	if r.Heads != nil {
+		controller = controller.WatchesRawSource(r.Heads.Source())
+	}
+	return controller.Complete(r)
+}
@@ -0,0 +1,476 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// A block of a fake chain running a Race contract: the events emitted in it and the
+// contract state at its end
+type fakeRaceBlock struct {
+	events   []string
+	bets     []fakeRaceBet
+	finished bool
+	winner   int64
+	jackpot  int64
+}
+
+type fakeRaceBet struct {
+	horse  int64
+	player string
+	amount int64
+}
+
+// A chain where two players bet on a race that horse 2 wins, and a third bet then
+// starts the next round
+type fakeRaceChain struct {
+	latest uint64
+	blocks map[uint64]*fakeRaceBlock
+	// Calls against the state of earlier blocks fail, like on a node that prunes it
+	prunedBefore uint64
+}
+
+const (
+	fakePlayerA = "0x1111111111111111111111111111111111111111"
+	fakePlayerB = "0x2222222222222222222222222222222222222222"
+)
+
+func newFakeRaceChain() *fakeRaceChain {
+	betA := fakeRaceBet{horse: 1, player: fakePlayerA, amount: 100}
+	betB := fakeRaceBet{horse: 2, player: fakePlayerB, amount: 150}
+	return &fakeRaceChain{
+		latest: 10,
+		blocks: map[uint64]*fakeRaceBlock{
+			3: {events: []string{topicBetPlaced}, bets: []fakeRaceBet{betA}, jackpot: 100},
+			5: {events: []string{topicBetPlaced}, bets: []fakeRaceBet{betA, betB}, jackpot: 250},
+			6: {events: []string{topicPlayersReadyToRaceChanged}, bets: []fakeRaceBet{betA, betB}, jackpot: 250},
+			7: {
+				events:   []string{topicPlayersReadyToRaceChanged, topicFinishedRace},
+				bets:     []fakeRaceBet{betA, betB},
+				finished: true, winner: 2, jackpot: 250,
+			},
+			9: {events: []string{topicBetPlaced}, bets: []fakeRaceBet{{horse: 0, player: fakePlayerA, amount: 50}}, jackpot: 50},
+		},
+	}
+}
+
+// The contract state at the end of a block
+func (c *fakeRaceChain) stateAt(block uint64) *fakeRaceBlock {
+	for number := block; number > 0; number-- {
+		if state, ok := c.blocks[number]; ok {
+			return state
+		}
+	}
+	return &fakeRaceBlock{}
+}
+
+// Serves the events and state of the chain
+func fakeRaceChainServer(chain *fakeRaceChain) *httptest.Server {
+	word := func(value int64) string {
+		return hex.EncodeToString(ethrpc.EncodeUint(big.NewInt(value)))
+	}
+	selector := func(signature string) string {
+		return hex.EncodeToString(ethrpc.Selector(signature))
+	}
+	quantity := func(raw json.RawMessage) uint64 {
+		var tag string
+		Expect(json.Unmarshal(raw, &tag)).To(Succeed())
+		value, err := ethrpc.DecodeQuantity(tag)
+		Expect(err).NotTo(HaveOccurred())
+		return value.Uint64()
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		Expect(json.NewDecoder(r.Body).Decode(request)).To(Succeed())
+
+		response := map[string]any{"jsonrpc": "2.0", "id": request.ID}
+		switch request.Method {
+		case "eth_blockNumber":
+			response["result"] = ethrpc.BlockTag(chain.latest)
+		case "eth_getLogs":
+			filter := map[string]json.RawMessage{}
+			Expect(json.Unmarshal(request.Params[0], &filter)).To(Succeed())
+			logs := []map[string]any{}
+			for number := quantity(filter["fromBlock"]); number <= quantity(filter["toBlock"]); number++ {
+				for i, topic := range chain.stateAt(number).events {
+					if chain.blocks[number] == nil {
+						break
+					}
+					logs = append(logs, map[string]any{
+						"address":         "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+						"topics":          []string{topic},
+						"data":            "0x",
+						"blockNumber":     ethrpc.BlockTag(number),
+						"transactionHash": fmt.Sprintf("0x%064x", number*10+uint64(i)),
+						"logIndex":        ethrpc.BlockTag(uint64(i)),
+					})
+				}
+			}
+			response["result"] = logs
+		case "eth_call":
+			block := quantity(request.Params[1])
+			if block < chain.prunedBefore {
+				response["error"] = map[string]any{"code": -32000, "message": "missing trie node"}
+				break
+			}
+			call := map[string]string{}
+			Expect(json.Unmarshal(request.Params[0], &call)).To(Succeed())
+			data := strings.TrimPrefix(call["data"], "0x")
+
+			state := chain.stateAt(block)
+			finished := int64(0)
+			if state.finished {
+				finished = 1
+			}
+			results := map[string]string{
+				selector("horseCount()"):         word(5),
+				selector("betCount()"):           word(int64(len(state.bets))),
+				selector("playersCount()"):       word(int64(len(state.bets))),
+				selector("playersReadyToRace()"): word(0),
+				selector("winnerHorse()"):        word(state.winner),
+				selector("jackpot()"):            word(state.jackpot),
+				selector("raceFinished()"):       word(finished),
+			}
+			for i, bet := range state.bets {
+				results[selector("bets(uint256)")+word(int64(i))] = word(bet.horse) +
+					"000000000000000000000000" + strings.TrimPrefix(bet.player, "0x") + word(bet.amount) + word(0)
+			}
+			response["result"] = "0x" + results[data]
+		}
+		Expect(json.NewEncoder(w).Encode(response)).To(Succeed())
+	}))
+}
+
+var _ = Describe("RaceRound indexer", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RaceRoundReconciler
+		recorder   *record.FakeRecorder
+		racecourse *racecoursev1beta1.Racecourse
+		chain      *fakeRaceChain
+		key        types.NamespacedName
+	)
+
+	const address = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	// Returns the rounds indexed so far, by round number
+	listRounds := func() map[int64]racecoursev1beta1.RaceRound {
+		rounds := &racecoursev1beta1.RaceRoundList{}
+		Expect(c.List(ctx, rounds, client.InNamespace("default"))).To(Succeed())
+		byNumber := map[int64]racecoursev1beta1.RaceRound{}
+		for _, round := range rounds.Items {
+			byNumber[round.Spec.Round] = round
+		}
+		return byNumber
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		chain = newFakeRaceChain()
+		server := fakeRaceChainServer(chain)
+		DeferCleanup(server.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas:        ptr.To(int32(2)),
+				ContractAddress: address,
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  server.URL,
+				},
+			},
+			Status: racecoursev1beta1.RacecourseStatus{
+				Conditions: []metav1.Condition{{
+					Type:               racecoursev1beta1.ConditionTypeContractVerified,
+					Status:             metav1.ConditionTrue,
+					Reason:             racecoursev1beta1.ReasonContractCodeMatches,
+					LastTransitionTime: metav1.Now(),
+				}},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).
+			WithObjects(racecourse).WithStatusSubresource(racecourse, &racecoursev1beta1.RaceRound{}).Build()
+		recorder = record.NewFakeRecorder(100)
+		reconciler = &RaceRoundReconciler{
+			Client:     c,
+			Scheme:     testScheme,
+			Recorder:   recorder,
+			APIReader:  c,
+			RPCOptions: []ethrpc.Option{ethrpc.WithRetries(0, 0)},
+		}
+	})
+
+	It("should record each round from the contract's events", func() {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(roundIndexInterval))
+
+		rounds := listRounds()
+		Expect(rounds).To(HaveLen(2))
+
+		first := rounds[1]
+		Expect(first.Name).To(Equal("racecourse-5aaeb605-1"))
+		Expect(first.OwnerReferences).To(ConsistOf(HaveField("UID", types.UID("1234"))))
+		Expect(first.Spec).To(Equal(racecoursev1beta1.RaceRoundSpec{Racecourse: "racecourse", ContractAddress: address, Round: 1}))
+		Expect(first.Status).To(Equal(racecoursev1beta1.RaceRoundStatus{
+			StartBlock:       3,
+			FinishedBlock:    7,
+			LastIndexedBlock: 7,
+			Bets: []racecoursev1beta1.RaceBet{
+				{Horse: 1, Player: fakePlayerA, Amount: "100", Block: 3, TransactionHash: fmt.Sprintf("0x%064x", 30)},
+				{Horse: 2, Player: fakePlayerB, Amount: "150", Block: 5, TransactionHash: fmt.Sprintf("0x%064x", 50)},
+			},
+			Players:            []string{fakePlayerA, fakePlayerB},
+			PlayersReadyToRace: 2,
+			Finished:           true,
+			WinnerHorse:        ptr.To(int64(2)),
+			Jackpot:            "250",
+		}))
+
+		second := rounds[2]
+		Expect(second.Status.StartBlock).To(Equal(int64(9)))
+		Expect(second.Status.Finished).To(BeFalse())
+		Expect(second.Status.WinnerHorse).To(BeNil())
+		Expect(second.Status.Players).To(Equal([]string{fakePlayerA}))
+		Expect(second.Status.Jackpot).To(Equal("50"))
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.RoundIndex).To(Equal(&racecoursev1beta1.RaceRoundIndex{ContractAddress: address, Block: 10, Rounds: 2}))
+		Expect(recorder.Events).To(Receive(Equal("Normal RaceFinished Round 1 was run in block 7: horse 2 won a jackpot of 250")))
+	})
+
+	It("should not record events twice when blocks are indexed again", func() {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		indexed := listRounds()
+
+		By("rewinding the cursor as if it hadn't been saved")
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		racecourse.Status.RoundIndex = &racecoursev1beta1.RaceRoundIndex{ContractAddress: address, Block: 4, Rounds: 1}
+		Expect(c.Status().Update(ctx, racecourse)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		rounds := listRounds()
+		Expect(rounds).To(HaveLen(2))
+		for number, round := range rounds {
+			Expect(round.Status).To(Equal(indexed[number].Status))
+		}
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.RoundIndex.Rounds).To(Equal(int64(2)))
+	})
+
+	It("should pick up new events from where it left off", func() {
+		chain.latest = 8
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listRounds()).To(HaveLen(1))
+
+		chain.latest = 10
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		rounds := listRounds()
+		Expect(rounds).To(HaveLen(2))
+		Expect(rounds[1].Status.Bets).To(HaveLen(2))
+		Expect(rounds[2].Status.Bets).To(HaveLen(1))
+	})
+
+	It("should read each bet of a round that spans two indexing windows", func() {
+		chain.latest = 4
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listRounds()[1].Status.LastIndexedBlock).To(Equal(int64(3)))
+
+		chain.latest = 10
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		first := listRounds()[1]
+		Expect(first.Status.Bets).To(HaveLen(2))
+		Expect(first.Status.Bets[0]).To(SatisfyAll(HaveField("Player", fakePlayerA), HaveField("Block", int64(3))))
+		Expect(first.Status.Bets[1]).To(SatisfyAll(HaveField("Player", fakePlayerB), HaveField("Horse", int64(2)), HaveField("Block", int64(5))))
+		Expect(first.Status.Players).To(Equal([]string{fakePlayerA, fakePlayerB}))
+	})
+
+	It("should read the bets of a round indexed from part way through by their place in the contract", func() {
+		chain.latest = 4
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		first := listRounds()[1]
+		Expect(c.Delete(ctx, &first)).To(Succeed())
+
+		chain.latest = 10
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		first = listRounds()[1]
+		Expect(first.Status.Message).To(Equal("the round was indexed from part way through"))
+		Expect(first.Status.Bets).To(HaveLen(1))
+		Expect(first.Status.Bets[0]).To(SatisfyAll(HaveField("Player", fakePlayerB), HaveField("Amount", "150"), HaveField("Block", int64(5))))
+		Expect(first.Status.Players).To(Equal([]string{fakePlayerB}))
+	})
+
+	It("should read bets placed in the same block in the order they were placed", func() {
+		betC := fakeRaceBet{horse: 3, player: "0x3333333333333333333333333333333333333333", amount: 75}
+		state := chain.blocks[5]
+		state.events = []string{topicBetPlaced, topicBetPlaced}
+		state.bets = append(state.bets, betC)
+		chain.blocks[6].bets = state.bets
+		chain.blocks[7].bets = state.bets
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		first := listRounds()[1]
+		Expect(first.Status.Players).To(Equal([]string{fakePlayerA, fakePlayerB, betC.player}))
+		Expect(first.Status.Bets[2]).To(SatisfyAll(HaveField("Horse", int64(3)), HaveField("Amount", "75")))
+	})
+
+	It("should keep indexing the current round when the cache hasn't seen it yet", func() {
+		chain.latest = 5
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listRounds()).To(HaveLen(1))
+
+		By("hiding the rounds from the cache")
+		reconciler.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*racecoursev1beta1.RaceRound); ok {
+					return apierrors.NewNotFound(racecoursev1beta1.GroupVersion.WithResource("racerounds").GroupResource(), key.Name)
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		})
+		chain.latest = 7
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		rounds := listRounds()
+		Expect(rounds).To(HaveLen(1))
+		Expect(rounds[1].Status.Bets).To(HaveLen(2))
+		Expect(rounds[1].Status.Finished).To(BeTrue())
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.RoundIndex.Rounds).To(Equal(int64(1)))
+	})
+
+	It("should note state the node no longer has", func() {
+		chain.prunedBefore = 8
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		first := listRounds()[1]
+		Expect(first.Status.Bets).To(HaveLen(2))
+		Expect(first.Status.Bets[0].Block).To(Equal(int64(3)))
+		Expect(first.Status.Bets[0].Player).To(BeEmpty())
+		Expect(first.Status.Players).To(BeEmpty())
+		Expect(first.Status.Finished).To(BeTrue())
+		Expect(first.Status.WinnerHorse).To(BeNil())
+		Expect(first.Status.Message).To(ContainSubstring("missing trie node"))
+	})
+
+	It("should write the leaderboard from the finished rounds", func() {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "racecourse-leaderboard", Namespace: "default"}, configMap)).To(Succeed())
+		Expect(configMap.OwnerReferences).To(ConsistOf(HaveField("UID", types.UID("1234"))))
+		board := &leaderboard{}
+		Expect(json.Unmarshal([]byte(configMap.Data["leaderboard.json"]), board)).To(Succeed())
+		Expect(board.Rounds).To(Equal(1))
+		Expect(board.Players).To(HaveLen(2))
+		Expect(board.Players[0]).To(SatisfyAll(HaveField("Address", fakePlayerB), HaveField("Wins", 1), HaveField("Net", "100")))
+		Expect(board.Players[1]).To(SatisfyAll(HaveField("Address", fakePlayerA), HaveField("Wins", 0), HaveField("Net", "-100")))
+	})
+
+	It("should index the contract again from block 0 when asked", func() {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		first := listRounds()[1]
+		tampered := first.DeepCopy()
+		tampered.Status.Jackpot = "1000000"
+		Expect(c.Status().Update(ctx, tampered)).To(Succeed())
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		racecourse.Annotations = map[string]string{reindexRoundsAnnotation: "true"}
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		rounds := listRounds()
+		Expect(rounds).To(HaveLen(2))
+		Expect(rounds[1].Status).To(Equal(first.Status))
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Annotations).NotTo(HaveKey(reindexRoundsAnnotation))
+		Expect(racecourse.Status.RoundIndex.Block).To(Equal(int64(10)))
+	})
+
+	It("should wait until the contract has been verified", func() {
+		racecourse.Status.Conditions = nil
+		Expect(c.Status().Update(ctx, racecourse)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(listRounds()).To(BeEmpty())
+
+		By("being woken when it is, but not by its own cursor updates")
+		verified := racecourse.DeepCopy()
+		verified.Status.Conditions = []metav1.Condition{{Type: racecoursev1beta1.ConditionTypeContractVerified, Status: metav1.ConditionTrue}}
+		Expect(roundIndexInputsChanged.Update(event.UpdateEvent{ObjectOld: racecourse, ObjectNew: verified})).To(BeTrue())
+		indexed := verified.DeepCopy()
+		indexed.Status.RoundIndex = &racecoursev1beta1.RaceRoundIndex{ContractAddress: address, Block: 10}
+		Expect(roundIndexInputsChanged.Update(event.UpdateEvent{ObjectOld: verified, ObjectNew: indexed})).To(BeFalse())
+	})
+})
@@ -17,142 +17,153 @@ limitations under the License.
 package controller
 
 import (
-	appsv1 "k8s.io/api/apps/v1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+	networkingv1ac "k8s.io/client-go/applyconfigurations/networking/v1"
 
-	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
 )
 
-// Creates a Deployment spec for Racecourse instances
-func (r *RacecourseReconciler) buildDeployment(racecourse *racecoursev1alpha1.Racecourse) *appsv1.Deployment {
-	replicas := int32(2)
-	if racecourse.Spec.Replicas != nil {
-		replicas = *racecourse.Spec.Replicas
-	}
+// The pod template annotation holding the hash of the generated config, so that pods
+// are rolled whenever the config changes
+const configHashAnnotation = "racecourse.kaleido.io/config-hash"
 
-	repository := "racecourse"
-	if racecourse.Spec.Image.Repository != "" {
-		repository = racecourse.Spec.Image.Repository
+// Renders the connection details the app reads from the generated ConfigMap
+func configData(racecourse *racecoursev1beta1.Racecourse) map[string]string {
+	return map[string]string{
+		"signer-url":       walletEndpointURL(racecourse),
+		"contract-address": contractAddress(racecourse),
 	}
+}
 
-	tag := "0.0.1"
-	if racecourse.Spec.Image.Tag != "" {
-		tag = racecourse.Spec.Image.Tag
+// Hashes the config data in a stable key order
+func configHash(data map[string]string) string {
+	hash := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		// Keys can't contain NUL, so the separators keep entries from running together
+		fmt.Fprintf(hash, "%s\x00%s\x00", key, data[key])
 	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
 
-	pullPolicy := corev1.PullIfNotPresent
-	if racecourse.Spec.Image.PullPolicy != "" {
-		pullPolicy = racecourse.Spec.Image.PullPolicy
-	}
+// Creates a ConfigMap spec with the connection details for Racecourse instances
+func (r *RacecourseReconciler) buildConfigMap(racecourse *racecoursev1beta1.Racecourse) *corev1ac.ConfigMapApplyConfiguration {
+	return corev1ac.ConfigMap(racecourse.Name+"-config", racecourse.Namespace).
+		WithOwnerReferences(ownerReference(racecourse)).
+		WithData(configData(racecourse))
+}
 
+// Creates a Service spec for Racecourse instances
+func (r *RacecourseReconciler) buildService(racecourse *racecoursev1beta1.Racecourse) *corev1ac.ServiceApplyConfiguration {
 	labels := labelsForRacecourse(racecourse.Name)
 
-	deployment := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      racecourse.Name,
-			Namespace: racecourse.Namespace,
-			Labels:    labels,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:            "racecourse",
-							Image:           repository + ":" + tag,
-							ImagePullPolicy: pullPolicy,
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "http",
-									ContainerPort: 3000,
-									Protocol:      corev1.ProtocolTCP,
-								},
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "APPLICATION_PORT",
-									Value: "3000",
-								},
-								{
-									Name: "SIGNER_URL",
-									ValueFrom: &corev1.EnvVarSource{
-										ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: racecourse.Name + "-config",
-											},
-											Key: "signer-url",
-										},
-									},
-								},
-								{
-									Name: "CONTRACT_ADDRESS",
-									ValueFrom: &corev1.EnvVarSource{
-										ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: racecourse.Name + "-config",
-											},
-											Key: "contract-address",
-										},
-									},
-								},
-							},
-							LivenessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/",
-										Port: intstr.FromInt(3000),
-									},
-								},
-								InitialDelaySeconds: 30,
-								PeriodSeconds:       10,
-								TimeoutSeconds:      5,
-								FailureThreshold:    3,
-							},
-							ReadinessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/",
-										Port: intstr.FromInt(3000),
-									},
-								},
-								InitialDelaySeconds: 10,
-								PeriodSeconds:       5,
-								TimeoutSeconds:      3,
-								FailureThreshold:    3,
-							},
-							Resources: racecourse.Spec.Resources,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	return deployment
+	return corev1ac.Service(racecourse.Name, racecourse.Namespace).
+		WithLabels(labels).
+		WithOwnerReferences(ownerReference(racecourse)).
+		WithSpec(corev1ac.ServiceSpec().
+			WithType(corev1.ServiceTypeClusterIP).
+			WithSelector(labels).
+			WithPorts(corev1ac.ServicePort().
+				WithName("http").
+				WithProtocol(corev1.ProtocolTCP).
+				WithPort(3000).
+				WithTargetPort(intstr.FromInt32(3000)),
+			),
+		)
 }
 
-// Creates an Ingress spec for Racecourse instances
-func (r *RacecourseReconciler) buildIngress(racecourse *racecoursev1alpha1.Racecourse) *networkingv1.Ingress {
-	className := "nginx"
-	if racecourse.Spec.Ingress.ClassName != "" {
-		className = racecourse.Spec.Ingress.ClassName
+// Creates a Deployment spec for Racecourse instances. The replica count is left out
+// when something else, like a HorizontalPodAutoscaler, manages it.
+func (r *RacecourseReconciler) buildDeployment(racecourse *racecoursev1beta1.Racecourse, manageReplicas bool) *appsv1ac.DeploymentApplyConfiguration {
+	labels := labelsForRacecourse(racecourse.Name)
+	configMapName := racecourse.Name + "-config"
+
+	spec := appsv1ac.DeploymentSpec().
+		WithSelector(metav1ac.LabelSelector().WithMatchLabels(labels)).
+		WithTemplate(corev1ac.PodTemplateSpec().
+			WithLabels(labels).
+			// The env vars are only read at startup, so roll the pods when they change
+			WithAnnotations(map[string]string{
+				configHashAnnotation: configHash(configData(racecourse)),
+			}).
+			WithSpec(corev1ac.PodSpec().
+				WithContainers(corev1ac.Container().
+					WithName("racecourse").
+					WithImage(racecourse.Spec.Image.Repository+":"+racecourse.Spec.Image.Tag).
+					WithImagePullPolicy(racecourse.Spec.Image.PullPolicy).
+					WithPorts(corev1ac.ContainerPort().
+						WithName("http").
+						WithContainerPort(3000).
+						WithProtocol(corev1.ProtocolTCP),
+					).
+					WithEnv(
+						corev1ac.EnvVar().
+							WithName("APPLICATION_PORT").
+							WithValue("3000"),
+						corev1ac.EnvVar().
+							WithName("SIGNER_URL").
+							WithValueFrom(corev1ac.EnvVarSource().
+								WithConfigMapKeyRef(corev1ac.ConfigMapKeySelector().
+									WithName(configMapName).
+									WithKey("signer-url"),
+								),
+							),
+						corev1ac.EnvVar().
+							WithName("CONTRACT_ADDRESS").
+							WithValueFrom(corev1ac.EnvVarSource().
+								WithConfigMapKeyRef(corev1ac.ConfigMapKeySelector().
+									WithName(configMapName).
+									WithKey("contract-address"),
+								),
+							),
+					).
+					WithLivenessProbe(corev1ac.Probe().
+						WithHTTPGet(corev1ac.HTTPGetAction().
+							WithPath("/").
+							WithPort(intstr.FromInt32(3000)),
+						).
+						WithInitialDelaySeconds(30).
+						WithPeriodSeconds(10).
+						WithTimeoutSeconds(5).
+						WithFailureThreshold(3),
+					).
+					WithReadinessProbe(corev1ac.Probe().
+						WithHTTPGet(corev1ac.HTTPGetAction().
+							WithPath("/").
+							WithPort(intstr.FromInt32(3000)),
+						).
+						WithInitialDelaySeconds(10).
+						WithPeriodSeconds(5).
+						WithTimeoutSeconds(3).
+						WithFailureThreshold(3),
+					).
+					WithResources(resourceRequirements(racecourse.Spec.Resources)),
+				),
+			),
+		)
+
+	if manageReplicas && racecourse.Spec.Replicas != nil {
+		spec.WithReplicas(*racecourse.Spec.Replicas)
 	}
 
-	host := "racecourse.local"
-	if racecourse.Spec.Ingress.Host != "" {
-		host = racecourse.Spec.Ingress.Host
-	}
+	return appsv1ac.Deployment(racecourse.Name, racecourse.Namespace).
+		WithLabels(labels).
+		WithOwnerReferences(ownerReference(racecourse)).
+		WithSpec(spec)
+}
 
+// Creates an Ingress spec for Racecourse instances
+func (r *RacecourseReconciler) buildIngress(racecourse *racecoursev1beta1.Racecourse) *networkingv1ac.IngressApplyConfiguration {
 	// annotations for websocket support
 	annotations := map[string]string{
 		"nginx.ingress.kubernetes.io/websocket-services":     racecourse.Name,
@@ -164,47 +175,73 @@ func (r *RacecourseReconciler) buildIngress(racecourse *racecoursev1alpha1.Racec
 		"nginx.ingress.kubernetes.io/session-cookie-max-age": "172800",
 	}
 
+	// Have cert-manager issue the certificate when an issuer is named
+	tls := racecourse.Spec.Ingress.TLS
+	if tls != nil && tls.ClusterIssuer != "" {
+		annotations["cert-manager.io/cluster-issuer"] = tls.ClusterIssuer
+	}
+
 	// Merge in user provided annotations
 	for key, value := range racecourse.Spec.Ingress.Annotations {
 		annotations[key] = value
 	}
 
-	pathTypePrefix := networkingv1.PathTypePrefix
-
-	ingress := &networkingv1.Ingress{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        racecourse.Name,
-			Namespace:   racecourse.Namespace,
-			Labels:      labelsForRacecourse(racecourse.Name),
-			Annotations: annotations,
-		},
-		Spec: networkingv1.IngressSpec{
-			IngressClassName: &className,
-			Rules: []networkingv1.IngressRule{
-				{
-					Host: host,
-					IngressRuleValue: networkingv1.IngressRuleValue{
-						HTTP: &networkingv1.HTTPIngressRuleValue{
-							Paths: []networkingv1.HTTPIngressPath{
-								{
-									Path:     racecourse.Spec.Ingress.Path,
-									PathType: &pathTypePrefix,
-									Backend: networkingv1.IngressBackend{
-										Service: &networkingv1.IngressServiceBackend{
-											Name: racecourse.Name,
-											Port: networkingv1.ServiceBackendPort{
-												Number: 3000,
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+	spec := networkingv1ac.IngressSpec().
+		WithIngressClassName(racecourse.Spec.Ingress.ClassName).
+		WithRules(networkingv1ac.IngressRule().
+			WithHost(racecourse.Spec.Ingress.HostOrDefault()).
+			WithHTTP(networkingv1ac.HTTPIngressRuleValue().
+				WithPaths(networkingv1ac.HTTPIngressPath().
+					WithPath(racecourse.Spec.Ingress.Path).
+					WithPathType(networkingv1.PathTypePrefix).
+					WithBackend(networkingv1ac.IngressBackend().
+						WithService(networkingv1ac.IngressServiceBackend().
+							WithName(racecourse.Name).
+							WithPort(networkingv1ac.ServiceBackendPort().WithNumber(3000)),
+						),
+					),
+				),
+			),
+		)
+
+	if tls != nil {
+		spec.WithTLS(networkingv1ac.IngressTLS().
+			WithHosts(racecourse.Spec.Ingress.HostOrDefault()).
+			WithSecretName(tls.SecretName),
+		)
 	}
 
-	return ingress
+	return networkingv1ac.Ingress(racecourse.Name, racecourse.Namespace).
+		WithLabels(labelsForRacecourse(racecourse.Name)).
+		WithAnnotations(annotations).
+		WithOwnerReferences(ownerReference(racecourse)).
+		WithSpec(spec)
+}
+
+// Creates the controller owner reference set on every child of the Racecourse
+func ownerReference(racecourse *racecoursev1beta1.Racecourse) *metav1ac.OwnerReferenceApplyConfiguration {
+	return metav1ac.OwnerReference().
+		WithAPIVersion(racecoursev1beta1.GroupVersion.String()).
+		WithKind("Racecourse").
+		WithName(racecourse.Name).
+		WithUID(racecourse.UID).
+		WithController(true).
+		WithBlockOwnerDeletion(true)
+}
+
+// Converts resource requirements into their apply configuration, leaving out
+// anything that isn't set so the operator doesn't claim it
+func resourceRequirements(resources corev1.ResourceRequirements) *corev1ac.ResourceRequirementsApplyConfiguration {
+	if len(resources.Limits) == 0 && len(resources.Requests) == 0 {
+		return nil
+	}
+
+	requirements := corev1ac.ResourceRequirements()
+	if len(resources.Limits) > 0 {
+		requirements.WithLimits(resources.Limits)
+	}
+	if len(resources.Requests) > 0 {
+		requirements.WithRequests(resources.Requests)
+	}
+	return requirements
 }
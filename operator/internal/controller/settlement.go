@@ -0,0 +1,304 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// Holds deletion of a Racecourse until the state of its Race contract has been saved
+const settlementFinalizer = "racecourse.kaleido.io/settlement"
+
+// Set to "true" on a Racecourse to delete it without a settlement snapshot, for when
+// the wallet or chain is gone for good
+const skipSettlementAnnotation = "racecourse.kaleido.io/skip-settlement"
+
+// The annotations on a settlement record naming the Racecourse it was written for, whose
+// name and UID may be cut short in the record's own name
+const (
+	settlementRacecourseAnnotation    = "racecourse.kaleido.io/racecourse"
+	settlementRacecourseUIDAnnotation = "racecourse.kaleido.io/racecourse-uid"
+)
+
+// How many characters of the Racecourse's UID go into the name of its settlement record
+const settlementUIDLength = 8
+
+// How often the teardown checks back while pods terminate or the wallet can't be reached
+const settlementRetryInterval = 10 * time.Second
+
+// The most horses and bets read from the contract, guarding against a bogus count
+const maxSnapshotEntries = 1000
+
+// The event reasons recorded while tearing a Racecourse down
+const (
+	eventReasonScalingDown      = "ScalingDown"
+	eventReasonSettled          = "Settled"
+	eventReasonSettlementFailed = "SettlementFailed"
+)
+
+// The final state of a Racecourse's Race contract
+type raceSnapshot struct {
+	Racecourse         string      `json:"racecourse"`
+	Namespace          string      `json:"namespace"`
+	ContractAddress    string      `json:"contractAddress,omitempty"`
+	BlockNumber        uint64      `json:"blockNumber,omitempty"`
+	TakenAt            metav1.Time `json:"takenAt"`
+	Note               string      `json:"note,omitempty"`
+	Horses             []raceHorse `json:"horses,omitempty"`
+	Bets               []raceBet   `json:"bets,omitempty"`
+	PlayersCount       string      `json:"playersCount,omitempty"`
+	PlayersReadyToRace string      `json:"playersReadyToRace,omitempty"`
+	RaceFinished       bool        `json:"raceFinished"`
+	WinnerHorse        string      `json:"winnerHorse,omitempty"`
+	Jackpot            string      `json:"jackpot,omitempty"`
+}
+
+type raceHorse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type raceBet struct {
+	Horse       string `json:"horse"`
+	Player      string `json:"player"`
+	Amount      string `json:"amount"`
+	ReadyToRace bool   `json:"readyToRace"`
+}
+
+// Tears down a Racecourse that is being deleted: scales the app down so no more bets
+// come in, saves the contract state to a ConfigMap that outlives the Racecourse, and
+// then lets the deletion complete
+func (r *RacecourseReconciler) finalize(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(racecourse, settlementFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if racecourse.Annotations[skipSettlementAnnotation] != "true" {
+		scaledDown, err := r.scaleDown(ctx, racecourse)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !scaledDown {
+			return ctrl.Result{RequeueAfter: settlementRetryInterval}, nil
+		}
+
+		snapshot, err := r.takeSettlementSnapshot(ctx, racecourse)
+		if err != nil {
+			log.Error(err, "Failed to read the final contract state")
+			r.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonSettlementFailed,
+				"Failed to read the final contract state, retrying (annotate with %s=true to delete without it): %v", skipSettlementAnnotation, err)
+			return ctrl.Result{RequeueAfter: settlementRetryInterval}, nil
+		}
+
+		name, err := r.writeSettlement(ctx, racecourse, snapshot)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info("Wrote settlement snapshot", "configMap", name)
+		r.Recorder.Eventf(racecourse, corev1.EventTypeNormal, eventReasonSettled, "Wrote the final contract state to ConfigMap %s", name)
+	}
+
+	controllerutil.RemoveFinalizer(racecourse, settlementFinalizer)
+	return ctrl.Result{}, r.Update(ctx, racecourse)
+}
+
+// Scales the Deployment to zero and reports whether all of its pods are gone
+func (r *RacecourseReconciler) scaleDown(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (bool, error) {
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: racecourse.Name, Namespace: racecourse.Namespace}, found)
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if found.Spec.Replicas != nil && *found.Spec.Replicas == 0 {
+		return found.Status.Replicas == 0, nil
+	}
+
+	// Take the replica count back from any autoscaler so it can't scale the app up again
+	deployment := r.buildDeployment(racecourse, true)
+	deployment.Spec.WithReplicas(0)
+	result, err := applyChild(ctx, r.Client, found, deployment, appsv1ac.ExtractDeployment)
+	r.recordApply(racecourse, "Deployment", *deployment.Name, result, err)
+	if err != nil {
+		return false, err
+	}
+	r.Recorder.Event(racecourse, corev1.EventTypeNormal, eventReasonScalingDown, "Scaling the app down before reading the final contract state")
+	return false, nil
+}
+
+// Reads the final state of the Race contract through the wallet
+func (r *RacecourseReconciler) takeSettlementSnapshot(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (*raceSnapshot, error) {
+	snapshot := &raceSnapshot{
+		Racecourse:      racecourse.Name,
+		Namespace:       racecourse.Namespace,
+		ContractAddress: contractAddress(racecourse),
+		TakenAt:         metav1.Now(),
+	}
+	if snapshot.ContractAddress == "" {
+		snapshot.Note = "no contract address is known, so the contract state could not be read"
+		return snapshot, nil
+	}
+
+	if err := readRaceState(ctx, r.walletClient(racecourse), snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Reads the state of the Race contract into the snapshot, all at the same block
+func readRaceState(ctx context.Context, rpc *ethrpc.Client, snapshot *raceSnapshot) error {
+	blockNumber, err := rpc.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	snapshot.BlockNumber = blockNumber
+	block := ethrpc.BlockTag(blockNumber)
+
+	call := func(signature string, args ...*big.Int) (ethrpc.Result, error) {
+		return callRace(ctx, rpc, snapshot.ContractAddress, block, signature, args...)
+	}
+
+	summary, err := readRaceSummary(ctx, rpc, snapshot.ContractAddress, block)
+	if err != nil {
+		return err
+	}
+	snapshot.PlayersCount = summary.PlayersCount.String()
+	snapshot.PlayersReadyToRace = summary.PlayersReadyToRace.String()
+	snapshot.RaceFinished = summary.RaceFinished
+	snapshot.WinnerHorse = summary.WinnerHorse.String()
+	snapshot.Jackpot = summary.Jackpot.String()
+
+	horseCount, betCount := summary.HorseCount, summary.BetCount
+	if horseCount.Cmp(big.NewInt(maxSnapshotEntries)) > 0 || betCount.Cmp(big.NewInt(maxSnapshotEntries)) > 0 {
+		return fmt.Errorf("contract reports %s horses and %s bets, more than the %d supported", horseCount, betCount, maxSnapshotEntries)
+	}
+
+	for i := range horseCount.Int64() {
+		result, err := call("horses(uint256)", big.NewInt(i))
+		if err != nil {
+			return err
+		}
+		id, err := result.Uint(0)
+		if err != nil {
+			return fmt.Errorf("horses(%d): %w", i, err)
+		}
+		name, err := result.String(1)
+		if err != nil {
+			return fmt.Errorf("horses(%d): %w", i, err)
+		}
+		snapshot.Horses = append(snapshot.Horses, raceHorse{ID: id.String(), Name: name})
+	}
+
+	for i := range betCount.Int64() {
+		result, err := call("bets(uint256)", big.NewInt(i))
+		if err != nil {
+			return err
+		}
+		horse, err := result.Uint(0)
+		if err != nil {
+			return fmt.Errorf("bets(%d): %w", i, err)
+		}
+		player, err := result.Address(1)
+		if err != nil {
+			return fmt.Errorf("bets(%d): %w", i, err)
+		}
+		amount, err := result.Uint(2)
+		if err != nil {
+			return fmt.Errorf("bets(%d): %w", i, err)
+		}
+		ready, err := result.Bool(3)
+		if err != nil {
+			return fmt.Errorf("bets(%d): %w", i, err)
+		}
+		snapshot.Bets = append(snapshot.Bets, raceBet{Horse: horse.String(), Player: player, Amount: amount.String(), ReadyToRace: ready})
+	}
+
+	return nil
+}
+
+// Writes the snapshot to a ConfigMap with no owner, so it's kept after the Racecourse
+// and its children are gone. A record that already exists is left as it is, since it
+// was written by an earlier attempt at this teardown.
+func (r *RacecourseReconciler) writeSettlement(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, snapshot *raceSnapshot) (string, error) {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	// A name too long for a label is only kept in the annotation
+	labels := labelsForRacecourse(racecourse.Name)
+	if len(validation.IsValidLabelValue(racecourse.Name)) > 0 {
+		delete(labels, "racecourse")
+	}
+	name := settlementName(racecourse)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: racecourse.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				settlementRacecourseAnnotation:    racecourse.Name,
+				settlementRacecourseUIDAnnotation: string(racecourse.UID),
+			},
+		},
+		Data: map[string]string{"settlement.json": string(data)},
+	}
+	if err := r.Create(ctx, configMap); err != nil && !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+	return name, nil
+}
+
+// The name of the Racecourse's settlement record. It carries the start of the UID, so a
+// later Racecourse of the same name gets its own record, and the Racecourse's name is cut
+// short to keep it within the 253 characters an object name may have.
+func settlementName(racecourse *racecoursev1beta1.Racecourse) string {
+	uid := string(racecourse.UID)
+	if len(uid) > settlementUIDLength {
+		uid = uid[:settlementUIDLength]
+	}
+	suffix := "-settlement-" + uid
+	name := racecourse.Name
+	if len(name)+len(suffix) > validation.DNS1123SubdomainMaxLength {
+		name = strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)], "-.")
+	}
+	return name + suffix
+}
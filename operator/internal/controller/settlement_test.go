@@ -0,0 +1,235 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/contracts"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// Serves the view functions of a Race contract with two horses and one bet
+func fakeRaceServer() *httptest.Server {
+	word := func(value int64) string {
+		return hex.EncodeToString(ethrpc.EncodeUint(big.NewInt(value)))
+	}
+	horse := func(id int64, name string) string {
+		padded := make([]byte, 32)
+		copy(padded, name)
+		return word(id) + word(64) + word(int64(len(name))) + hex.EncodeToString(padded)
+	}
+	results := map[string]string{
+		hex.EncodeToString(ethrpc.Selector("horseCount()")):              word(2),
+		hex.EncodeToString(ethrpc.Selector("betCount()")):                word(1),
+		hex.EncodeToString(ethrpc.Selector("playersCount()")):            word(1),
+		hex.EncodeToString(ethrpc.Selector("playersReadyToRace()")):      word(0),
+		hex.EncodeToString(ethrpc.Selector("winnerHorse()")):             word(0),
+		hex.EncodeToString(ethrpc.Selector("jackpot()")):                 word(250),
+		hex.EncodeToString(ethrpc.Selector("raceFinished()")):            word(0),
+		hex.EncodeToString(ethrpc.Selector("horses(uint256)")) + word(0): horse(1, "Secretariat"),
+		hex.EncodeToString(ethrpc.Selector("horses(uint256)")) + word(1): horse(2, "Man O' War"),
+		hex.EncodeToString(ethrpc.Selector("bets(uint256)")) + word(0): word(1) +
+			"000000000000000000000000" + "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" + word(250) + word(0),
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		Expect(json.NewDecoder(r.Body).Decode(request)).To(Succeed())
+
+		var result any
+		switch request.Method {
+		case "eth_chainId":
+			result = "0x539"
+		case "eth_accounts":
+			result = []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+		case "eth_getCode":
+			code, err := contracts.RaceRuntimeCode()
+			Expect(err).NotTo(HaveOccurred())
+			result = ethrpc.EncodeHex(code)
+		case "eth_blockNumber":
+			result = "0x2a"
+		case "eth_call":
+			call := map[string]string{}
+			Expect(json.Unmarshal(request.Params[0], &call)).To(Succeed())
+			Expect(string(request.Params[1])).To(Equal(`"0x2a"`))
+			result = "0x" + results[strings.TrimPrefix(call["data"], "0x")]
+		}
+		Expect(json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})).To(Succeed())
+	}))
+}
+
+var _ = Describe("Racecourse settlement", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		server     *httptest.Server
+		key        types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = fakeRaceServer()
+		DeferCleanup(server.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas:        ptr.To(int32(2)),
+				ContractAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  server.URL,
+				},
+			},
+		}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(racecourse).WithStatusSubresource(racecourse).Build()
+		reconciler = &RacecourseReconciler{Client: c, Scheme: testScheme, Recorder: record.NewFakeRecorder(100)}
+	})
+
+	It("should read the final contract state at a single block", func() {
+		snapshot, err := reconciler.takeSettlementSnapshot(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(snapshot.BlockNumber).To(Equal(uint64(42)))
+		Expect(snapshot.Jackpot).To(Equal("250"))
+		Expect(snapshot.RaceFinished).To(BeFalse())
+		Expect(snapshot.Horses).To(Equal([]raceHorse{{ID: "1", Name: "Secretariat"}, {ID: "2", Name: "Man O' War"}}))
+		Expect(snapshot.Bets).To(Equal([]raceBet{{
+			Horse:  "1",
+			Player: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			Amount: "250",
+		}}))
+	})
+
+	It("should add the finalizer and settle before letting the deletion complete", func() {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Finalizers).To(ContainElement(settlementFinalizer))
+
+		Expect(c.Delete(ctx, racecourse)).To(Succeed())
+
+		By("scaling the app down first")
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(settlementRetryInterval))
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, key, deployment)).To(Succeed())
+		Expect(deployment.Spec.Replicas).To(HaveValue(Equal(int32(0))))
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "racecourse-settlement-1234", Namespace: "default"}, &corev1.ConfigMap{}))).To(BeTrue())
+
+		By("writing the snapshot once the pods are gone")
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		settlement := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "racecourse-settlement-1234", Namespace: "default"}, settlement)).To(Succeed())
+		Expect(settlement.OwnerReferences).To(BeEmpty())
+		Expect(settlement.Annotations).To(HaveKeyWithValue(settlementRacecourseAnnotation, "racecourse"))
+		Expect(settlement.Annotations).To(HaveKeyWithValue(settlementRacecourseUIDAnnotation, "1234"))
+		Expect(settlement.Data["settlement.json"]).To(ContainSubstring(`"jackpot": "250"`))
+		Expect(errors.IsNotFound(c.Get(ctx, key, &racecoursev1beta1.Racecourse{}))).To(BeTrue())
+	})
+
+	It("should keep the record's name within the limit however long the Racecourse's name is", func() {
+		long := &racecoursev1beta1.Racecourse{ObjectMeta: metav1.ObjectMeta{
+			Name: strings.Repeat("a", 232) + "." + strings.Repeat("b", 20),
+			UID:  "0d9f3c2e-5b7a-4e61-9c1d-2f8a6b4e7c90",
+		}}
+		name := settlementName(long)
+		Expect(name).To(Equal(strings.Repeat("a", 232) + "-settlement-0d9f3c2e"))
+		Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty())
+
+		long.Name = "racecourse"
+		Expect(settlementName(long)).To(Equal("racecourse-settlement-0d9f3c2e"))
+	})
+
+	It("should keep a settlement record that has already been written", func() {
+		written := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse-settlement-1234", Namespace: "default"},
+			Data:       map[string]string{"settlement.json": "{}"},
+		}
+		Expect(c.Create(ctx, written)).To(Succeed())
+		addSettlementFinalizer(ctx, c, racecourse)
+		Expect(c.Delete(ctx, racecourse)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(c.Get(ctx, key, &racecoursev1beta1.Racecourse{}))).To(BeTrue())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(written), written)).To(Succeed())
+		Expect(written.Data).To(HaveKeyWithValue("settlement.json", "{}"))
+	})
+
+	It("should hold the deletion while the contract can't be read", func() {
+		server.Close()
+		addSettlementFinalizer(ctx, c, racecourse)
+		Expect(c.Delete(ctx, racecourse)).To(Succeed())
+
+		for range 2 {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+
+		By("skipping the settlement when asked to")
+		racecourse.Annotations = map[string]string{skipSettlementAnnotation: "true"}
+		Expect(c.Update(ctx, racecourse)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(c.Get(ctx, key, &racecoursev1beta1.Racecourse{}))).To(BeTrue())
+	})
+})
+
+// Adds the settlement finalizer to a stored Racecourse
+func addSettlementFinalizer(ctx context.Context, c client.Client, racecourse *racecoursev1beta1.Racecourse) {
+	racecourse.Finalizers = append(racecourse.Finalizers, settlementFinalizer)
+	Expect(c.Update(ctx, racecourse)).To(Succeed())
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// Sets a condition on the Racecourse, stamped with the generation it was computed from
+func setCondition(racecourse *racecoursev1beta1.Racecourse, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&racecourse.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: racecourse.Generation,
+	})
+}
+
+// Sets the Available, Progressing and Degraded conditions from the owned Deployment,
+// which is nil if it doesn't exist yet
+func setDeploymentConditions(racecourse *racecoursev1beta1.Racecourse, deployment *appsv1.Deployment) {
+	if deployment == nil {
+		message := "the Deployment has not been created yet"
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeAvailable, metav1.ConditionFalse, racecoursev1beta1.ReasonDeploymentNotFound, message)
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionTrue, racecoursev1beta1.ReasonDeploymentNotFound, message)
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionFalse, racecoursev1beta1.ReasonDeploymentNotFound, message)
+		return
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	available := deployment.Status.AvailableReplicas
+	replicas := fmt.Sprintf("%d/%d replicas available", available, desired)
+
+	if available > 0 {
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeAvailable, metav1.ConditionTrue, racecoursev1beta1.ReasonMinimumReplicasAvailable, replicas)
+	} else {
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeAvailable, metav1.ConditionFalse, racecoursev1beta1.ReasonReplicasUnavailable, replicas)
+	}
+
+	// The rollout is only finished once the Deployment controller has seen the latest
+	// spec and every replica runs it
+	rolledOut := deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == desired &&
+		deployment.Status.Replicas == desired &&
+		available == desired
+	if rolledOut {
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionFalse, racecoursev1beta1.ReasonRolloutComplete,
+			fmt.Sprintf("all %d replicas are up to date", desired))
+	} else {
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeProgressing, metav1.ConditionTrue, racecoursev1beta1.ReasonRollingOut,
+			fmt.Sprintf("%d/%d replicas updated, %s", deployment.Status.UpdatedReplicas, desired, replicas))
+	}
+
+	progressing := deploymentCondition(deployment, appsv1.DeploymentProgressing)
+	switch {
+	case progressing != nil && progressing.Reason == "ProgressDeadlineExceeded":
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, racecoursev1beta1.ReasonProgressDeadlineExceeded, progressing.Message)
+	case rolledOut || available >= desired:
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionFalse, racecoursev1beta1.ReasonAsExpected, replicas)
+	case deployment.Status.UpdatedReplicas == desired && deployment.Status.ObservedGeneration >= deployment.Generation:
+		// Every pod runs the latest spec but some of them still aren't available
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionTrue, racecoursev1beta1.ReasonReplicasUnavailable, replicas)
+	default:
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeDegraded, metav1.ConditionFalse, racecoursev1beta1.ReasonRollingOut, replicas)
+	}
+}
+
+// Sets the WalletReachable condition from the checks of the wallet
+func setWalletCondition(racecourse *racecoursev1beta1.Racecourse, wallet *walletHealth) {
+	status := metav1.ConditionFalse
+	if wallet.Ready {
+		status = metav1.ConditionTrue
+	}
+	setCondition(racecourse, racecoursev1beta1.ConditionTypeWalletReachable, status, wallet.Reason, wallet.Message)
+}
+
+// Sets the ChainVerified condition from the check of the wallet's chain
+func setChainVerifiedCondition(racecourse *racecoursev1beta1.Racecourse, verification *chainVerification) {
+	setCondition(racecourse, racecoursev1beta1.ConditionTypeChainVerified, verification.Status, verification.Reason, verification.Message)
+}
+
+// Sets the ContractReady condition
+func setContractCondition(racecourse *racecoursev1beta1.Racecourse) {
+	switch {
+	case racecourse.Spec.ContractAddress != "":
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeContractReady, metav1.ConditionTrue, racecoursev1beta1.ReasonContractAddressSet,
+			fmt.Sprintf("using the contract at %s", racecourse.Spec.ContractAddress))
+	case racecourse.Status.ContractAddress != "":
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeContractReady, metav1.ConditionTrue, racecoursev1beta1.ReasonContractDeployed,
+			fmt.Sprintf("using the contract the operator deployed at %s", racecourse.Status.ContractAddress))
+	case racecourse.Status.ContractDeploymentTransaction != "":
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeContractReady, metav1.ConditionFalse, racecoursev1beta1.ReasonContractDeploying,
+			fmt.Sprintf("waiting for transaction %s deploying the contract to be mined", racecourse.Status.ContractDeploymentTransaction))
+	case racecourse.Status.ContractDeploymentNonce != nil:
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeContractReady, metav1.ConditionFalse, racecoursev1beta1.ReasonContractDeploying,
+			fmt.Sprintf("deploying the contract from %s with nonce %d", racecourse.Status.ContractDeploymentFrom, *racecourse.Status.ContractDeploymentNonce))
+	default:
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeContractReady, metav1.ConditionFalse, racecoursev1beta1.ReasonContractNotConfigured,
+			"no contract address is set, so the operator deploys a contract once the wallet is ready")
+	}
+}
+
+// Sets the ContractVerified condition from the check of the code at the contract address
+func setContractVerifiedCondition(racecourse *racecoursev1beta1.Racecourse, verification *contractVerification) {
+	setCondition(racecourse, racecoursev1beta1.ConditionTypeContractVerified, verification.Status, verification.Reason, verification.Message)
+}
+
+// Sets the IngressReady condition, given the owned Ingress if there is one
+func setIngressCondition(racecourse *racecoursev1beta1.Racecourse, ingress *networkingv1.Ingress) {
+	switch {
+	case !racecourse.Spec.Ingress.IsEnabled():
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeIngressReady, metav1.ConditionTrue, racecoursev1beta1.ReasonIngressDisabled,
+			"no Ingress is requested")
+	case ingress == nil || len(ingress.Status.LoadBalancer.Ingress) == 0:
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeIngressReady, metav1.ConditionFalse, racecoursev1beta1.ReasonIngressPending,
+			"waiting for the ingress controller to assign an address")
+	default:
+		address := ingress.Status.LoadBalancer.Ingress[0].IP
+		if address == "" {
+			address = ingress.Status.LoadBalancer.Ingress[0].Hostname
+		}
+		setCondition(racecourse, racecoursev1beta1.ConditionTypeIngressReady, metav1.ConditionTrue, racecoursev1beta1.ReasonIngressAddressAssigned,
+			fmt.Sprintf("the Ingress is served at %s", address))
+	}
+}
+
+// Finds a condition on the Deployment, or nil if it isn't set
+func deploymentCondition(deployment *appsv1.Deployment, conditionType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range deployment.Status.Conditions {
+		if deployment.Status.Conditions[i].Type == conditionType {
+			return &deployment.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse status conditions", func() {
+	var (
+		racecourse *racecoursev1beta1.Racecourse
+		deployment *appsv1.Deployment
+	)
+
+	condition := func(conditionType string) *metav1.Condition {
+		return meta.FindStatusCondition(racecourse.Status.Conditions, conditionType)
+	}
+
+	BeforeEach(func() {
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", Generation: 3},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type:    racecoursev1beta1.WalletEndpointTypeService,
+					Service: &racecoursev1beta1.WalletServiceRef{Name: "firefly-signer", Namespace: "default", Port: 8545},
+				},
+			},
+		}
+		deployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(2))},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           2,
+				UpdatedReplicas:    2,
+				AvailableReplicas:  2,
+			},
+		}
+	})
+
+	It("should report a fully rolled out Deployment as available", func() {
+		setDeploymentConditions(racecourse, deployment)
+
+		Expect(condition(racecoursev1beta1.ConditionTypeAvailable).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(racecoursev1beta1.ConditionTypeProgressing).Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition(racecoursev1beta1.ConditionTypeDegraded).Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition(racecoursev1beta1.ConditionTypeAvailable).ObservedGeneration).To(Equal(int64(3)))
+	})
+
+	It("should report a rollout in progress", func() {
+		deployment.Generation = 3
+		deployment.Status.UpdatedReplicas = 1
+		setDeploymentConditions(racecourse, deployment)
+
+		Expect(condition(racecoursev1beta1.ConditionTypeProgressing).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(racecoursev1beta1.ConditionTypeProgressing).Reason).To(Equal(racecoursev1beta1.ReasonRollingOut))
+		Expect(condition(racecoursev1beta1.ConditionTypeDegraded).Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should report missing replicas after a rollout as degraded", func() {
+		deployment.Status.AvailableReplicas = 1
+		setDeploymentConditions(racecourse, deployment)
+
+		Expect(condition(racecoursev1beta1.ConditionTypeAvailable).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(racecoursev1beta1.ConditionTypeDegraded).Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition(racecoursev1beta1.ConditionTypeDegraded).Reason).To(Equal(racecoursev1beta1.ReasonReplicasUnavailable))
+	})
+
+	It("should report a stuck rollout as degraded", func() {
+		deployment.Status.AvailableReplicas = 0
+		deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentProgressing,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ProgressDeadlineExceeded",
+			Message: "ReplicaSet has timed out progressing.",
+		}}
+		setDeploymentConditions(racecourse, deployment)
+
+		Expect(condition(racecoursev1beta1.ConditionTypeAvailable).Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition(racecoursev1beta1.ConditionTypeDegraded).Reason).To(Equal(racecoursev1beta1.ReasonProgressDeadlineExceeded))
+	})
+
+	It("should report a missing Deployment as unavailable", func() {
+		setDeploymentConditions(racecourse, nil)
+
+		Expect(condition(racecoursev1beta1.ConditionTypeAvailable).Reason).To(Equal(racecoursev1beta1.ReasonDeploymentNotFound))
+		Expect(condition(racecoursev1beta1.ConditionTypeProgressing).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("should report the outcome of the wallet checks", func() {
+		setWalletCondition(racecourse, &walletHealth{Reason: racecoursev1beta1.ReasonWalletNoAccounts, Message: "no accounts"})
+		Expect(condition(racecoursev1beta1.ConditionTypeWalletReachable).Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition(racecoursev1beta1.ConditionTypeWalletReachable).Reason).To(Equal(racecoursev1beta1.ReasonWalletNoAccounts))
+
+		setWalletCondition(racecourse, &walletHealth{Ready: true, Reason: racecoursev1beta1.ReasonWalletResponding, Message: "answers"})
+		Expect(condition(racecoursev1beta1.ConditionTypeWalletReachable).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("should only report the Ingress as ready once it has an address", func() {
+		ingress := &networkingv1.Ingress{}
+		setIngressCondition(racecourse, ingress)
+		Expect(condition(racecoursev1beta1.ConditionTypeIngressReady).Status).To(Equal(metav1.ConditionFalse))
+
+		ingress.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.1"}}
+		setIngressCondition(racecourse, ingress)
+		Expect(condition(racecoursev1beta1.ConditionTypeIngressReady).Status).To(Equal(metav1.ConditionTrue))
+
+		racecourse.Spec.Ingress.Enabled = ptr.To(false)
+		setIngressCondition(racecourse, nil)
+		Expect(condition(racecoursev1beta1.ConditionTypeIngressReady).Reason).To(Equal(racecoursev1beta1.ReasonIngressDisabled))
+	})
+})
@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// The name of the Racecourse CustomResourceDefinition
+const racecourseCRDName = "racecourses.racecourse.kaleido.io"
+
+// How often the migration is retried while the API server can't convert objects yet
+const storageMigrationRetryInterval = 10 * time.Second
+
+// How often objects the API server refused to write back are tried again. They only
+// go through once someone fixes them, so there's no point trying often.
+const storageMigrationRejectedInterval = 10 * time.Minute
+
+// Event reason on a Racecourse that can't be written back in the storage version
+const eventReasonStorageMigrationFailed = "StorageMigrationFailed"
+
+// StorageVersionMigrator rewrites every Racecourse in the current storage version and then
+// drops the older versions from the CRD's status.storedVersions, so they can later be
+// removed from the CRD. Objects are served in every version the whole time, so clients
+// don't notice the migration. A Racecourse the API server refuses to write back, such
+// as one the validating webhook rejects as it stands, is skipped with a Warning event,
+// and the older versions stay in storedVersions until it has been fixed.
+type StorageVersionMigrator struct {
+	Client client.Client
+
+	// Reads objects straight from the API server, avoiding a cache of CRDs
+	APIReader client.Reader
+
+	Recorder record.EventRecorder
+
+	// The Racecourses refused on the last complete pass, which are the only ones left
+	// in an older version. Nil until a pass completes.
+	rejected map[types.NamespacedName]bool
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update
+
+// Start runs the migration once, retrying until it succeeds or the manager stops
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("storage-version-migrator")
+
+	for {
+		rejected, err := m.migrate(ctx)
+		interval := storageMigrationRetryInterval
+		switch {
+		case err != nil:
+			// The conversion webhook is served by this process, so it may not be
+			// reachable through its Service straight away
+			log.Error(err, "Failed to migrate Racecourse storage version, retrying")
+		case rejected > 0:
+			log.Info("Some Racecourses can't be migrated until they are fixed, trying them again later",
+				"count", rejected, "after", storageMigrationRejectedInterval)
+			interval = storageMigrationRejectedInterval
+		default:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Only one replica needs to do the migration
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Writes every Racecourse back in the storage version, and drops the older versions
+// from storedVersions unless some were refused. Returns how many were refused.
+func (m *StorageVersionMigrator) migrate(ctx context.Context) (int, error) {
+	log := log.FromContext(ctx).WithName("storage-version-migrator")
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := m.APIReader.Get(ctx, types.NamespacedName{Name: racecourseCRDName}, crd); err != nil {
+		return 0, err
+	}
+
+	storageVersion := racecoursev1beta1.GroupVersion.Version
+	if slices.Equal(crd.Status.StoredVersions, []string{storageVersion}) {
+		return 0, nil
+	}
+
+	racecourses := &racecoursev1beta1.RacecourseList{}
+	if err := m.APIReader.List(ctx, racecourses); err != nil {
+		return 0, err
+	}
+
+	// An empty patch still makes the API server write the object back, encoded in the
+	// storage version
+	rejected := map[types.NamespacedName]bool{}
+	for i := range racecourses.Items {
+		racecourse := &racecourses.Items[i]
+		key := client.ObjectKeyFromObject(racecourse)
+		// The rest were written back on an earlier pass, or created since
+		if m.rejected != nil && !m.rejected[key] {
+			continue
+		}
+
+		err := m.Client.Patch(ctx, racecourse, client.RawPatch(types.MergePatchType, []byte("{}")))
+		switch {
+		case err == nil || errors.IsNotFound(err):
+		case errors.IsInvalid(err) || errors.IsForbidden(err) || errors.IsBadRequest(err):
+			log.Info("Racecourse can't be written back in the storage version", "racecourse", key, "error", err.Error())
+			m.Recorder.Eventf(racecourse, corev1.EventTypeWarning, eventReasonStorageMigrationFailed,
+				"Can't be written back in version %s, so versions %s stay stored until it is fixed: %v",
+				storageVersion, strings.Join(crd.Status.StoredVersions, ", "), err)
+			rejected[key] = true
+		default:
+			return 0, err
+		}
+	}
+	m.rejected = rejected
+	if len(rejected) > 0 {
+		return len(rejected), nil
+	}
+
+	log.Info("Migrated Racecourse storage version", "version", storageVersion,
+		"count", len(racecourses.Items), "storedVersions", crd.Status.StoredVersions)
+	crd.Status.StoredVersions = []string{storageVersion}
+	return 0, m.Client.Status().Update(ctx, crd)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse storage version migration", func() {
+	var (
+		ctx      context.Context
+		c        client.WithWatch
+		recorder *record.FakeRecorder
+		migrator *StorageVersionMigrator
+		// The Racecourses the validating webhook rejects, and every patch sent
+		invalid map[string]bool
+		patched []string
+	)
+
+	storedVersions := func() []string {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: racecourseCRDName}, crd)).To(Succeed())
+		return crd.Status.StoredVersions
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		invalid = map[string]bool{"broken": true}
+		patched = nil
+
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: racecourseCRDName},
+			Status:     apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: []string{"v1alpha1", "v1beta1"}},
+		}
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(apiextensionsv1.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).
+			WithObjects(
+				crd,
+				&racecoursev1beta1.Racecourse{ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "default"}},
+				&racecoursev1beta1.Racecourse{ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default"}},
+			).
+			WithStatusSubresource(crd).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					patched = append(patched, obj.GetName())
+					if invalid[obj.GetName()] {
+						return apierrors.NewForbidden(racecoursev1beta1.GroupVersion.WithResource("racecourses").GroupResource(),
+							obj.GetName(), apierrors.NewBadRequest("spec.wallet.service.name is required"))
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build()
+		recorder = record.NewFakeRecorder(100)
+		migrator = &StorageVersionMigrator{Client: c, APIReader: c, Recorder: recorder}
+	})
+
+	It("should drop the older versions once every Racecourse is written back", func() {
+		invalid = map[string]bool{}
+		rejected, err := migrator.migrate(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rejected).To(BeZero())
+		Expect(patched).To(ConsistOf("broken", "racecourse"))
+		Expect(storedVersions()).To(Equal([]string{"v1beta1"}))
+	})
+
+	It("should skip a Racecourse the webhook rejects and keep the older versions until it is fixed", func() {
+		rejected, err := migrator.migrate(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rejected).To(Equal(1))
+		Expect(patched).To(ConsistOf("broken", "racecourse"))
+		Expect(storedVersions()).To(Equal([]string{"v1alpha1", "v1beta1"}))
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning StorageMigrationFailed Can't be written back in version v1beta1")))
+
+		By("only trying the rejected Racecourse again")
+		patched = nil
+		rejected, err = migrator.migrate(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rejected).To(Equal(1))
+		Expect(patched).To(Equal([]string{"broken"}))
+
+		By("fixing it")
+		delete(invalid, "broken")
+		patched = nil
+		rejected, err = migrator.migrate(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rejected).To(BeZero())
+		Expect(patched).To(Equal([]string{"broken"}))
+		Expect(storedVersions()).To(Equal([]string{"v1beta1"}))
+	})
+})
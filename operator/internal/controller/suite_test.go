@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -61,6 +62,8 @@ var _ = BeforeSuite(func() {
 	var err error
 	err = racecoursev1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = racecoursev1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// How long the JSON-RPC checks of the wallet may take, retries included
+const walletProbeTimeout = 5 * time.Second
+
+// How soon, and at most how long after, the wallet is checked again while it holds up
+// the first rollout
+const (
+	walletGateMinBackoff = 5 * time.Second
+	walletGateMaxBackoff = 5 * time.Minute
+)
+
+// What the checks of the wallet found
+type walletHealth struct {
+	// Whether the wallet passed every check and the app can be rolled out against it
+	Ready bool
+	// The reason and message for the WalletReachable condition
+	Reason  string
+	Message string
+	// The chain ID the wallet answered with, if it answered
+	ChainID *big.Int
+	// The accounts the wallet can sign with
+	Accounts []string
+}
+
+// Checks the wallet the way the app will use it: the Service exists and has ready
+// endpoints, and the wallet answers eth_chainId and has at least one account to sign with.
+// Only failures to talk to the Kubernetes API are returned as errors.
+func (r *RacecourseReconciler) checkWallet(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (*walletHealth, error) {
+	wallet := racecourse.Spec.Wallet
+	url := walletEndpointURL(racecourse)
+
+	if wallet.Type == racecoursev1beta1.WalletEndpointTypeService {
+		service, err := r.getWalletService(ctx, racecourse)
+		if err != nil {
+			return nil, err
+		}
+		if service == nil {
+			return &walletHealth{
+				Reason:  racecoursev1beta1.ReasonWalletServiceNotFound,
+				Message: fmt.Sprintf("the wallet Service %s/%s does not exist", walletServiceNamespace(racecourse), wallet.Service.Name),
+			}, nil
+		}
+
+		// An ExternalName Service has no endpoints of its own, so only the JSON-RPC checks apply
+		if service.Spec.Type != corev1.ServiceTypeExternalName {
+			ready, err := r.countReadyEndpoints(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			if ready == 0 {
+				return &walletHealth{
+					Reason:  racecoursev1beta1.ReasonWalletEndpointsNotReady,
+					Message: fmt.Sprintf("the wallet Service %s/%s has no ready endpoints", service.Namespace, service.Name),
+				}, nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, walletProbeTimeout)
+	defer cancel()
+	rpc := r.walletClient(racecourse)
+
+	chainID, err := rpc.ChainID(ctx)
+	if err != nil {
+		return probeFailure(url, err), nil
+	}
+	accounts, err := rpc.Accounts(ctx)
+	if err != nil {
+		return probeFailure(url, err), nil
+	}
+	if len(accounts) == 0 {
+		return &walletHealth{
+			Reason:  racecoursev1beta1.ReasonWalletNoAccounts,
+			Message: fmt.Sprintf("the wallet at %s has no accounts to sign with", url),
+			ChainID: chainID,
+		}, nil
+	}
+
+	return &walletHealth{
+		Ready:    true,
+		Reason:   racecoursev1beta1.ReasonWalletResponding,
+		Message:  fmt.Sprintf("the wallet at %s answers for chain ID %s with %d account(s)", url, chainID, len(accounts)),
+		ChainID:  chainID,
+		Accounts: accounts,
+	}, nil
+}
+
+// Describes a failed JSON-RPC call to the wallet
+func probeFailure(url string, err error) *walletHealth {
+	if ethrpc.IsUnreachable(err) {
+		return &walletHealth{
+			Reason:  racecoursev1beta1.ReasonWalletUnreachable,
+			Message: fmt.Sprintf("the wallet at %s cannot be reached: %v", url, err),
+		}
+	}
+	return &walletHealth{
+		Reason:  racecoursev1beta1.ReasonWalletRPCError,
+		Message: fmt.Sprintf("the wallet at %s failed to answer: %v", url, err),
+	}
+}
+
+// Get the wallet Service, or nil if the wallet isn't a Service or the Service doesn't exist
+func (r *RacecourseReconciler) getWalletService(ctx context.Context, racecourse *racecoursev1beta1.Racecourse) (*corev1.Service, error) {
+	wallet := racecourse.Spec.Wallet
+	if wallet.Type != racecoursev1beta1.WalletEndpointTypeService || wallet.Service == nil {
+		return nil, nil
+	}
+
+	service := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: wallet.Service.Name, Namespace: walletServiceNamespace(racecourse)}, service)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// Counts the ready endpoints behind a Service across all of its EndpointSlices
+func (r *RacecourseReconciler) countReadyEndpoints(ctx context.Context, service *corev1.Service) (int, error) {
+	slices := &discoveryv1.EndpointSliceList{}
+	err := r.List(ctx, slices, client.InNamespace(service.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: service.Name})
+	if err != nil {
+		return 0, err
+	}
+
+	ready := 0
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			// Unset means ready
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready++
+			}
+		}
+	}
+	return ready, nil
+}
+
+// Creates a JSON-RPC client for the wallet of the Racecourse
+func (r *RacecourseReconciler) walletClient(racecourse *racecoursev1beta1.Racecourse) *ethrpc.Client {
+	return ethrpc.New(walletEndpointURL(racecourse), r.RPCOptions...)
+}
+
+// How long to wait before checking the wallet again, which grows with how long it has
+// been failing so a wallet that is down for good isn't polled constantly
+func walletGateBackoff(racecourse *racecoursev1beta1.Racecourse, now time.Time) time.Duration {
+	backoff := walletGateMinBackoff
+	condition := meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeWalletReachable)
+	if condition != nil && condition.Status == metav1.ConditionFalse {
+		backoff = max(backoff, now.Sub(condition.LastTransitionTime.Time))
+	}
+	return min(backoff, walletGateMaxBackoff)
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+	"github.com/mgoode/racecourse-operator/internal/contracts"
+	"github.com/mgoode/racecourse-operator/internal/ethrpc"
+)
+
+// The state of the signer served by fakeSigner
+type fakeSignerState struct {
+	accounts []string
+	// The transactions sent, each hashed to its position in the list
+	sent []map[string]string
+	// Whether sent transactions have been mined, and whether they succeeded
+	mined  bool
+	failed bool
+	// Whether eth_sendTransaction fails with a server error
+	unavailable bool
+	// The account's transaction count in the latest block, and counting the pending ones
+	nonce        uint64
+	pendingNonce uint64
+	// The code of each contract, by lower case address
+	code map[string][]byte
+}
+
+// The address the fake signer deploys contracts to, and holds a Race contract at
+const fakeContractAddress = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+
+// The hash of the fake signer's genesis block
+var fakeGenesisHash = "0x" + strings.Repeat("ab", 32)
+
+// Creates the state of a signer with one account and a Race contract
+func newFakeSignerState() *fakeSignerState {
+	code, err := contracts.RaceRuntimeCode()
+	Expect(err).NotTo(HaveOccurred())
+	return &fakeSignerState{
+		accounts: []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		code:     map[string][]byte{fakeContractAddress: code},
+	}
+}
+
+// Serves the JSON-RPC methods of a signer that the operator uses
+func fakeSigner(state *fakeSignerState) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		Expect(json.NewDecoder(r.Body).Decode(request)).To(Succeed())
+
+		var result any
+		switch request.Method {
+		case "eth_chainId":
+			result = "0x539"
+		case "eth_accounts":
+			result = state.accounts
+		case "eth_getTransactionCount":
+			Expect(string(request.Params[0])).To(Equal(`"` + state.accounts[0] + `"`))
+			result = fmt.Sprintf("0x%x", state.nonce)
+			if string(request.Params[1]) == `"pending"` {
+				result = fmt.Sprintf("0x%x", state.pendingNonce)
+			}
+		case "eth_sendTransaction":
+			if state.unavailable {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			tx := map[string]string{}
+			Expect(json.Unmarshal(request.Params[0], &tx)).To(Succeed())
+			state.sent = append(state.sent, tx)
+			// The transaction waits in the pool until the test mines it
+			var nonce uint64
+			if _, err := fmt.Sscanf(tx["nonce"], "0x%x", &nonce); err == nil {
+				state.pendingNonce = max(state.pendingNonce, nonce+1)
+			}
+			result = fmt.Sprintf("0x%064x", len(state.sent))
+		case "eth_getBlockByNumber":
+			Expect(string(request.Params[0])).To(Equal(`"0x0"`))
+			result = map[string]any{"number": "0x0", "hash": fakeGenesisHash}
+		case "eth_getCode":
+			var address string
+			Expect(json.Unmarshal(request.Params[0], &address)).To(Succeed())
+			result = ethrpc.EncodeHex(state.code[strings.ToLower(address)])
+		case "eth_getTransactionReceipt":
+			if !state.mined {
+				break
+			}
+			status := "0x1"
+			if state.failed {
+				status = "0x0"
+			}
+			result = map[string]any{
+				"transactionHash": fmt.Sprintf("0x%064x", len(state.sent)),
+				"blockNumber":     "0x2a",
+				"contractAddress": fakeContractAddress,
+				"status":          status,
+			}
+		}
+		Expect(json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})).To(Succeed())
+	}))
+}
+
+var _ = Describe("Racecourse wallet gate", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		reconciler *RacecourseReconciler
+		racecourse *racecoursev1beta1.Racecourse
+		service    *corev1.Service
+		signer     *fakeSignerState
+		key        types.NamespacedName
+	)
+
+	// Publishes an endpoint for the wallet Service
+	addEndpoint := func(ready bool) {
+		Expect(c.Create(ctx, &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "firefly-signer-abcde",
+				Namespace: "default",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "firefly-signer"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(ready)},
+			}},
+		})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		signer = newFakeSignerState()
+		server := fakeSigner(signer)
+		DeferCleanup(server.Close)
+
+		racecourse = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default", UID: "1234"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas:        ptr.To(int32(2)),
+				ContractAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type:    racecoursev1beta1.WalletEndpointTypeService,
+					Service: &racecoursev1beta1.WalletServiceRef{Name: "firefly-signer", Namespace: "default", Port: 8545},
+				},
+			},
+		}
+		service = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "firefly-signer", Namespace: "default"}}
+		key = types.NamespacedName{Name: "racecourse", Namespace: "default"}
+
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		utilruntime.Must(racecoursev1beta1.AddToScheme(testScheme))
+		c = fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().
+			WithObjects(racecourse).WithStatusSubresource(racecourse).Build()
+
+		// Send the wallet's requests to the fake signer rather than the in-cluster address
+		reconciler = &RacecourseReconciler{
+			Client:   c,
+			Scheme:   testScheme,
+			Recorder: record.NewFakeRecorder(100),
+			RPCOptions: []ethrpc.Option{
+				ethrpc.WithRetries(0, 0),
+				ethrpc.WithHTTPClient(&http.Client{Transport: redirectTo(server.URL)}),
+			},
+		}
+	})
+
+	It("should check each step the app relies on", func() {
+		wallet, err := reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Reason).To(Equal(racecoursev1beta1.ReasonWalletServiceNotFound))
+
+		Expect(c.Create(ctx, service)).To(Succeed())
+		wallet, err = reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Reason).To(Equal(racecoursev1beta1.ReasonWalletEndpointsNotReady))
+
+		addEndpoint(true)
+		signer.accounts = []string{}
+		wallet, err = reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Reason).To(Equal(racecoursev1beta1.ReasonWalletNoAccounts))
+		Expect(wallet.Ready).To(BeFalse())
+
+		signer.accounts = []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+		wallet, err = reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Ready).To(BeTrue())
+		Expect(wallet.ChainID.Int64()).To(Equal(int64(1337)))
+	})
+
+	It("should look for a wallet Service stored without defaults in the Racecourse's namespace", func() {
+		racecourse.Spec.Wallet.Service = &racecoursev1beta1.WalletServiceRef{Name: "firefly-signer"}
+		Expect(walletEndpointURL(racecourse)).To(Equal("http://firefly-signer.default.svc.cluster.local:8545"))
+
+		Expect(c.Create(ctx, service)).To(Succeed())
+		addEndpoint(true)
+		wallet, err := reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Ready).To(BeTrue())
+	})
+
+	It("should not count endpoints that aren't ready", func() {
+		Expect(c.Create(ctx, service)).To(Succeed())
+		addEndpoint(false)
+
+		wallet, err := reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Reason).To(Equal(racecoursev1beta1.ReasonWalletEndpointsNotReady))
+	})
+
+	It("should report a wallet URL that can't be reached", func() {
+		racecourse.Spec.Wallet = racecoursev1beta1.WalletEndpoint{Type: racecoursev1beta1.WalletEndpointTypeURL, URL: "http://signer.example.com"}
+		reconciler.RPCOptions = []ethrpc.Option{ethrpc.WithRetries(0, 0), ethrpc.WithHTTPClient(&http.Client{Transport: redirectTo("http://127.0.0.1:1")})}
+
+		wallet, err := reconciler.checkWallet(ctx, racecourse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wallet.Reason).To(Equal(racecoursev1beta1.ReasonWalletUnreachable))
+	})
+
+	It("should hold the first rollout in Pending until the wallet is ready", func() {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(walletGateMinBackoff))
+		Expect(errors.IsNotFound(c.Get(ctx, key, &appsv1.Deployment{}))).To(BeTrue())
+
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(racecourse.Status.Phase).To(Equal(racecoursev1beta1.RacecoursePhasePending))
+		progressing := meta.FindStatusCondition(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeProgressing)
+		Expect(progressing.Reason).To(Equal(racecoursev1beta1.ReasonWaitingForWallet))
+		Expect(progressing.Message).To(ContainSubstring("does not exist"))
+
+		By("rolling out once the wallet is ready")
+		Expect(c.Create(ctx, service)).To(Succeed())
+		addEndpoint(true)
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, key, &appsv1.Deployment{})).To(Succeed())
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeWalletReachable)).To(BeTrue())
+
+		By("keeping the Deployment when the wallet goes away later")
+		Expect(c.Delete(ctx, service)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, key, &appsv1.Deployment{})).To(Succeed())
+		Expect(c.Get(ctx, key, racecourse)).To(Succeed())
+		Expect(meta.IsStatusConditionFalse(racecourse.Status.Conditions, racecoursev1beta1.ConditionTypeWalletReachable)).To(BeTrue())
+	})
+
+	It("should back off the longer the wallet has been failing", func() {
+		now := time.Now()
+		Expect(walletGateBackoff(racecourse, now)).To(Equal(walletGateMinBackoff))
+
+		racecourse.Status.Conditions = []metav1.Condition{{
+			Type:               racecoursev1beta1.ConditionTypeWalletReachable,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+		}}
+		Expect(walletGateBackoff(racecourse, now)).To(Equal(time.Minute))
+
+		racecourse.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Hour))
+		Expect(walletGateBackoff(racecourse, now)).To(Equal(walletGateMaxBackoff))
+	})
+})
+
+// Sends every request to the server at the URL, whatever host it was addressed to
+type redirectTo string
+
+func (target redirectTo) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = strings.TrimPrefix(string(target), "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethrpc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 hashes data the way Ethereum does
+func Keccak256(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hash.Write(d)
+	}
+	return hash.Sum(nil)
+}
+
+// CreateAddress returns the lower case hex address of the contract an account creates
+// with a transaction sent with the nonce: the last 20 bytes of the hash of the RLP
+// encoded list of the account and the nonce
+func CreateAddress(from string, nonce uint64) (string, error) {
+	sender, err := DecodeHex(from)
+	if err != nil || len(sender) != 20 {
+		return "", fmt.Errorf("invalid address %q", from)
+	}
+
+	// An integer is encoded as its big endian bytes without leading zeros, and zero as
+	// the empty string. Both items are short, so each takes a one byte prefix.
+	var encodedNonce []byte
+	switch {
+	case nonce == 0:
+		encodedNonce = []byte{0x80}
+	case nonce < 0x80:
+		encodedNonce = []byte{byte(nonce)}
+	default:
+		digits := new(big.Int).SetUint64(nonce).Bytes()
+		encodedNonce = append([]byte{0x80 + byte(len(digits))}, digits...)
+	}
+	list := append(append([]byte{0x80 + 20}, sender...), encodedNonce...)
+	return EncodeHex(Keccak256(append([]byte{0xc0 + byte(len(list))}, list...))[12:]), nil
+}
+
+// Selector returns the four byte selector of a function signature like "bets(uint256)"
+func Selector(signature string) []byte {
+	return Keccak256([]byte(signature))[:4]
+}
+
+// EventTopic returns the topic that identifies an event signature like "betPlaced()" in logs
+func EventTopic(signature string) string {
+	return EncodeHex(Keccak256([]byte(signature)))
+}
+
+// EncodeCall ABI encodes a call to a function that only takes unsigned integers
+func EncodeCall(signature string, args ...*big.Int) []byte {
+	data := Selector(signature)
+	for _, arg := range args {
+		data = append(data, EncodeUint(arg)...)
+	}
+	return data
+}
+
+// EncodeUint encodes an unsigned integer as a 32-byte ABI word
+func EncodeUint(value *big.Int) []byte {
+	return value.FillBytes(make([]byte, 32))
+}
+
+// EncodeHex encodes data as a 0x-prefixed hex string
+func EncodeHex(data []byte) string {
+	return "0x" + hex.EncodeToString(data)
+}
+
+// DecodeHex decodes a 0x-prefixed hex string
+func DecodeHex(value string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+}
+
+// Result reads the ABI encoded output of a contract call
+type Result []byte
+
+// Uint reads a word of the result as an unsigned integer
+func (r Result) Uint(index int) (*big.Int, error) {
+	word, err := r.word(index)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(word), nil
+}
+
+// Bool reads a word of the result as a bool
+func (r Result) Bool(index int) (bool, error) {
+	value, err := r.Uint(index)
+	if err != nil {
+		return false, err
+	}
+	return value.Sign() != 0, nil
+}
+
+// Address reads a word of the result as a lower case hex address
+func (r Result) Address(index int) (string, error) {
+	word, err := r.word(index)
+	if err != nil {
+		return "", err
+	}
+	return EncodeHex(word[12:]), nil
+}
+
+// String reads a dynamic string whose offset is held in a word of the result
+func (r Result) String(index int) (string, error) {
+	offset, err := r.Uint(index)
+	if err != nil {
+		return "", err
+	}
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(r)) {
+		return "", fmt.Errorf("string offset %s out of range", offset)
+	}
+	start := int(offset.Int64())
+	length := new(big.Int).SetBytes(r[start : start+32])
+	if !length.IsInt64() || int64(start+32)+length.Int64() > int64(len(r)) {
+		return "", fmt.Errorf("string length %s out of range", length)
+	}
+	return string(r[start+32 : start+32+int(length.Int64())]), nil
+}
+
+func (r Result) word(index int) ([]byte, error) {
+	if len(r) < (index+1)*32 {
+		return nil, fmt.Errorf("result of %d bytes has no word %d", len(r), index)
+	}
+	return r[index*32 : (index+1)*32], nil
+}
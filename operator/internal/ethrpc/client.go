@@ -0,0 +1,499 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ethrpc is a small Ethereum JSON-RPC client for probing wallets and chains.
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults used unless overridden by an Option
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultRetries      = 2
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// The block tag for the latest block
+const Latest = "latest"
+
+// The block tag for the latest block together with the transactions waiting to be mined
+const PendingBlock = "pending"
+
+// Client makes JSON-RPC calls to a single Ethereum endpoint
+type Client struct {
+	url          string
+	httpClient   *http.Client
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+	nextID       atomic.Int64
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithTimeout limits how long each attempt of a call may take
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets how many times a call is retried when the endpoint can't be reached
+// or fails with a server error, and the delay before the first retry, which doubles
+// with every attempt
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a client for the JSON-RPC endpoint at the URL
+func New(url string, opts ...Option) *Client {
+	c := &Client{
+		url:          url,
+		httpClient:   http.DefaultClient,
+		timeout:      DefaultTimeout,
+		retries:      DefaultRetries,
+		retryBackoff: DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// URL returns the endpoint the client calls
+func (c *Client) URL() string {
+	return c.url
+}
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// Methods that do something new every time they're sent, so they're never retried: a
+// call that timed out or failed with a server error may still have been carried out
+var nonIdempotent = map[string]bool{
+	"eth_sendTransaction":    true,
+	"eth_sendRawTransaction": true,
+}
+
+// Call invokes a JSON-RPC method and decodes its result into result, retrying when
+// the endpoint can't be reached or fails with a server error, unless the method
+// isn't safe to send twice
+func (c *Client) Call(ctx context.Context, result any, method string, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.call(ctx, result, method, params)
+		if err == nil || attempt >= c.retries || nonIdempotent[method] || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) call(ctx context.Context, result any, method string, params []any) error {
+	body, err := json.Marshal(request{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return &ConnectionError{Method: method, Err: err}
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode != http.StatusOK {
+		// Drain a little of the body so the connection can be reused
+		_, _ = io.CopyN(io.Discard, res.Body, 4096)
+		return &HTTPError{Method: method, StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	decoded := &response{}
+	if err := json.NewDecoder(res.Body).Decode(decoded); err != nil {
+		return &InvalidResponseError{Method: method, Err: err}
+	}
+	if decoded.Error != nil {
+		decoded.Error.Method = method
+		return decoded.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(decoded.Result, result); err != nil {
+		return &InvalidResponseError{Method: method, Err: err}
+	}
+	return nil
+}
+
+// ChainID returns the chain ID the endpoint signs transactions for
+func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
+	return c.callQuantity(ctx, "eth_chainId")
+}
+
+// BlockNumber returns the number of the latest block
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	number, err := c.callQuantity(ctx, "eth_blockNumber")
+	if err != nil {
+		return 0, err
+	}
+	if !number.IsUint64() {
+		return 0, &InvalidResponseError{Method: "eth_blockNumber", Err: fmt.Errorf("block number %s out of range", number)}
+	}
+	return number.Uint64(), nil
+}
+
+// Syncing reports whether the node is still catching up with the chain. eth_syncing
+// returns false once it has, and an object describing its progress until then.
+func (c *Client) Syncing(ctx context.Context) (bool, error) {
+	var result json.RawMessage
+	if err := c.Call(ctx, &result, "eth_syncing"); err != nil {
+		return false, err
+	}
+	var synced bool
+	if err := json.Unmarshal(result, &synced); err == nil {
+		return synced, nil
+	}
+	var progress map[string]any
+	if err := json.Unmarshal(result, &progress); err != nil {
+		return false, &InvalidResponseError{Method: "eth_syncing", Err: err}
+	}
+	return true, nil
+}
+
+// PeerCount returns the number of peers the node is connected to
+func (c *Client) PeerCount(ctx context.Context) (uint64, error) {
+	count, err := c.callQuantity(ctx, "net_peerCount")
+	if err != nil {
+		return 0, err
+	}
+	if !count.IsUint64() {
+		return 0, &InvalidResponseError{Method: "net_peerCount", Err: fmt.Errorf("peer count %s out of range", count)}
+	}
+	return count.Uint64(), nil
+}
+
+// TransactionCount returns the number of transactions an account has sent as of a block,
+// which is the nonce of its next transaction. The "pending" block tag counts those
+// waiting to be mined as well.
+func (c *Client) TransactionCount(ctx context.Context, address, block string) (uint64, error) {
+	count, err := c.callQuantity(ctx, "eth_getTransactionCount", address, block)
+	if err != nil {
+		return 0, err
+	}
+	if !count.IsUint64() {
+		return 0, &InvalidResponseError{Method: "eth_getTransactionCount", Err: fmt.Errorf("transaction count %s out of range", count)}
+	}
+	return count.Uint64(), nil
+}
+
+// Accounts returns the addresses the endpoint can sign for
+func (c *Client) Accounts(ctx context.Context) ([]string, error) {
+	var accounts []string
+	if err := c.Call(ctx, &accounts, "eth_accounts"); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// GetCode returns the runtime bytecode at an address, which is empty for an account
+// that isn't a contract
+func (c *Client) GetCode(ctx context.Context, address, block string) ([]byte, error) {
+	return c.callData(ctx, "eth_getCode", address, block)
+}
+
+// CallMsg describes a contract call that isn't sent as a transaction
+type CallMsg struct {
+	From string
+	To   string
+	Data []byte
+}
+
+// EthCall executes a contract call against the state at a block and returns its output
+func (c *Client) EthCall(ctx context.Context, msg CallMsg, block string) ([]byte, error) {
+	call := map[string]string{"to": msg.To, "data": EncodeHex(msg.Data)}
+	if msg.From != "" {
+		call["from"] = msg.From
+	}
+	return c.callData(ctx, "eth_call", call, block)
+}
+
+func (c *Client) callQuantity(ctx context.Context, method string, params ...any) (*big.Int, error) {
+	var result string
+	if err := c.Call(ctx, &result, method, params...); err != nil {
+		return nil, err
+	}
+	quantity, err := DecodeQuantity(result)
+	if err != nil {
+		return nil, &InvalidResponseError{Method: method, Err: err}
+	}
+	return quantity, nil
+}
+
+func (c *Client) callData(ctx context.Context, method string, params ...any) ([]byte, error) {
+	var result string
+	if err := c.Call(ctx, &result, method, params...); err != nil {
+		return nil, err
+	}
+	data, err := DecodeHex(result)
+	if err != nil {
+		return nil, &InvalidResponseError{Method: method, Err: err}
+	}
+	return data, nil
+}
+
+// BlockTag formats a block number as a JSON-RPC block parameter
+func BlockTag(number uint64) string {
+	return fmt.Sprintf("0x%x", number)
+}
+
+// DecodeQuantity decodes a 0x-prefixed hex quantity
+func DecodeQuantity(value string) (*big.Int, error) {
+	digits, ok := strings.CutPrefix(value, "0x")
+	if !ok || digits == "" {
+		return nil, fmt.Errorf("invalid quantity %q", value)
+	}
+	quantity, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity %q", value)
+	}
+	return quantity, nil
+}
+
+// Reports whether a failed call is worth retrying
+func retryable(err error) bool {
+	var connectionErr *ConnectionError
+	if errors.As(err, &connectionErr) {
+		return !errors.Is(err, context.Canceled)
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// TransactionArgs describes a transaction for the endpoint to sign and send
+type TransactionArgs struct {
+	From     string
+	To       string
+	Data     []byte
+	Gas      uint64
+	GasPrice *big.Int
+	// The nonce to send the transaction with, rather than the endpoint's choice
+	Nonce *uint64
+}
+
+// SendTransaction asks the endpoint to sign and send a transaction from one of its
+// accounts, and returns the transaction hash. It is sent once whatever the client's
+// retries, since a failed attempt may still have reached the chain.
+func (c *Client) SendTransaction(ctx context.Context, args TransactionArgs) (string, error) {
+	tx := map[string]string{"from": args.From, "data": EncodeHex(args.Data)}
+	if args.To != "" {
+		tx["to"] = args.To
+	}
+	if args.Gas != 0 {
+		tx["gas"] = BlockTag(args.Gas)
+	}
+	if args.GasPrice != nil {
+		tx["gasPrice"] = "0x" + args.GasPrice.Text(16)
+	}
+	if args.Nonce != nil {
+		tx["nonce"] = BlockTag(*args.Nonce)
+	}
+
+	var hash string
+	if err := c.Call(ctx, &hash, "eth_sendTransaction", tx); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// Receipt is the outcome of a mined transaction
+type Receipt struct {
+	TransactionHash string
+	BlockNumber     uint64
+	// The address of the contract the transaction created, if any
+	ContractAddress string
+	// Whether the transaction succeeded
+	Succeeded bool
+}
+
+// TransactionReceipt returns the receipt of a transaction, or nil if it hasn't been mined yet
+func (c *Client) TransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	var result *struct {
+		TransactionHash string  `json:"transactionHash"`
+		BlockNumber     string  `json:"blockNumber"`
+		ContractAddress *string `json:"contractAddress"`
+		Status          string  `json:"status"`
+	}
+	if err := c.Call(ctx, &result, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	blockNumber, err := DecodeQuantity(result.BlockNumber)
+	if err != nil || !blockNumber.IsUint64() {
+		return nil, &InvalidResponseError{Method: "eth_getTransactionReceipt", Err: fmt.Errorf("invalid block number %q", result.BlockNumber)}
+	}
+	receipt := &Receipt{
+		TransactionHash: result.TransactionHash,
+		BlockNumber:     blockNumber.Uint64(),
+		// Chains from before Byzantium don't report a status, so count those as succeeded
+		Succeeded: result.Status != "0x0",
+	}
+	if result.ContractAddress != nil {
+		receipt.ContractAddress = *result.ContractAddress
+	}
+	return receipt, nil
+}
+
+// BlockHash returns the hash of the block with the number
+func (c *Client) BlockHash(ctx context.Context, number uint64) (string, error) {
+	var block *struct {
+		Hash string `json:"hash"`
+	}
+	if err := c.Call(ctx, &block, "eth_getBlockByNumber", BlockTag(number), false); err != nil {
+		return "", err
+	}
+	if block == nil || block.Hash == "" {
+		return "", &InvalidResponseError{Method: "eth_getBlockByNumber", Err: fmt.Errorf("block %d not found", number)}
+	}
+	return block.Hash, nil
+}
+
+// FilterQuery selects the logs to return from a range of blocks
+type FilterQuery struct {
+	FromBlock uint64
+	ToBlock   uint64
+	// The contract that emitted the logs
+	Address string
+	// Matches logs whose first topic is any of these
+	Topics []string
+}
+
+// Log is an event emitted by a contract
+type Log struct {
+	Address         string
+	Topics          []string
+	Data            []byte
+	BlockNumber     uint64
+	TransactionHash string
+	LogIndex        uint64
+}
+
+// GetLogs returns the logs matching the query, in the order they were emitted
+func (c *Client) GetLogs(ctx context.Context, query FilterQuery) ([]Log, error) {
+	filter := map[string]any{
+		"fromBlock": BlockTag(query.FromBlock),
+		"toBlock":   BlockTag(query.ToBlock),
+		"address":   query.Address,
+	}
+	if len(query.Topics) > 0 {
+		filter["topics"] = [][]string{query.Topics}
+	}
+
+	var results []struct {
+		Address         string   `json:"address"`
+		Topics          []string `json:"topics"`
+		Data            string   `json:"data"`
+		BlockNumber     string   `json:"blockNumber"`
+		TransactionHash string   `json:"transactionHash"`
+		LogIndex        string   `json:"logIndex"`
+		Removed         bool     `json:"removed"`
+	}
+	if err := c.Call(ctx, &results, "eth_getLogs", filter); err != nil {
+		return nil, err
+	}
+
+	logs := make([]Log, 0, len(results))
+	for _, result := range results {
+		if result.Removed {
+			continue
+		}
+		data, err := DecodeHex(result.Data)
+		if err != nil {
+			return nil, &InvalidResponseError{Method: "eth_getLogs", Err: err}
+		}
+		blockNumber, err := DecodeQuantity(result.BlockNumber)
+		if err != nil || !blockNumber.IsUint64() {
+			return nil, &InvalidResponseError{Method: "eth_getLogs", Err: fmt.Errorf("invalid block number %q", result.BlockNumber)}
+		}
+		logIndex, err := DecodeQuantity(result.LogIndex)
+		if err != nil || !logIndex.IsUint64() {
+			return nil, &InvalidResponseError{Method: "eth_getLogs", Err: fmt.Errorf("invalid log index %q", result.LogIndex)}
+		}
+		logs = append(logs, Log{
+			Address:         result.Address,
+			Topics:          result.Topics,
+			Data:            data,
+			BlockNumber:     blockNumber.Uint64(),
+			TransactionHash: result.TransactionHash,
+			LogIndex:        logIndex.Uint64(),
+		})
+	}
+	return logs, nil
+}
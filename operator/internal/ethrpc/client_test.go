@@ -0,0 +1,413 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// A JSON-RPC request as seen by the fake node
+type fakeRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      int64             `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// Serves JSON-RPC requests with the handler, which returns either a result or an error
+func fakeNode(handler func(request *fakeRequest) (any, *RPCError)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer GinkgoRecover()
+		request := &fakeRequest{}
+		Expect(json.NewDecoder(r.Body).Decode(request)).To(Succeed())
+		Expect(request.JSONRPC).To(Equal("2.0"))
+
+		response := map[string]any{"jsonrpc": "2.0", "id": request.ID}
+		result, rpcErr := handler(request)
+		if rpcErr != nil {
+			response["error"] = rpcErr
+		} else {
+			response["result"] = result
+		}
+		Expect(json.NewEncoder(w).Encode(response)).To(Succeed())
+	}))
+}
+
+var _ = Describe("Client", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Context("against a healthy node", func() {
+		var client *Client
+
+		BeforeEach(func() {
+			server := fakeNode(func(request *fakeRequest) (any, *RPCError) {
+				switch request.Method {
+				case "eth_chainId":
+					return "0x539", nil
+				case "eth_blockNumber":
+					return "0x2a", nil
+				case "eth_accounts":
+					return []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}, nil
+				case "eth_syncing":
+					return false, nil
+				case "net_peerCount":
+					return "0x3", nil
+				case "eth_getCode":
+					Expect(string(request.Params[0])).To(Equal(`"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`))
+					Expect(string(request.Params[1])).To(Equal(`"latest"`))
+					return "0x6080604052", nil
+				case "eth_call":
+					call := map[string]string{}
+					Expect(json.Unmarshal(request.Params[0], &call)).To(Succeed())
+					Expect(call).To(HaveKeyWithValue("to", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+					Expect(call).To(HaveKeyWithValue("data", EncodeHex(EncodeCall("horses(uint256)", big.NewInt(1)))))
+					Expect(call).NotTo(HaveKey("from"))
+					Expect(string(request.Params[1])).To(Equal(`"0x2a"`))
+					return EncodeHex(EncodeUint(big.NewInt(7))), nil
+				case "eth_getBlockByNumber":
+					Expect(string(request.Params[0])).To(Equal(`"0x0"`))
+					Expect(string(request.Params[1])).To(Equal(`false`))
+					return map[string]any{"number": "0x0", "hash": "0x" + strings.Repeat("ab", 32)}, nil
+				case "eth_getLogs":
+					filter := map[string]any{}
+					Expect(json.Unmarshal(request.Params[0], &filter)).To(Succeed())
+					Expect(filter).To(Equal(map[string]any{
+						"fromBlock": "0x0",
+						"toBlock":   "0x2a",
+						"address":   "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+						"topics":    []any{[]any{EventTopic("betPlaced()")}},
+					}))
+					return []map[string]any{
+						{
+							"address":         "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+							"topics":          []string{EventTopic("betPlaced()")},
+							"data":            "0x",
+							"blockNumber":     "0x7",
+							"transactionHash": "0x03",
+							"logIndex":        "0x1",
+							"removed":         false,
+						},
+						{
+							"address":         "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+							"topics":          []string{EventTopic("betPlaced()")},
+							"data":            "0x",
+							"blockNumber":     "0x8",
+							"transactionHash": "0x04",
+							"logIndex":        "0x0",
+							"removed":         true,
+						},
+					}, nil
+				case "eth_sendTransaction":
+					tx := map[string]string{}
+					Expect(json.Unmarshal(request.Params[0], &tx)).To(Succeed())
+					Expect(tx).To(Equal(map[string]string{
+						"from":     "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+						"data":     "0x6080",
+						"gas":      "0x4c4b40",
+						"gasPrice": "0x0",
+					}))
+					return "0x01", nil
+				case "eth_getTransactionCount":
+					Expect(string(request.Params[0])).To(Equal(`"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`))
+					if string(request.Params[1]) == `"pending"` {
+						return "0x8", nil
+					}
+					return "0x7", nil
+				case "eth_getTransactionReceipt":
+					if string(request.Params[0]) == `"0x02"` {
+						return nil, nil
+					}
+					return map[string]any{
+						"transactionHash": "0x01",
+						"blockNumber":     "0x2a",
+						"contractAddress": "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+						"status":          "0x1",
+					}, nil
+				}
+				return nil, &RPCError{Code: -32601, Message: "method not found"}
+			})
+			DeferCleanup(server.Close)
+			client = New(server.URL)
+		})
+
+		It("should get the chain ID", func() {
+			chainID, err := client.ChainID(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chainID.Int64()).To(Equal(int64(1337)))
+		})
+
+		It("should get the latest block number", func() {
+			Expect(client.BlockNumber(ctx)).To(Equal(uint64(42)))
+		})
+
+		It("should report a node that has caught up and its peers", func() {
+			Expect(client.Syncing(ctx)).To(BeFalse())
+			Expect(client.PeerCount(ctx)).To(Equal(uint64(3)))
+		})
+
+		It("should list the accounts", func() {
+			Expect(client.Accounts(ctx)).To(ConsistOf("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+		})
+
+		It("should get the hash of a block", func() {
+			Expect(client.BlockHash(ctx, 0)).To(Equal("0x" + strings.Repeat("ab", 32)))
+		})
+
+		It("should get the code at an address", func() {
+			code, err := client.GetCode(ctx, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Latest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hex.EncodeToString(code)).To(Equal("6080604052"))
+		})
+
+		It("should call a contract at a block", func() {
+			msg := CallMsg{To: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Data: EncodeCall("horses(uint256)", big.NewInt(1))}
+			output, err := client.EthCall(ctx, msg, BlockTag(42))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Result(output).Uint(0)).To(Equal(big.NewInt(7)))
+		})
+
+		It("should send a transaction and get its receipt", func() {
+			hash, err := client.SendTransaction(ctx, TransactionArgs{
+				From:     "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+				Data:     []byte{0x60, 0x80},
+				Gas:      5000000,
+				GasPrice: big.NewInt(0),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hash).To(Equal("0x01"))
+
+			receipt, err := client.TransactionReceipt(ctx, hash)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(receipt).To(Equal(&Receipt{
+				TransactionHash: "0x01",
+				BlockNumber:     42,
+				ContractAddress: "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+				Succeeded:       true,
+			}))
+		})
+
+		It("should count an account's transactions with and without the pending ones", func() {
+			Expect(client.TransactionCount(ctx, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Latest)).To(Equal(uint64(7)))
+			Expect(client.TransactionCount(ctx, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", PendingBlock)).To(Equal(uint64(8)))
+		})
+
+		It("should get the logs in a range of blocks, leaving out removed ones", func() {
+			logs, err := client.GetLogs(ctx, FilterQuery{
+				FromBlock: 0,
+				ToBlock:   42,
+				Address:   "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+				Topics:    []string{EventTopic("betPlaced()")},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(Equal([]Log{{
+				Address:         "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+				Topics:          []string{EventTopic("betPlaced()")},
+				Data:            []byte{},
+				BlockNumber:     7,
+				TransactionHash: "0x03",
+				LogIndex:        1,
+			}}))
+		})
+
+		It("should return no receipt for a transaction that hasn't been mined", func() {
+			Expect(client.TransactionReceipt(ctx, "0x02")).To(BeNil())
+		})
+
+		It("should return JSON-RPC errors as RPCErrors", func() {
+			err := client.Call(ctx, nil, "eth_mining")
+			rpcErr := &RPCError{}
+			Expect(errors.As(err, &rpcErr)).To(BeTrue())
+			Expect(rpcErr.Code).To(Equal(-32601))
+			Expect(rpcErr.Method).To(Equal("eth_mining"))
+			Expect(IsUnreachable(err)).To(BeFalse())
+		})
+	})
+
+	It("should retry server errors and then succeed", func() {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+		}))
+		DeferCleanup(server.Close)
+
+		chainID, err := New(server.URL, WithRetries(2, time.Millisecond)).ChainID(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chainID.Int64()).To(Equal(int64(1)))
+		Expect(requests.Load()).To(Equal(int32(3)))
+	})
+
+	It("should give up once the retries are used up", func() {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		DeferCleanup(server.Close)
+
+		_, err := New(server.URL, WithRetries(1, time.Millisecond)).ChainID(ctx)
+		httpErr := &HTTPError{}
+		Expect(errors.As(err, &httpErr)).To(BeTrue())
+		Expect(httpErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(IsUnreachable(err)).To(BeTrue())
+		Expect(requests.Load()).To(Equal(int32(2)))
+	})
+
+	It("should not retry JSON-RPC errors", func() {
+		var requests atomic.Int32
+		server := fakeNode(func(*fakeRequest) (any, *RPCError) {
+			requests.Add(1)
+			return nil, &RPCError{Code: -32000, Message: "execution reverted"}
+		})
+		DeferCleanup(server.Close)
+
+		_, err := New(server.URL, WithRetries(3, time.Millisecond)).EthCall(ctx, CallMsg{To: "0x00"}, Latest)
+		Expect(err).To(MatchError(ContainSubstring("execution reverted")))
+		Expect(requests.Load()).To(Equal(int32(1)))
+	})
+
+	It("should send a transaction once when the node fails with a server error", func() {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		DeferCleanup(server.Close)
+
+		_, err := New(server.URL, WithRetries(3, time.Millisecond)).SendTransaction(ctx, TransactionArgs{From: "0x01"})
+		Expect(IsUnreachable(err)).To(BeTrue())
+		Expect(requests.Load()).To(Equal(int32(1)))
+	})
+
+	It("should send a transaction once when the node times out", func() {
+		var requests atomic.Int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			<-release
+		}))
+		DeferCleanup(server.Close)
+		DeferCleanup(func() { close(release) })
+
+		_, err := New(server.URL, WithTimeout(50*time.Millisecond), WithRetries(3, time.Millisecond)).
+			SendTransaction(ctx, TransactionArgs{From: "0x01"})
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(requests.Load()).To(Equal(int32(1)))
+	})
+
+	It("should time out a node that doesn't answer", func() {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		DeferCleanup(server.Close)
+		DeferCleanup(func() { close(release) })
+
+		_, err := New(server.URL, WithTimeout(50*time.Millisecond), WithRetries(0, 0)).BlockNumber(ctx)
+		connectionErr := &ConnectionError{}
+		Expect(errors.As(err, &connectionErr)).To(BeTrue())
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(IsUnreachable(err)).To(BeTrue())
+	})
+
+	It("should report a node that isn't listening as unreachable", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+
+		_, err := New(url, WithRetries(1, time.Millisecond)).Accounts(ctx)
+		Expect(IsUnreachable(err)).To(BeTrue())
+	})
+
+	It("should report a node that is still syncing", func() {
+		server := fakeNode(func(request *fakeRequest) (any, *RPCError) {
+			Expect(request.Method).To(Equal("eth_syncing"))
+			return map[string]string{"startingBlock": "0x0", "currentBlock": "0x10", "highestBlock": "0x2a"}, nil
+		})
+		DeferCleanup(server.Close)
+		Expect(New(server.URL).Syncing(ctx)).To(BeTrue())
+	})
+
+	It("should reject answers that aren't valid quantities", func() {
+		server := fakeNode(func(*fakeRequest) (any, *RPCError) {
+			return "1337", nil
+		})
+		DeferCleanup(server.Close)
+
+		_, err := New(server.URL).ChainID(ctx)
+		invalidErr := &InvalidResponseError{}
+		Expect(errors.As(err, &invalidErr)).To(BeTrue())
+		Expect(IsUnreachable(err)).To(BeFalse())
+	})
+})
+
+var _ = Describe("ABI helpers", func() {
+	It("should compute the address of a contract from its creator and nonce", func() {
+		for nonce, address := range []string{
+			"0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d",
+			"0x343c43a37d37dff08ae8c4a11544c718abb4fcf8",
+			"0xf778b86fa74e846c4f0a1fbd1335fe81c00a0c91",
+			"0xfffd933a0bc612844eaf0c6fe3e5b8e9b6c1d19c",
+		} {
+			Expect(CreateAddress("0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0", uint64(nonce))).To(Equal(address))
+		}
+		_, err := CreateAddress("0x6ac7", 0)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should compute function selectors", func() {
+		Expect(hex.EncodeToString(Selector("transfer(address,uint256)"))).To(Equal("a9059cbb"))
+	})
+
+	It("should decode words, addresses and strings", func() {
+		name := make([]byte, 32)
+		copy(name, "Secretariat")
+		data := append(EncodeUint(big.NewInt(1)), EncodeUint(big.NewInt(64))...)
+		data = append(data, EncodeUint(big.NewInt(11))...)
+		data = append(data, name...)
+		result := Result(data)
+
+		Expect(result.Uint(0)).To(Equal(big.NewInt(1)))
+		Expect(result.Bool(0)).To(BeTrue())
+		Expect(result.String(1)).To(Equal("Secretariat"))
+		Expect(result.Address(0)).To(Equal("0x0000000000000000000000000000000000000001"))
+
+		_, err := result.Uint(4)
+		Expect(err).To(HaveOccurred())
+		_, err = Result(EncodeUint(big.NewInt(4096))).String(0)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ConnectionError is returned when the endpoint can't be reached, or doesn't answer in time
+type ConnectionError struct {
+	Method string
+	Err    error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("%s: unable to reach endpoint: %v", e.Method, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPError is returned when the endpoint answers with an HTTP status other than 200 OK
+type HTTPError struct {
+	Method     string
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: unexpected HTTP status %s", e.Method, e.Status)
+}
+
+// RPCError is a JSON-RPC error returned by the endpoint
+type RPCError struct {
+	Method  string          `json:"-"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: error %d: %s", e.Method, e.Code, e.Message)
+}
+
+// InvalidResponseError is returned when the endpoint's answer can't be decoded
+type InvalidResponseError struct {
+	Method string
+	Err    error
+}
+
+func (e *InvalidResponseError) Error() string {
+	return fmt.Sprintf("%s: invalid response: %v", e.Method, e.Err)
+}
+
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+// IsUnreachable reports whether the error means nothing usable answered at the endpoint,
+// as opposed to the endpoint rejecting the call
+func IsUnreachable(err error) bool {
+	var connectionErr *ConnectionError
+	var httpErr *HTTPError
+	return errors.As(err, &connectionErr) || errors.As(err, &httpErr)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethrpc
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/net/websocket"
+)
+
+// Header is the part of a block header that subscribers look at
+type Header struct {
+	Number    uint64
+	Hash      string
+	LogsBloom []byte
+}
+
+// MayContainLogsFrom reports whether the block may hold logs emitted by the address.
+// A block without a bloom may hold anything.
+func (h Header) MayContainLogsFrom(address string) bool {
+	if len(h.LogsBloom) != 256 {
+		return true
+	}
+	raw, err := DecodeHex(address)
+	if err != nil {
+		return true
+	}
+	// Each value sets three of the bloom's 2048 bits, picked by the first three pairs
+	// of bytes of its hash
+	hash := Keccak256(raw)
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+		if h.LogsBloom[255-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// HeadSubscription receives the header of every new block from a WebSocket endpoint
+type HeadSubscription struct {
+	conn *websocket.Conn
+	stop func() bool
+}
+
+// SubscribeNewHeads opens a WebSocket connection to the endpoint and subscribes to new
+// block headers. The connection is closed when the context is done.
+func SubscribeNewHeads(ctx context.Context, url string) (*HeadSubscription, error) {
+	config, err := websocket.NewConfig(url, "http://localhost/")
+	if err != nil {
+		return nil, &ConnectionError{Method: "eth_subscribe", Err: err}
+	}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, &ConnectionError{Method: "eth_subscribe", Err: err}
+	}
+	s := &HeadSubscription{conn: conn, stop: context.AfterFunc(ctx, func() { _ = conn.Close() })}
+
+	req := request{JSONRPC: "2.0", ID: 1, Method: "eth_subscribe", Params: []any{"newHeads"}}
+	if err := websocket.JSON.Send(conn, req); err != nil {
+		s.Close() //nolint:errcheck
+		return nil, &ConnectionError{Method: "eth_subscribe", Err: err}
+	}
+	res := &response{}
+	if err := websocket.JSON.Receive(conn, res); err != nil {
+		s.Close() //nolint:errcheck
+		return nil, &ConnectionError{Method: "eth_subscribe", Err: err}
+	}
+	if res.Error != nil {
+		s.Close() //nolint:errcheck
+		res.Error.Method = "eth_subscribe"
+		return nil, res.Error
+	}
+	return s, nil
+}
+
+// Next waits for the header of the next block
+func (s *HeadSubscription) Next() (Header, error) {
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Result struct {
+				Number    string `json:"number"`
+				Hash      string `json:"hash"`
+				LogsBloom string `json:"logsBloom"`
+			} `json:"result"`
+		} `json:"params"`
+	}
+	for {
+		if err := websocket.JSON.Receive(s.conn, &notification); err != nil {
+			return Header{}, &ConnectionError{Method: "eth_subscription", Err: err}
+		}
+		if notification.Method != "eth_subscription" {
+			continue
+		}
+
+		result := notification.Params.Result
+		number, err := DecodeQuantity(result.Number)
+		if err != nil || !number.IsUint64() {
+			return Header{}, &InvalidResponseError{Method: "eth_subscription", Err: fmt.Errorf("invalid block number %q", result.Number)}
+		}
+		header := Header{Number: number.Uint64(), Hash: result.Hash}
+		if result.LogsBloom != "" {
+			if header.LogsBloom, err = DecodeHex(result.LogsBloom); err != nil {
+				return Header{}, &InvalidResponseError{Method: "eth_subscription", Err: err}
+			}
+		}
+		return header, nil
+	}
+}
+
+// Close closes the connection
+func (s *HeadSubscription) Close() error {
+	s.stop()
+	return s.conn.Close()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ethrpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/websocket"
+)
+
+// Answers eth_subscribe with the error, if any, and otherwise sends the headers
+func fakeHeadsNode(rpcErr *RPCError, headers ...map[string]string) *httptest.Server {
+	return httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		defer GinkgoRecover()
+		request := &fakeRequest{}
+		Expect(websocket.JSON.Receive(conn, request)).To(Succeed())
+		Expect(request.Method).To(Equal("eth_subscribe"))
+		Expect(string(request.Params[0])).To(Equal(`"newHeads"`))
+
+		if rpcErr != nil {
+			Expect(websocket.JSON.Send(conn, map[string]any{"jsonrpc": "2.0", "id": request.ID, "error": rpcErr})).To(Succeed())
+			return
+		}
+		Expect(websocket.JSON.Send(conn, map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": "0x1"})).To(Succeed())
+		for _, header := range headers {
+			Expect(websocket.JSON.Send(conn, map[string]any{
+				"jsonrpc": "2.0",
+				"method":  "eth_subscription",
+				"params":  map[string]any{"subscription": "0x1", "result": header},
+			})).To(Succeed())
+		}
+	}))
+}
+
+// The URL of the WebSocket endpoint served by the test server
+func webSocketURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// Sets the bits of the logs bloom for the address the way a node does
+func addToBloom(bloom []byte, address string) {
+	raw, err := DecodeHex(address)
+	Expect(err).NotTo(HaveOccurred())
+	hash := Keccak256(raw)
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+		bloom[255-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+var _ = Describe("New heads subscription", func() {
+	const address = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+
+	It("should receive block headers until the node goes away", func() {
+		bloom := make([]byte, 256)
+		addToBloom(bloom, address)
+		server := fakeHeadsNode(nil,
+			map[string]string{"number": "0x2a", "hash": "0xabc", "logsBloom": EncodeHex(bloom)},
+			map[string]string{"number": "0x2b", "hash": "0xdef"},
+		)
+		DeferCleanup(server.Close)
+
+		subscription, err := SubscribeNewHeads(context.Background(), webSocketURL(server))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(subscription.Close)
+
+		header, err := subscription.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Number).To(Equal(uint64(42)))
+		Expect(header.Hash).To(Equal("0xabc"))
+		Expect(header.MayContainLogsFrom(address)).To(BeTrue())
+		Expect(header.MayContainLogsFrom("0x0000000000000000000000000000000000000001")).To(BeFalse())
+
+		header, err = subscription.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Number).To(Equal(uint64(43)))
+		Expect(header.MayContainLogsFrom(address)).To(BeTrue())
+
+		_, err = subscription.Next()
+		Expect(IsUnreachable(err)).To(BeTrue())
+	})
+
+	It("should stop when the context is done", func() {
+		server := fakeHeadsNode(nil)
+		DeferCleanup(server.Close)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		subscription, err := SubscribeNewHeads(ctx, webSocketURL(server))
+		Expect(err).NotTo(HaveOccurred())
+		cancel()
+		_, err = subscription.Next()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the node's error when it can't subscribe", func() {
+		server := fakeHeadsNode(&RPCError{Code: -32601, Message: "Method not found"})
+		DeferCleanup(server.Close)
+
+		_, err := SubscribeNewHeads(context.Background(), webSocketURL(server))
+		rpcErr := &RPCError{}
+		Expect(errors.As(err, &rpcErr)).To(BeTrue())
+		Expect(rpcErr.Method).To(Equal("eth_subscribe"))
+	})
+
+	It("should fail to subscribe to an endpoint that isn't there", func() {
+		server := fakeHeadsNode(nil)
+		server.Close()
+
+		_, err := SubscribeNewHeads(context.Background(), webSocketURL(server))
+		Expect(IsUnreachable(err)).To(BeTrue())
+	})
+})
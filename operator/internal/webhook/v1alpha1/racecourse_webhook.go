@@ -0,0 +1,31 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+)
+
+// SetupRacecourseWebhookWithManager registers the webhook for Racecourse in the manager.
+// v1alpha1 only needs the conversion webhook; defaulting and validation are served
+// for v1beta1, which the API server converts v1alpha1 admission requests to.
+func SetupRacecourseWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&racecoursev1alpha1.Racecourse{}).
+		Complete()
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	racecoursev1alpha1 "github.com/mgoode/racecourse-operator/api/v1alpha1"
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse Webhook", func() {
+	var (
+		obj *racecoursev1alpha1.Racecourse
+		hub *racecoursev1beta1.Racecourse
+	)
+
+	BeforeEach(func() {
+		obj = &racecoursev1alpha1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default"},
+			Spec: racecoursev1alpha1.RacecourseSpec{
+				Replicas: ptr.To(int32(3)),
+				Image:    racecoursev1alpha1.ImageSpec{Repository: "racecourse", Tag: "1.0.0", PullPolicy: "Always"},
+				WalletService: racecoursev1alpha1.WalletServiceSpec{
+					Name:      "firefly-signer",
+					Namespace: "blockchain",
+					Port:      8545,
+				},
+				ContractAddress: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
+				Ingress: racecoursev1alpha1.IngressSpec{
+					Enabled:     true,
+					ClassName:   "nginx",
+					Host:        "racecourse.example.com",
+					Path:        "/",
+					Annotations: map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "true"},
+				},
+			},
+			Status: racecoursev1alpha1.RacecourseStatus{
+				Phase:             racecoursev1alpha1.RacecoursePhaseRunning,
+				AvailableReplicas: 3,
+				DeploymentReady:   true,
+			},
+		}
+		hub = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Replicas: ptr.To(int32(2)),
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type: racecoursev1beta1.WalletEndpointTypeURL,
+					URL:  "https://signer.example.com",
+				},
+				Ingress: racecoursev1beta1.IngressSpec{
+					Enabled: ptr.To(true),
+					Host:    "racecourse.example.com",
+					TLS: &racecoursev1beta1.IngressTLS{
+						SecretName:    "racecourse-tls",
+						ClusterIssuer: "letsencrypt-prod",
+					},
+				},
+				Chain: &racecoursev1beta1.ChainSpec{ChainID: 1337},
+			},
+		}
+	})
+
+	Context("When creating Racecourse under Conversion Webhook", func() {
+		It("Should convert a v1alpha1 Racecourse to the hub", func() {
+			converted := &racecoursev1beta1.Racecourse{}
+			Expect(obj.ConvertTo(converted)).To(Succeed())
+
+			Expect(converted.Spec.Wallet.Type).To(Equal(racecoursev1beta1.WalletEndpointTypeService))
+			Expect(converted.Spec.Wallet.Service).To(HaveValue(Equal(racecoursev1beta1.WalletServiceRef{
+				Name:      "firefly-signer",
+				Namespace: "blockchain",
+				Port:      8545,
+			})))
+			Expect(converted.Spec.Ingress.Enabled).To(HaveValue(BeTrue()))
+			Expect(converted.Spec.Ingress.TLS).To(BeNil())
+			Expect(converted.Status.Phase).To(Equal(racecoursev1beta1.RacecoursePhaseRunning))
+		})
+
+		It("Should round-trip a v1alpha1 Racecourse without an annotation", func() {
+			converted := &racecoursev1beta1.Racecourse{}
+			Expect(obj.ConvertTo(converted)).To(Succeed())
+
+			back := &racecoursev1alpha1.Racecourse{}
+			Expect(back.ConvertFrom(converted)).To(Succeed())
+			Expect(back).To(Equal(obj))
+		})
+
+		It("Should keep a disabled ingress disabled", func() {
+			obj.Spec.Ingress.Enabled = false
+			converted := &racecoursev1beta1.Racecourse{}
+			Expect(obj.ConvertTo(converted)).To(Succeed())
+			Expect(converted.Spec.Ingress.Enabled).To(HaveValue(BeFalse()))
+		})
+
+		It("Should round-trip v1beta1 fields that v1alpha1 cannot express", func() {
+			spoke := &racecoursev1alpha1.Racecourse{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Annotations).To(HaveKey(racecoursev1alpha1.ConversionDataAnnotation))
+			Expect(spoke.Spec.WalletService.Name).To(BeEmpty())
+
+			back := &racecoursev1beta1.Racecourse{}
+			Expect(spoke.ConvertTo(back)).To(Succeed())
+			Expect(back).To(Equal(hub))
+		})
+
+		It("Should let a v1alpha1 client change fields while keeping the rest", func() {
+			spoke := &racecoursev1alpha1.Racecourse{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			spoke.Spec.Replicas = ptr.To(int32(4))
+			spoke.Spec.Ingress.Host = "racecourse.example.org"
+
+			back := &racecoursev1beta1.Racecourse{}
+			Expect(spoke.ConvertTo(back)).To(Succeed())
+			Expect(back.Spec.Replicas).To(HaveValue(Equal(int32(4))))
+			Expect(back.Spec.Ingress.Host).To(Equal("racecourse.example.org"))
+			Expect(back.Spec.Ingress.TLS).To(Equal(hub.Spec.Ingress.TLS))
+			Expect(back.Spec.Wallet).To(Equal(hub.Spec.Wallet))
+		})
+
+		It("Should switch to a wallet Service set through v1alpha1", func() {
+			spoke := &racecoursev1alpha1.Racecourse{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			spoke.Spec.WalletService = racecoursev1alpha1.WalletServiceSpec{Name: "firefly-signer", Port: 8545}
+
+			back := &racecoursev1beta1.Racecourse{}
+			Expect(spoke.ConvertTo(back)).To(Succeed())
+			Expect(back.Spec.Wallet.Type).To(Equal(racecoursev1beta1.WalletEndpointTypeService))
+			Expect(back.Spec.Wallet.URL).To(BeEmpty())
+		})
+	})
+})
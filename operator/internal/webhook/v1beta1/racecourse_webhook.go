@@ -0,0 +1,363 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+// log is for logging in this package.
+var racecourselog = logf.Log.WithName("racecourse-resource")
+
+// SetupRacecourseWebhookWithManager registers the webhook for Racecourse in the manager.
+func SetupRacecourseWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&racecoursev1beta1.Racecourse{}).
+		WithValidator(&RacecourseCustomValidator{Client: mgr.GetClient()}).
+		WithDefaulter(&RacecourseCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-racecourse-kaleido-io-v1beta1-racecourse,mutating=true,failurePolicy=fail,sideEffects=None,groups=racecourse.kaleido.io,resources=racecourses,verbs=create;update,versions=v1beta1,name=mracecourse-v1beta1.kb.io,admissionReviewVersions=v1
+
+// RacecourseCustomDefaulter is responsible for setting default values on the Racecourse
+// resource when it is created or updated, so that the stored spec shows exactly what the
+// controller will run.
+type RacecourseCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &RacecourseCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Racecourse.
+func (d *RacecourseCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	racecourse, ok := obj.(*racecoursev1beta1.Racecourse)
+	if !ok {
+		return fmt.Errorf("expected a Racecourse object but got %T", obj)
+	}
+	racecourselog.Info("Defaulting for Racecourse", "name", racecourse.GetName())
+
+	// The namespace is not always set on the submitted object, so fall back to the request
+	namespace := racecourse.Namespace
+	if namespace == "" {
+		if req, err := admission.RequestFromContext(ctx); err == nil {
+			namespace = req.Namespace
+		}
+	}
+
+	spec := &racecourse.Spec
+
+	if spec.Replicas == nil {
+		replicas := racecoursev1beta1.DefaultReplicas
+		spec.Replicas = &replicas
+	}
+
+	if spec.Image.Repository == "" {
+		spec.Image.Repository = racecoursev1beta1.DefaultImageRepository
+	}
+	if spec.Image.Tag == "" {
+		spec.Image.Tag = racecoursev1beta1.DefaultImageTag
+	}
+	if spec.Image.PullPolicy == "" {
+		spec.Image.PullPolicy = racecoursev1beta1.DefaultImagePullPolicy
+	}
+
+	if spec.Wallet.Type == racecoursev1beta1.WalletEndpointTypeService && spec.Wallet.Service != nil {
+		if spec.Wallet.Service.Namespace == "" {
+			spec.Wallet.Service.Namespace = namespace
+		}
+		if spec.Wallet.Service.Port == 0 {
+			spec.Wallet.Service.Port = racecoursev1beta1.DefaultWalletServicePort
+		}
+	}
+
+	if spec.Ingress.Enabled == nil {
+		spec.Ingress.Enabled = ptr.To(true)
+	}
+
+	// Leave a disabled ingress alone so that it doesn't claim a host it isn't using
+	if spec.Ingress.IsEnabled() {
+		if spec.Ingress.ClassName == "" {
+			spec.Ingress.ClassName = racecoursev1beta1.DefaultIngressClassName
+		}
+		if spec.Ingress.Host == "" {
+			spec.Ingress.Host = racecoursev1beta1.DefaultIngressHost
+		}
+		if spec.Ingress.Path == "" {
+			spec.Ingress.Path = racecoursev1beta1.DefaultIngressPath
+		}
+		if spec.Ingress.TLS != nil && spec.Ingress.TLS.SecretName == "" {
+			spec.Ingress.TLS.SecretName = racecourse.Name + "-tls"
+		}
+	}
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-racecourse-kaleido-io-v1beta1-racecourse,mutating=false,failurePolicy=fail,sideEffects=None,groups=racecourse.kaleido.io,resources=racecourses,verbs=create;update,versions=v1beta1,name=vracecourse-v1beta1.kb.io,admissionReviewVersions=v1
+
+// RacecourseCustomValidator is responsible for validating the Racecourse resource
+// when it is created or updated. It needs a client to look up the wallet Service
+// and the other Racecourse instances in the cluster. Requests for v1alpha1 reach it
+// converted to v1beta1.
+type RacecourseCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &RacecourseCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Racecourse.
+func (v *RacecourseCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	racecourse, ok := obj.(*racecoursev1beta1.Racecourse)
+	if !ok {
+		return nil, fmt.Errorf("expected a Racecourse object but got %T", obj)
+	}
+	racecourselog.Info("Validation for Racecourse upon creation", "name", racecourse.GetName())
+
+	return nil, v.validateRacecourse(ctx, racecourse, nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Racecourse.
+func (v *RacecourseCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	racecourse, ok := newObj.(*racecoursev1beta1.Racecourse)
+	if !ok {
+		return nil, fmt.Errorf("expected a Racecourse object for the newObj but got %T", newObj)
+	}
+	oldRacecourse, ok := oldObj.(*racecoursev1beta1.Racecourse)
+	if !ok {
+		return nil, fmt.Errorf("expected a Racecourse object for the oldObj but got %T", oldObj)
+	}
+	racecourselog.Info("Validation for Racecourse upon update", "name", racecourse.GetName())
+
+	// Never block an object that is on its way out, otherwise a missing wallet
+	// Service could stop finalizers from ever being removed
+	if !racecourse.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return nil, v.validateRacecourse(ctx, racecourse, oldRacecourse)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Racecourse.
+func (v *RacecourseCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Runs every check against the spec and folds the failures into a single Invalid error.
+// The old object is nil on create.
+func (v *RacecourseCustomValidator) validateRacecourse(ctx context.Context, racecourse, old *racecoursev1beta1.Racecourse) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	allErrs = append(allErrs, validateContractAddress(racecourse.Spec.ContractAddress, specPath.Child("contractAddress"))...)
+	if interval := racecourse.Spec.ResyncInterval; interval != nil && interval.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("resyncInterval"), interval.Duration.String(), "must not be negative"))
+	}
+
+	// Only look the wallet Service up again when it has changed, so that unrelated
+	// edits are not rejected because the wallet happens to be down
+	if old == nil || !equality.Semantic.DeepEqual(old.Spec.Wallet, racecourse.Spec.Wallet) {
+		errs, err := v.validateWallet(ctx, racecourse, specPath.Child("wallet"))
+		if err != nil {
+			return err
+		}
+		allErrs = append(allErrs, errs...)
+		allErrs = append(allErrs, validateWebSocketURL(racecourse.Spec.Wallet.WebSocketURL, specPath.Child("wallet", "webSocketURL"))...)
+	}
+
+	errs, err := v.validateIngressHost(ctx, racecourse, specPath.Child("ingress", "host"))
+	if err != nil {
+		return err
+	}
+	allErrs = append(allErrs, errs...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return errors.NewInvalid(
+		racecoursev1beta1.GroupVersion.WithKind("Racecourse").GroupKind(),
+		racecourse.Name,
+		allErrs,
+	)
+}
+
+// Checks that the wallet endpoint matches its type and, for a Service, that the
+// Service exists and exposes the configured JSON-RPC port
+func (v *RacecourseCustomValidator) validateWallet(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, fldPath *field.Path) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+	wallet := racecourse.Spec.Wallet
+
+	switch wallet.Type {
+	case racecoursev1beta1.WalletEndpointTypeURL:
+		if wallet.Service != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("service"), "must not be set when type is URL"))
+		}
+		endpoint, err := url.Parse(wallet.URL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), wallet.URL, "must be an absolute http or https URL"))
+		}
+		return allErrs, nil
+	case racecoursev1beta1.WalletEndpointTypeService:
+		if wallet.URL != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("url"), "must not be set when type is Service"))
+		}
+		if wallet.Service == nil || wallet.Service.Name == "" {
+			return append(allErrs, field.Required(fldPath.Child("service", "name"), "the wallet Service name must be set")), nil
+		}
+	default:
+		return append(allErrs, field.NotSupported(fldPath.Child("type"), wallet.Type,
+			[]racecoursev1beta1.WalletEndpointType{racecoursev1beta1.WalletEndpointTypeService, racecoursev1beta1.WalletEndpointTypeURL})), nil
+	}
+
+	fldPath = fldPath.Child("service")
+	walletNamespace := wallet.Service.Namespace
+	if walletNamespace == "" {
+		walletNamespace = racecourse.Namespace
+	}
+
+	service := &corev1.Service{}
+	err := v.Client.Get(ctx, types.NamespacedName{Name: wallet.Service.Name, Namespace: walletNamespace}, service)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return append(allErrs, field.NotFound(fldPath.Child("name"),
+				fmt.Sprintf("%s/%s", walletNamespace, wallet.Service.Name))), nil
+		}
+		return nil, err
+	}
+
+	walletPort := wallet.Service.Port
+	if walletPort == 0 {
+		walletPort = racecoursev1beta1.DefaultWalletServicePort
+	}
+	for _, port := range service.Spec.Ports {
+		if port.Port == walletPort {
+			return allErrs, nil
+		}
+	}
+
+	return append(allErrs, field.Invalid(fldPath.Child("port"), walletPort,
+		fmt.Sprintf("Service %s/%s does not expose this port", walletNamespace, service.Name))), nil
+}
+
+// Checks that the WebSocket endpoint, if any, is an absolute ws or wss URL
+func validateWebSocketURL(webSocketURL string, fldPath *field.Path) field.ErrorList {
+	if webSocketURL == "" {
+		return nil
+	}
+	endpoint, err := url.Parse(webSocketURL)
+	if err != nil || (endpoint.Scheme != "ws" && endpoint.Scheme != "wss") || endpoint.Host == "" {
+		return field.ErrorList{field.Invalid(fldPath, webSocketURL, "must be an absolute ws or wss URL")}
+	}
+	return nil
+}
+
+// Checks that no other Racecourse in the cluster already serves the same ingress host
+func (v *RacecourseCustomValidator) validateIngressHost(ctx context.Context, racecourse *racecoursev1beta1.Racecourse, fldPath *field.Path) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+
+	if !racecourse.Spec.Ingress.IsEnabled() {
+		return allErrs, nil
+	}
+	host := racecourse.Spec.Ingress.HostOrDefault()
+
+	racecourses := &racecoursev1beta1.RacecourseList{}
+	if err := v.Client.List(ctx, racecourses); err != nil {
+		return nil, err
+	}
+
+	for _, other := range racecourses.Items {
+		if other.Namespace == racecourse.Namespace && other.Name == racecourse.Name {
+			continue
+		}
+		if !other.Spec.Ingress.IsEnabled() || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if strings.EqualFold(other.Spec.Ingress.HostOrDefault(), host) {
+			allErrs = append(allErrs, field.Duplicate(fldPath,
+				fmt.Sprintf("%s (already used by Racecourse %s/%s)", host, other.Namespace, other.Name)))
+			break
+		}
+	}
+
+	return allErrs, nil
+}
+
+// Checks the contract address against its EIP-55 checksum. The format itself is
+// enforced by the CRD schema, and all-lowercase or all-uppercase addresses carry
+// no checksum so they are accepted as-is.
+func validateContractAddress(address string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if address == "" {
+		return allErrs
+	}
+
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return append(allErrs, field.Invalid(fldPath, address, "must be a 0x-prefixed 40 character hex string"))
+	}
+	if _, err := hex.DecodeString(address[2:]); err != nil {
+		return append(allErrs, field.Invalid(fldPath, address, "must be a 0x-prefixed 40 character hex string"))
+	}
+
+	hexAddress := address[2:]
+	if hexAddress == strings.ToLower(hexAddress) || hexAddress == strings.ToUpper(hexAddress) {
+		return allErrs
+	}
+
+	if expected := toChecksumAddress(hexAddress); expected != address {
+		allErrs = append(allErrs, field.Invalid(fldPath, address,
+			fmt.Sprintf("invalid EIP-55 checksum, expected %s", expected)))
+	}
+
+	return allErrs
+}
+
+// Returns the EIP-55 mixed-case form of a 40 character hex address
+func toChecksumAddress(hexAddress string) string {
+	lower := strings.ToLower(hexAddress)
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		if c >= 'a' && c <= 'f' && digest[i] >= '8' {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(checksummed)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	racecoursev1beta1 "github.com/mgoode/racecourse-operator/api/v1beta1"
+)
+
+var _ = Describe("Racecourse Webhook", func() {
+	var (
+		obj       *racecoursev1beta1.Racecourse
+		oldObj    *racecoursev1beta1.Racecourse
+		validator RacecourseCustomValidator
+		defaulter RacecourseCustomDefaulter
+		wallet    *corev1.Service
+	)
+
+	BeforeEach(func() {
+		wallet = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "firefly-signer", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "rpc", Port: 8545}},
+			},
+		}
+		obj = &racecoursev1beta1.Racecourse{
+			ObjectMeta: metav1.ObjectMeta{Name: "racecourse", Namespace: "default"},
+			Spec: racecoursev1beta1.RacecourseSpec{
+				Wallet: racecoursev1beta1.WalletEndpoint{
+					Type:    racecoursev1beta1.WalletEndpointTypeService,
+					Service: &racecoursev1beta1.WalletServiceRef{Name: "firefly-signer"},
+				},
+				Ingress: racecoursev1beta1.IngressSpec{
+					Host: "racecourse.example.com",
+				},
+			},
+		}
+		oldObj = obj.DeepCopy()
+		validator = RacecourseCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(wallet).Build(),
+		}
+		defaulter = RacecourseCustomDefaulter{}
+		Expect(validator).NotTo(BeNil(), "Expected validator to be initialized")
+		Expect(defaulter).NotTo(BeNil(), "Expected defaulter to be initialized")
+		Expect(oldObj).NotTo(BeNil(), "Expected oldObj to be initialized")
+		Expect(obj).NotTo(BeNil(), "Expected obj to be initialized")
+	})
+
+	Context("When creating Racecourse under Defaulting Webhook", func() {
+		It("Should apply defaults when fields are not set", func() {
+			By("calling the Default method to apply defaults")
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			By("checking that the default values are set")
+			Expect(obj.Spec.Replicas).To(HaveValue(Equal(racecoursev1beta1.DefaultReplicas)))
+			Expect(obj.Spec.Image.Repository).To(Equal(racecoursev1beta1.DefaultImageRepository))
+			Expect(obj.Spec.Image.Tag).To(Equal(racecoursev1beta1.DefaultImageTag))
+			Expect(obj.Spec.Image.PullPolicy).To(Equal(racecoursev1beta1.DefaultImagePullPolicy))
+			Expect(obj.Spec.Wallet.Service.Namespace).To(Equal("default"))
+			Expect(obj.Spec.Wallet.Service.Port).To(Equal(racecoursev1beta1.DefaultWalletServicePort))
+			Expect(obj.Spec.Ingress.Enabled).To(HaveValue(BeTrue()))
+			Expect(obj.Spec.Ingress.ClassName).To(Equal(racecoursev1beta1.DefaultIngressClassName))
+			Expect(obj.Spec.Ingress.Host).To(Equal("racecourse.example.com"))
+			Expect(obj.Spec.Ingress.Path).To(Equal(racecoursev1beta1.DefaultIngressPath))
+			Expect(obj.Spec.Ingress.TLS).To(BeNil())
+		})
+
+		It("Should default the TLS Secret name", func() {
+			obj.Spec.Ingress.TLS = &racecoursev1beta1.IngressTLS{ClusterIssuer: "letsencrypt-prod"}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Ingress.TLS.SecretName).To(Equal("racecourse-tls"))
+		})
+
+		It("Should not default a wallet URL", func() {
+			obj.Spec.Wallet = racecoursev1beta1.WalletEndpoint{
+				Type: racecoursev1beta1.WalletEndpointTypeURL,
+				URL:  "https://signer.example.com",
+			}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Wallet.Service).To(BeNil())
+		})
+
+		It("Should keep values that are already set", func() {
+			obj.Spec.Replicas = ptr.To(int32(5))
+			obj.Spec.Image.Tag = "1.0.0"
+			obj.Spec.Wallet.Service.Namespace = "blockchain"
+			obj.Spec.Ingress.Path = "/racecourse"
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Replicas).To(HaveValue(Equal(int32(5))))
+			Expect(obj.Spec.Image.Tag).To(Equal("1.0.0"))
+			Expect(obj.Spec.Wallet.Service.Namespace).To(Equal("blockchain"))
+			Expect(obj.Spec.Ingress.Path).To(Equal("/racecourse"))
+		})
+
+		It("Should not default the ingress when it is disabled", func() {
+			obj.Spec.Ingress = racecoursev1beta1.IngressSpec{Enabled: ptr.To(false)}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Ingress.Host).To(BeEmpty())
+			Expect(obj.Spec.Ingress.Path).To(BeEmpty())
+		})
+	})
+
+	Context("When creating or updating Racecourse under Validating Webhook", func() {
+		It("Should admit a valid Racecourse", func() {
+			By("simulating a valid creation scenario")
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should deny creation if the wallet Service does not exist", func() {
+			obj.Spec.Wallet.Service.Name = "missing-signer"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.wallet.service.name"))
+		})
+
+		It("Should deny creation if the wallet Service does not expose the port", func() {
+			obj.Spec.Wallet.Service.Port = 9000
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.wallet.service.port"))
+		})
+
+		It("Should admit a wallet URL without looking up a Service", func() {
+			validator.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			obj.Spec.Wallet = racecoursev1beta1.WalletEndpoint{
+				Type: racecoursev1beta1.WalletEndpointTypeURL,
+				URL:  "https://signer.example.com:8545",
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should deny a wallet with both a Service and a URL", func() {
+			obj.Spec.Wallet.URL = "https://signer.example.com"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.wallet.url"))
+		})
+
+		It("Should deny a WebSocket endpoint that isn't a ws or wss URL", func() {
+			obj.Spec.Wallet.WebSocketURL = "wss://besu.example.com:8546"
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+
+			obj.Spec.Wallet.WebSocketURL = "ws://"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.wallet.webSocketURL"))
+		})
+
+		It("Should deny a negative resync interval", func() {
+			obj.Spec.ResyncInterval = &metav1.Duration{Duration: -time.Minute}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.resyncInterval"))
+		})
+
+		It("Should accept a correctly checksummed contract address", func() {
+			obj.Spec.ContractAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should accept a contract address without a checksum", func() {
+			obj.Spec.ContractAddress = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should deny a contract address with an invalid checksum", func() {
+			obj.Spec.ContractAddress = "0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.contractAddress"))
+		})
+
+		It("Should deny an ingress host already used by another Racecourse", func() {
+			other := obj.DeepCopy()
+			other.Name = "other-racecourse"
+			other.Namespace = "other"
+			validator.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(wallet, other).Build()
+
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.ingress.host"))
+		})
+
+		It("Should ignore host collisions when ingress is disabled", func() {
+			other := obj.DeepCopy()
+			other.Name = "other-racecourse"
+			validator.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(wallet, other).Build()
+
+			obj.Spec.Ingress.Enabled = ptr.To(false)
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should not re-check an unchanged wallet Service on update", func() {
+			validator.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeNil())
+
+			By("changing the wallet Service to one that does not exist")
+			obj.Spec.Wallet.Service.Name = "missing-signer"
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+		})
+	})
+})